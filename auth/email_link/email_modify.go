@@ -21,6 +21,7 @@ func (c *client) handleEmailModification(ctx context.Context, els *emailLinkSign
 	usr := new(users.User)
 	usr.ID = *els.UserID
 	usr.Email = newEmail
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.UserAuditActorType, ID: usr.ID, Source: applicationYamlKey})
 	err := c.userModifier.ModifyUser(users.ConfirmedEmailContext(ctx, newEmail), usr, nil)
 	if err != nil {
 		return errors.Wrapf(err, "failed to modify user %v with email modification", els.UserID)
@@ -53,7 +54,7 @@ func (c *client) handleEmailModification(ctx context.Context, els *emailLinkSign
 		}
 		resetEmailPayload, rErr := c.generateMagicLinkPayload(
 			&loginID{Email: oldEmail, DeviceUniqueID: els.DeviceUniqueID},
-			newEmail, "", resetEmailOTP, now)
+			newEmail, "", "", resetEmailOTP, now)
 		if rErr != nil {
 			return multierror.Append( //nolint:wrapcheck // .
 				errors.Wrapf(c.resetEmailModification(ctx, usr.ID, oldEmail), "[reset] resetEmailModification failed for email:%v", oldEmail),
@@ -86,6 +87,8 @@ func (c *client) resetEmailModification(ctx context.Context, userID users.UserID
 	usr.ID = userID
 	usr.Email = oldEmail
 
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.InternalAuditActorType, Source: applicationYamlKey})
+
 	return errors.Wrapf(c.userModifier.ModifyUser(users.ConfirmedEmailContext(ctx, oldEmail), usr, nil),
 		"[rollback] failed to modify user:%v", userID)
 }
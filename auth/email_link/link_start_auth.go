@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"net"
 	stdlibtime "time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -42,6 +43,11 @@ func (c *client) SendSignInLinkToEmail(ctx context.Context, emailValue, deviceUn
 			return "", errors.Wrapf(vErr, "can't validate modification email for:%#v", oldID)
 		}
 	}
+	if clientIP != "" && userIDForPhoneNumberToEmailMigration(ctx) == "" {
+		if rErr := c.assessSignInIPRisk(ctx, &id, net.ParseIP(clientIP)); rErr != nil {
+			return "", errors.Wrapf(rErr, "failed to assess the ip risk of %v for:%#v", clientIP, id)
+		}
+	}
 	otp := generateOTP()
 	confirmationCode := generateConfirmationCode()
 	loginSession, err = c.generateLoginSession(&id, confirmationCode, clientIP, loginSessionNumber)
@@ -71,7 +77,7 @@ func (c *client) SendSignInLinkToEmail(ctx context.Context, emailValue, deviceUn
 			errors.Wrapf(uErr, "failed to store/update email link sign ins for id:%#v", id),
 		).ErrorOrNil()
 	}
-	payload, pErr := c.generateMagicLinkPayload(&id, oldEmail, oldEmail, otp, now)
+	payload, pErr := c.generateMagicLinkPayload(&id, oldEmail, oldEmail, "", otp, now)
 	if pErr != nil {
 		return "", multierror.Append( //nolint:wrapcheck // .
 			errors.Wrapf(c.decrementIPLoginAttempts(ctx, clientIP, loginSessionNumber), "[rollback] failed to rollback login attempts for ip"),
@@ -127,6 +133,25 @@ func (c *client) validateEmailSignIn(ctx context.Context, id *loginID) error {
 	return nil
 }
 
+// The challenged and rejected sign ins are recorded for the account of the email, if there's one already,
+// so they're flagged and exported together with the rest of its data.
+func (c *client) assessSignInIPRisk(ctx context.Context, id *loginID, clientIP net.IP) error {
+	ipRisk, err := c.userModifier.AssessIPRisk(ctx, users.SignInIPRiskRoute, &users.DeviceID{DeviceUniqueID: id.DeviceUniqueID}, clientIP)
+	if ipRisk == nil || ipRisk.Action == users.AllowIPRiskAction {
+		return err //nolint:wrapcheck // It's wrapped by the caller.
+	}
+	userID, uErr := c.getUserIDFromEmail(ctx, id.Email, "")
+	if uErr != nil {
+		return errors.Wrapf(uErr, "failed to find the user to record the ip risk for:%#v", id)
+	}
+	ipRisk.UserID = userID
+	if rErr := c.userModifier.RecordIPRisk(ctx, ipRisk); rErr != nil {
+		log.Error(errors.Wrapf(rErr, "failed to record the ip risk for:%#v", id))
+	}
+
+	return err //nolint:wrapcheck // It's wrapped by the caller.
+}
+
 func (c *client) decrementIPLoginAttempts(ctx context.Context, ip string, loginSessionNumber int64) error {
 	if ip != "" && loginSessionNumber > 0 && userIDForPhoneNumberToEmailMigration(ctx) == "" {
 		sql := `UPDATE sign_ins_per_ip SET
@@ -259,7 +284,8 @@ func (c *client) upsertIPLoginAttempt(ctx context.Context, id *loginID, clientIP
 	return nil
 }
 
-func (c *client) generateMagicLinkPayload(id *loginID, oldEmail, notifyEmail, otp string, now *time.Time) (string, error) {
+//nolint:revive // .
+func (c *client) generateMagicLinkPayload(id *loginID, oldEmail, notifyEmail, miningBlockchainAccountAddress, otp string, now *time.Time) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, magicLinkToken{
 		RegisteredClaims: &jwt.RegisteredClaims{
 			Issuer:    jwtIssuer,
@@ -269,10 +295,11 @@ func (c *client) generateMagicLinkPayload(id *loginID, oldEmail, notifyEmail, ot
 			NotBefore: jwt.NewNumericDate(*now.Time),
 			IssuedAt:  jwt.NewNumericDate(*now.Time),
 		},
-		OTP:            otp,
-		OldEmail:       oldEmail,
-		NotifyEmail:    notifyEmail,
-		DeviceUniqueID: id.DeviceUniqueID,
+		OTP:                            otp,
+		OldEmail:                       oldEmail,
+		NotifyEmail:                    notifyEmail,
+		DeviceUniqueID:                 id.DeviceUniqueID,
+		MiningBlockchainAccountAddress: miningBlockchainAccountAddress,
 	})
 	payload, err := token.SignedString([]byte(c.cfg.EmailValidation.JwtSecret))
 	if err != nil {
@@ -36,6 +36,11 @@ func (c *client) SignIn(ctx context.Context, emailLinkPayload, confirmationCode
 	if vErr := c.verifySignIn(ctx, els, &id, emailLinkPayload, confirmationCode, token.OTP); vErr != nil {
 		return errors.Wrapf(vErr, "can't verify sign in for id:%#v", id)
 	}
+	if token.MiningBlockchainAccountAddress != "" {
+		if err = c.handleMiningBlockchainAccountAddressModification(ctx, *els.UserID, token.MiningBlockchainAccountAddress); err != nil {
+			return errors.Wrapf(err, "failed to handle mining blockchain account address modification for email:%v", email)
+		}
+	}
 	var emailConfirmed bool
 	if token.OldEmail != "" || (els.PhoneNumberToEmailMigrationUserID != nil && *els.PhoneNumberToEmailMigrationUserID != "") {
 		if err = c.handleEmailModification(ctx, els, email, token.OldEmail, token.NotifyEmail); err != nil {
@@ -59,7 +64,7 @@ func (c *client) SignIn(ctx context.Context, emailLinkPayload, confirmationCode
 		return mErr.ErrorOrNil() //nolint:wrapcheck // .
 	}
 
-	return nil
+	return errors.Wrapf(c.userModifier.RestoreUser(ctx, *els.UserID), "failed to restore user pending deletion for userID:%v", *els.UserID)
 }
 
 //nolint:revive // .
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package emaillinkiceauth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// SendMiningBlockchainAccountAddressChangeLinkToEmail sends a link to the email of the user, for the device the change was asked from.
+// Once verified (via SignIn, like any other link), it changes the mining blockchain account address of the user.
+func (c *client) SendMiningBlockchainAccountAddressChangeLinkToEmail(
+	ctx context.Context, emailValue, deviceUniqueID, language, miningBlockchainAccountAddress string,
+) (loginSession string, err error) {
+	if ctx.Err() != nil {
+		return "", errors.Wrap(ctx.Err(), "send mining blockchain account address change link to email failed because context failed")
+	}
+	id := loginID{emailValue, deviceUniqueID}
+	if vErr := c.validateEmailSignIn(ctx, &id); vErr != nil {
+		return "", errors.Wrapf(vErr, "can't validate email sign in for:%#v", id)
+	}
+	otp, confirmationCode, now := generateOTP(), generateConfirmationCode(), time.Now()
+	if loginSession, err = c.generateLoginSession(&id, confirmationCode, "", 0); err != nil {
+		return "", errors.Wrap(err, "can't call generateLoginSession")
+	}
+	if uErr := c.upsertEmailLinkSignIn(ctx, id.Email, id.DeviceUniqueID, otp, confirmationCode, now); uErr != nil {
+		if errors.Is(uErr, ErrUserDuplicate) {
+			return c.restoreOldLoginSession(ctx, &id, "", 0)
+		}
+
+		return "", errors.Wrapf(uErr, "failed to store/update email link sign ins for id:%#v", id)
+	}
+	payload, err := c.generateMagicLinkPayload(&id, "", "", miningBlockchainAccountAddress, otp, now)
+	if err != nil {
+		return "", errors.Wrapf(err, "can't generate magic link payload for id: %#v", id)
+	}
+	if sErr := c.sendEmailWithType(ctx, modifyMiningAddressEmailType, id.Email, language, c.getAuthLink(payload, language)); sErr != nil {
+		return "", errors.Wrapf(sErr, "can't send mining blockchain account address change link for id:%#v", id)
+	}
+
+	return loginSession, nil
+}
+
+func (c *client) handleMiningBlockchainAccountAddressModification(ctx context.Context, userID, miningBlockchainAccountAddress string) error {
+	usr := new(users.User)
+	usr.ID = userID
+	usr.MiningBlockchainAccountAddress = miningBlockchainAccountAddress
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.UserAuditActorType, ID: usr.ID, Source: applicationYamlKey})
+
+	return errors.Wrapf(c.userModifier.ModifyUser(users.ConfirmedMiningBlockchainAccountAddressContext(ctx, miningBlockchainAccountAddress), usr, nil),
+		"failed to modify the mining blockchain account address of user %v", userID)
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package emaillinkiceauth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+func (c *client) GetDeviceSessions(ctx context.Context, userID string) ([]*DeviceSession, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "get device sessions failed because context failed")
+	}
+	sql := `SELECT email_confirmed_at,
+				   token_issued_at,
+				   email,
+				   device_unique_id
+			FROM email_link_sign_ins
+			WHERE user_id = $1
+				  AND token_issued_at IS NOT NULL
+			ORDER BY token_issued_at DESC`
+	sessions, err := storage.Select[DeviceSession](ctx, c.db, sql, userID)
+
+	return sessions, errors.Wrapf(err, "failed to select device sessions for userID:%v", userID)
+}
+
+// RevokeDeviceSession moves the refresh token sequences of the device past all the issued ones, so none of them can be refreshed anymore.
+// The access tokens that were already issued are still valid until they expire.
+// It covers only the email link sessions, the Firebase ones are managed by Firebase and can't be revoked per device.
+func (c *client) RevokeDeviceSession(ctx context.Context, userID, deviceUniqueID string) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "revoke device session failed because context failed")
+	}
+	sql := `UPDATE email_link_sign_ins
+			SET issued_token_seq = COALESCE(issued_token_seq, 0) + 1,
+				previously_issued_token_seq = COALESCE(issued_token_seq, 0) + 1
+			WHERE user_id = $1
+				  AND device_unique_id = $2`
+	updated, err := storage.Exec(ctx, c.db, sql, userID, deviceUniqueID)
+	if err == nil && updated == 0 {
+		err = ErrUserNotFound
+	}
+
+	return errors.Wrapf(err, "failed to revoke session of device:%v for userID:%v", deviceUniqueID, userID)
+}
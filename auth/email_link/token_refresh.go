@@ -38,6 +38,9 @@ func (c *client) RegenerateTokens(ctx context.Context, previousRefreshToken stri
 		return nil, errors.Wrapf(ErrUserDataMismatch,
 			"user's email:%v does not match token's email:%v or deviceID:%v (userID %v)", usr.Email, token.Email, token.DeviceUniqueID, token.Subject)
 	}
+	if !usr.isRefreshTokenSeqValid(token.Seq) {
+		return nil, errors.Wrapf(ErrInvalidToken, "refreshToken with revoked or outdated sequence:%v provided (userID:%v)", token.Seq, token.Subject)
+	}
 	now := time.Now()
 	refreshTokenSeq, err := c.incrementRefreshTokenSeq(ctx, &id, token.Subject, token.Seq, now)
 	if err != nil {
@@ -83,6 +86,12 @@ func (c *client) incrementRefreshTokenSeq(
 	return updatedValue.IssuedTokenSeq, nil
 }
 
+// It's the same condition incrementRefreshTokenSeq enforces, checked upfront so the revoked or outdated tokens don't even get to the update:
+// the last issued token can be refreshed and so can the ones issued since the previous refresh, while their refreshes race.
+func (s *emailLinkSignIn) isRefreshTokenSeqValid(seq int64) bool {
+	return s.IssuedTokenSeq == seq || (s.PreviouslyIssuedTokenSeq <= seq && s.PreviouslyIssuedTokenSeq <= s.IssuedTokenSeq+1)
+}
+
 func (c *client) generateTokens(now *time.Time, els *emailLinkSignIn, seq int64) (tokens *Tokens, err error) {
 	role := ""
 	if els.Metadata != nil {
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package emaillinkiceauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailLinkSignInIsRefreshTokenSeqValid(t *testing.T) {
+	t.Parallel()
+
+	signIn := &emailLinkSignIn{IssuedTokenSeq: 5, PreviouslyIssuedTokenSeq: 3}
+	assert.True(t, signIn.isRefreshTokenSeqValid(5))
+	assert.True(t, signIn.isRefreshTokenSeqValid(4))
+	assert.True(t, signIn.isRefreshTokenSeqValid(3))
+	assert.False(t, signIn.isRefreshTokenSeqValid(2))
+	assert.False(t, signIn.isRefreshTokenSeqValid(0))
+}
+
+func TestEmailLinkSignInIsRefreshTokenSeqValidAfterRevocation(t *testing.T) {
+	t.Parallel()
+
+	for _, signIn := range []*emailLinkSignIn{
+		{IssuedTokenSeq: 1, PreviouslyIssuedTokenSeq: 1},
+		{IssuedTokenSeq: 5, PreviouslyIssuedTokenSeq: 3},
+		{IssuedTokenSeq: 5, PreviouslyIssuedTokenSeq: 5},
+	} {
+		issuedSeqs := make([]int64, 0, signIn.IssuedTokenSeq+1)
+		for seq := int64(0); seq <= signIn.IssuedTokenSeq; seq++ {
+			issuedSeqs = append(issuedSeqs, seq)
+		}
+		// That's what RevokeDeviceSession does.
+		signIn.PreviouslyIssuedTokenSeq = signIn.IssuedTokenSeq + 1
+		signIn.IssuedTokenSeq++
+		for _, seq := range issuedSeqs {
+			assert.False(t, signIn.isRefreshTokenSeqValid(seq), "seq:%v of %#v", seq, signIn)
+		}
+	}
+}
@@ -281,3 +281,12 @@ func (c *client) Metadata(ctx context.Context, userID, tokenEmail string) (strin
 
 	return encoded, md.Metadata, nil
 }
+
+func (c *client) ExportUserData(ctx context.Context, userID users.UserID) (*users.UserDataExportSection, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "export user data failed because context failed")
+	}
+	section, err := users.SelectUserDataExportSection(ctx, c.db, userDataExportSectionName, userDataExportTableQueries, userID)
+
+	return section, errors.Wrapf(err, "failed to export email link data for userID:%v", userID)
+}
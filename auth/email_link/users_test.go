@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package emaillinkiceauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDataExportTableQueries(t *testing.T) {
+	t.Parallel()
+
+	tables := make([]string, 0, len(userDataExportTableQueries))
+	for table, sql := range userDataExportTableQueries {
+		tables = append(tables, table)
+		assert.Contains(t, sql, "FROM "+table, table)
+		assert.Contains(t, sql, "WHERE user_id = $1", table)
+	}
+	assert.ElementsMatch(t, []string{"email_link_sign_ins", "account_metadata"}, tables)
+	// The sign ins hold the secrets of the login flow, so they must never be exported as a whole.
+	assert.NotContains(t, userDataExportTableQueries["email_link_sign_ins"], "SELECT *")
+	assert.NotContains(t, userDataExportTableQueries["email_link_sign_ins"], "otp")
+	assert.NotContains(t, userDataExportTableQueries["email_link_sign_ins"], "confirmation_code")
+}
@@ -44,7 +44,7 @@ func (s *service) setupAuthRoutes(router *server.Router) {
 //	@Param			X-User-ID		header		string							false	"UserID to process phone number migration for"	default()
 //	@Param			X-Forwarded-For	header		string							false	"Client IP"										default(1.1.1.1)
 //	@Success		200				{object}	Auth
-//	@Failure		403				{object}	server.ErrorResponse	"if too many pending auth requests from one IP"
+//	@Failure		403				{object}	server.ErrorResponse	"if too many pending auth requests from one IP or if the IP is rejected by the risk policy"
 //	@Failure		409				{object}	server.ErrorResponse	"if email conflicts with another user's"
 //	@Failure		422				{object}	server.ErrorResponse	"if syntax fails"
 //	@Failure		500				{object}	server.ErrorResponse
@@ -77,6 +77,8 @@ func (s *service) SendSignInLinkToEmail( //nolint:gocritic,funlen // .
 			if tErr := terror.As(err); tErr != nil {
 				return nil, server.ForbiddenWithCode(err, tooManyRequests, tErr.Data)
 			}
+		case errors.Is(err, users.ErrIPRejected):
+			return nil, server.ForbiddenWithCode(err, ipRejectedErrorCode)
 		default:
 			return nil, server.Unexpected(errors.Wrapf(err, "failed to start email link auth %#v", req.Data))
 		}
@@ -256,6 +258,9 @@ func (s *service) Metadata(
 		} else if updMD != "" {
 			md = updMD
 		}
+		if err = s.usersProcessor.RestoreUser(ctx, req.AuthenticatedUser.UserID); err != nil {
+			return nil, server.Unexpected(errors.Wrapf(err, "failed to restore user pending deletion for userID:%v", req.AuthenticatedUser.UserID))
+		}
 	}
 
 	return server.OK(&Metadata{Metadata: md, UserID: req.AuthenticatedUser.UserID}), nil
@@ -369,7 +374,7 @@ func (s *service) updateMetadataWithFirebaseID(
 // ProcessFaceRecognitionResult godoc
 //
 //	@Schemes
-//	@Description	Webhook to notify the service about the result of an user's face authentication process.
+//	@Description	Webhook to notify the service about the result of an user's face authentication process. The accounts flagged for a device risk configured to block the KYC are blocked at liveness detection, the same way the potentially duplicate ones are.
 //	@Tags			Auth
 //	@Accept			json
 //	@Produce		json
@@ -400,6 +405,15 @@ func (s *service) ProcessFaceRecognitionResult(
 
 		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
 	}
+	if *usr.KYCStepBlocked == users.NoneKYCStep && *usr.KYCStepPassed > users.NoneKYCStep {
+		if blocked, bErr := s.usersProcessor.IsKYCBlockedByDeviceRisk(ctx, usr.ID); bErr != nil {
+			return nil, server.Unexpected(errors.Wrapf(bErr, "failed to check device risk for userID:%v", usr.ID))
+		} else if blocked {
+			kycStepBlocked := users.LivenessDetectionKYCStep
+			usr.KYCStepBlocked = &kycStepBlocked
+		}
+	}
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.APIKeyAuditActorType, Source: applicationYamlKey})
 	if err = s.usersProcessor.ModifyUser(ctx, usr, nil); err != nil {
 		err = errors.Wrapf(err, "failed to UpdateFaceRecognitionResult for %#v", usr)
 		switch {
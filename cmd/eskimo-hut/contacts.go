@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/server"
+)
+
+func (s *service) setupContactsRoutes(router *server.Router) {
+	router.
+		Group("v1w").
+		POST("users/:userId/contacts/sync", server.RootHandler(s.SyncContacts))
+}
+
+// SyncContacts godoc
+//
+//	@Schemes
+//	@Description	Syncs the agenda contacts of the user. `replace` mode replaces all of them, `delta` mode adds/removes the provided ones and requires the `version` returned by the last sync.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string					true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string					false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string					true	"ID of the user"
+//	@Param			request				body		SyncContactsRequestBody	true	"Request params"
+//	@Success		200					{object}	users.ContactsSyncResult
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if user not found"
+//	@Failure		409					{object}	server.ErrorResponse	"if the version is not the current one"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/contacts/sync [POST].
+func (s *service) SyncContacts( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[SyncContactsRequestBody, users.ContactsSyncResult],
+) (*server.Response[users.ContactsSyncResult], *server.Response[server.ErrorResponse]) {
+	sync := &users.ContactsSync{
+		Mode:                     users.ContactsSyncMode(req.Data.Mode),
+		PhoneNumberHashes:        req.Data.PhoneNumberHashes,
+		AddedPhoneNumberHashes:   req.Data.AddedPhoneNumberHashes,
+		RemovedPhoneNumberHashes: req.Data.RemovedPhoneNumberHashes,
+		Version:                  req.Data.Version,
+	}
+	result, err := s.usersProcessor.SyncContacts(ctx, req.Data.UserID, sync)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to sync contacts for userID:%v", req.Data.UserID)
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		case errors.Is(err, users.ErrInvalidContactsSyncMode):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrContactsSyncVersionMismatch):
+			return nil, server.Conflict(err, contactsSyncVersionMismatchErrorCode)
+		case errors.Is(err, users.ErrTooManyContacts):
+			return nil, server.BadRequest(err, tooManyContactsErrorCode)
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.OK(result), nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	emaillink "github.com/ice-blockchain/eskimo/auth/email_link"
+	"github.com/ice-blockchain/eskimo/cmd/eskimo-hut/internal/devices"
 	kycquiz "github.com/ice-blockchain/eskimo/kyc/quiz"
 	kycsocial "github.com/ice-blockchain/eskimo/kyc/social"
 	"github.com/ice-blockchain/eskimo/users"
@@ -89,18 +90,67 @@ type (
 		MiningBlockchainAccountAddress string `form:"miningBlockchainAccountAddress" formMultipart:"miningBlockchainAccountAddress"`
 		// Optional. Example:`en`.
 		Language string `form:"language" formMultipart:"language"`
-		// Optional. Example:`1232412415326543647657`.
+		// Deprecated. It's ignored, use the `If-Match` header instead. Example:`1232412415326543647657`.
 		Checksum string `form:"checksum" formMultipart:"checksum"`
+		IfMatch  string `header:"If-Match" swaggerignore:"true"` //nolint:tagliatelle // Nope.
+	}
+	SyncContactsRequestBody struct {
+		UserID string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Mode   string `json:"mode" required:"true" example:"delta" enums:"replace,delta"`
+		// Used only in `replace` mode: the hashes of all the phone numbers in the agenda.
+		PhoneNumberHashes []string `json:"phoneNumberHashes" example:"Ef86A6021afCDe5673511376B2"`
+		// Used only in `delta` mode.
+		AddedPhoneNumberHashes []string `json:"addedPhoneNumberHashes" example:"Ef86A6021afCDe5673511376B2"`
+		// Used only in `delta` mode.
+		RemovedPhoneNumberHashes []string `json:"removedPhoneNumberHashes" example:"Ef86A6021afCDe5673511376B2"`
+		// Required in `delta` mode: the version returned by the last sync.
+		Version uint64 `json:"version" example:"3"`
+	}
+	CreateWalletOwnershipChallengeRequestBody struct {
+		UserID  string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Address string `json:"address" required:"true" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	VerifyWalletOwnershipRequestBody struct {
+		UserID  string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Address string `json:"address" required:"true" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// EIP-191 `personal_sign` signature for EVM addresses; ed25519 signature for TON/ION addresses. Hex or base64.
+		Signature string `json:"signature" required:"true" example:"0x2c6401216c9031b9a6fb8cbfccab4fcec6c951cdf40e2320108d1856eb532250576865fbcd452bcdc4c57321b619ed7a9cfd38bd973c3e1e0243ac2777fe9d5b1b"` //nolint:lll // .
+		// Required only for TON/ION addresses: the ed25519 public key of the wallet. Hex or base64.
+		PublicKey string `json:"publicKey" example:"7c6c9d3cbd5a2e7cc4ef1a9d7bdb0bbfd5f1f0a2b5f6a7e8e6e9d1c2b3a4f5e6"`
+		// Required only for TON/ION addresses: the state init (bag of cells) of the wallet, as provided by TON Connect. Hex or base64.
+		StateInit string `json:"stateInit" example:"te6cckECBAEAAEcAAgE0AQMBFndhbGxldCBjb2RlAgAObGlicmFyeQBRAAAAAAAAAAB8bJ08vVoufMTvGp172wu/1fHworX2p+jm6dHCs6T15kDSoyyS"` //nolint:lll // .
+	}
+	BlockUserArg struct {
+		UserID        string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		BlockedUserID string `uri:"blockedUserId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	ReportUserRequestBody struct {
+		UserID         string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		ReportedUserID string `json:"reportedUserId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Reason         string `json:"reason" required:"true" example:"spam" enums:"spam,harassment,impersonation,inappropriateContent,other"`
+		// Optional, at most 1000 characters.
+		Comment string `json:"comment" example:"keeps sending me links"`
 	}
 	DeleteUserArg struct {
 		UserID string `uri:"userId" required:"true" allowForbiddenWriteOperation:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 	}
+	ExportUserDataArg struct {
+		UserID string `uri:"userId" allowForbiddenGet:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
 	GetDeviceLocationArg struct {
 		// Optional. Set it to `-` if unknown.
 		UserID string `uri:"userId" required:"true" allowUnauthorized:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 		// Optional. Set it to `-` if unknown.
 		DeviceUniqueID string `uri:"deviceUniqueId" required:"true" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
 	}
+	ListDevicesArg struct {
+		UserID string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	RevokeDeviceArg struct {
+		UserID         string `uri:"userId" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		DeviceUniqueID string `uri:"deviceUniqueId" required:"true" swaggerignore:"true" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
+	}
+	Device                           = devices.Device
 	ReplaceDeviceMetadataRequestBody struct {
 		UserID         string `uri:"userId" allowUnauthorized:"true" required:"true" swaggerignore:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"` //nolint:lll // .
 		DeviceUniqueID string `uri:"deviceUniqueId" required:"true" swaggerignore:"true" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
@@ -164,23 +214,34 @@ type (
 const (
 	applicationYamlKey = "cmd/eskimo-hut"
 	swaggerRoot        = "/users/w"
+	etagHeader         = "ETag"
 )
 
 // Values for server.ErrorResponse#Code.
 const (
-	deviceMetadataAppUpdateRequireErrorCode = "UPDATE_REQUIRED"
-	invalidUsernameErrorCode                = "INVALID_USERNAME"
-	userNotFoundErrorCode                   = "USER_NOT_FOUND"
-	metadataNotFoundErrorCode               = "METADATA_NOT_FOUND"
-	userBlockedErrorCode                    = "USER_BLOCKED"
-	duplicateUserErrorCode                  = "CONFLICT_WITH_ANOTHER_USER"
-	referralNotFoundErrorCode               = "REFERRAL_NOT_FOUND"
-	raceConditionErrorCode                  = "RACE_CONDITION"
-	invalidPropertiesErrorCode              = "INVALID_PROPERTIES"
-	invalidEmail                            = "INVALID_EMAIL"
-	emailUsedBySomebodyElseEmail            = "EMAIL_USED_BY_SOMEBODY_ELSE"
-	emailAlreadySetErrorCode                = "EMAIL_ALREADY_SET"
-	accountLostErrorCode                    = "ACCOUNT_LOST"
+	deviceMetadataAppUpdateRequireErrorCode   = "UPDATE_REQUIRED"
+	invalidUsernameErrorCode                  = "INVALID_USERNAME"
+	userNotFoundErrorCode                     = "USER_NOT_FOUND"
+	metadataNotFoundErrorCode                 = "METADATA_NOT_FOUND"
+	userBlockedErrorCode                      = "USER_BLOCKED"
+	duplicateUserErrorCode                    = "CONFLICT_WITH_ANOTHER_USER"
+	referralNotFoundErrorCode                 = "REFERRAL_NOT_FOUND"
+	raceConditionErrorCode                    = "RACE_CONDITION"
+	invalidPropertiesErrorCode                = "INVALID_PROPERTIES"
+	invalidEmail                              = "INVALID_EMAIL"
+	emailUsedBySomebodyElseEmail              = "EMAIL_USED_BY_SOMEBODY_ELSE"
+	emailAlreadySetErrorCode                  = "EMAIL_ALREADY_SET"
+	accountLostErrorCode                      = "ACCOUNT_LOST"
+	contactsSyncVersionMismatchErrorCode      = "CONTACTS_SYNC_VERSION_MISMATCH"
+	tooManyContactsErrorCode                  = "TOO_MANY_CONTACTS"
+	miningAddressChangeCooldownErrorCode      = "MINING_ADDRESS_CHANGE_COOLDOWN"
+	emailConfirmationRequiredErrorCode        = "EMAIL_CONFIRMATION_REQUIRED"
+	walletOwnershipChallengeNotFoundErrorCode = "WALLET_OWNERSHIP_CHALLENGE_NOT_FOUND"
+	invalidSignatureErrorCode                 = "INVALID_SIGNATURE"
+	usernameChangeLimitReachedErrorCode       = "USERNAME_CHANGE_LIMIT_REACHED"
+	usernameQuarantinedErrorCode              = "USERNAME_QUARANTINED"
+	deviceNotFoundErrorCode                   = "DEVICE_NOT_FOUND"
+	ipRejectedErrorCode                       = "IP_REJECTED"
 
 	linkExpiredErrorCode    = "EXPIRED_LINK"
 	invalidOTPCodeErrorCode = "INVALID_OTP"
@@ -220,8 +281,9 @@ type (
 		socialRepository    kycsocial.Repository
 	}
 	config struct {
-		APIKey  string `yaml:"api-key" mapstructure:"api-key"` //nolint:tagliatelle // Nope.
-		Host    string `yaml:"host"`
-		Version string `yaml:"version"`
+		APIKey         string `yaml:"api-key" mapstructure:"api-key"` //nolint:tagliatelle // Nope.
+		Host           string `yaml:"host"`
+		Version        string `yaml:"version"`
+		EnforceIfMatch bool   `yaml:"enforceIfMatch"`
 	}
 )
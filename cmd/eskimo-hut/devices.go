@@ -7,6 +7,8 @@ import (
 
 	"github.com/pkg/errors"
 
+	emaillink "github.com/ice-blockchain/eskimo/auth/email_link"
+	"github.com/ice-blockchain/eskimo/cmd/eskimo-hut/internal/devices"
 	"github.com/ice-blockchain/eskimo/users"
 	"github.com/ice-blockchain/wintr/server"
 )
@@ -15,13 +17,15 @@ func (s *service) setupDevicesRoutes(router *server.Router) {
 	router.
 		Group("v1w").
 		PUT("users/:userId/devices/:deviceUniqueId/metadata", server.RootHandler(s.ReplaceDeviceMetadata)).
-		PUT("users/:userId/devices/:deviceUniqueId/metadata/location", server.RootHandler(s.GetDeviceLocation))
+		PUT("users/:userId/devices/:deviceUniqueId/metadata/location", server.RootHandler(s.GetDeviceLocation)).
+		GET("users/:userId/devices", server.RootHandler(s.ListDevices)).
+		DELETE("users/:userId/devices/:deviceUniqueId", server.RootHandler(s.RevokeDevice))
 }
 
 // ReplaceDeviceMetadata godoc
 //
 //	@Schemes
-//	@Description	Replaces existing device metadata with the provided one.
+//	@Description	Replaces existing device metadata with the provided one. It also tells the app if an update is recommended.
 //	@Tags			Devices
 //	@Accept			json
 //	@Produce		json
@@ -31,10 +35,10 @@ func (s *service) setupDevicesRoutes(router *server.Router) {
 //	@Param			userId				path	string								true	"ID of the user"
 //	@Param			deviceUniqueId		path	string								true	"ID of the device"
 //	@Param			request				body	ReplaceDeviceMetadataRequestBody	true	"Request params"
-//	@Success		200					"OK"
+//	@Success		200					{object}	users.AppVersionStatus
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
-//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed or if the IP is rejected by the risk policy"
 //	@Failure		404					{object}	server.ErrorResponse	"if user not found"
 //	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
 //	@Failure		500					{object}	server.ErrorResponse
@@ -42,8 +46,8 @@ func (s *service) setupDevicesRoutes(router *server.Router) {
 //	@Router			/users/{userId}/devices/{deviceUniqueId}/metadata [PUT].
 func (s *service) ReplaceDeviceMetadata( //nolint:gocritic // False negative.
 	ctx context.Context,
-	req *server.Request[ReplaceDeviceMetadataRequestBody, any],
-) (*server.Response[any], *server.Response[server.ErrorResponse]) {
+	req *server.Request[ReplaceDeviceMetadataRequestBody, users.AppVersionStatus],
+) (*server.Response[users.AppVersionStatus], *server.Response[server.ErrorResponse]) {
 	req.Data.DeviceMetadata.ID.DeviceUniqueID = req.Data.DeviceUniqueID
 	req.Data.DeviceMetadata.ID.UserID = req.Data.UserID
 	if req.AuthenticatedUser.UserID == "" && req.Data.DeviceMetadata.ID.UserID != "" && req.Data.DeviceMetadata.ID.UserID != "-" {
@@ -58,12 +62,17 @@ func (s *service) ReplaceDeviceMetadata( //nolint:gocritic // False negative.
 			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
 		case errors.Is(err, users.ErrOutdatedAppVersion):
 			return nil, server.BadRequest(err, deviceMetadataAppUpdateRequireErrorCode)
+		case errors.Is(err, users.ErrIPRejected):
+			return nil, server.ForbiddenWithCode(err, ipRejectedErrorCode)
 		default:
 			return nil, server.Unexpected(err)
 		}
 	}
+	if req.Data.DeviceMetadata.AppVersionStatus == nil {
+		req.Data.DeviceMetadata.AppVersionStatus = new(users.AppVersionStatus)
+	}
 
-	return server.OK[any](), nil
+	return server.OK(req.Data.DeviceMetadata.AppVersionStatus), nil
 }
 
 // GetDeviceLocation godoc
@@ -100,3 +109,80 @@ func (s *service) GetDeviceLocation( //nolint:gocritic // False negative.
 
 	return server.OK(s.usersProcessor.GetDeviceMetadataLocation(ctx, deviceID, req.ClientIP)), nil
 }
+
+// ListDevices godoc
+//
+//	@Schemes
+//	@Description	Returns the devices of the user, with their sign in sessions, most recently used first. Only the email link sessions are listed, the devices signed in with Firebase have no session.
+//	@Tags			Devices
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the user"
+//	@Success		200					{array}		Device
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/devices [GET].
+func (s *service) ListDevices( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[ListDevicesArg, []*Device],
+) (*server.Response[[]*Device], *server.Response[server.ErrorResponse]) {
+	metadata, err := s.usersProcessor.GetAllDeviceMetadata(ctx, req.Data.UserID)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get device metadata for %#v", req.Data))
+	}
+	sessions, err := s.authEmailLinkClient.GetDeviceSessions(ctx, req.Data.UserID)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get device sessions for %#v", req.Data))
+	}
+	currentDeviceUniqueID, _ := req.AuthenticatedUser.Claims[deviceIDTokenClaim].(string)
+
+	userDevices := devices.Merge(metadata, sessions, currentDeviceUniqueID)
+
+	return server.OK(&userDevices), nil
+}
+
+// RevokeDevice godoc
+//
+//	@Schemes
+//	@Description	Signs the device out remotely: its refresh tokens can't be used anymore and its metadata is deleted. Already issued access tokens are valid until they expire. The Firebase sessions can't be revoked per device, for those devices only the metadata is deleted.
+//	@Tags			Devices
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header	string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header	string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path	string	true	"ID of the user"
+//	@Param			deviceUniqueId		path	string	true	"ID of the device"
+//	@Success		200					"OK"
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if device not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/devices/{deviceUniqueId} [DELETE].
+func (s *service) RevokeDevice( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[RevokeDeviceArg, any],
+) (*server.Response[any], *server.Response[server.ErrorResponse]) {
+	sessionErr := s.authEmailLinkClient.RevokeDeviceSession(ctx, req.Data.UserID, req.Data.DeviceUniqueID)
+	if sessionErr != nil && !errors.Is(sessionErr, emaillink.ErrUserNotFound) {
+		return nil, server.Unexpected(errors.Wrapf(sessionErr, "failed to revoke device session for %#v", req.Data))
+	}
+	deviceID := &users.DeviceID{UserID: req.Data.UserID, DeviceUniqueID: req.Data.DeviceUniqueID}
+	metadataErr := s.usersProcessor.DeleteDeviceMetadata(ctx, deviceID)
+	if metadataErr != nil && !errors.Is(metadataErr, users.ErrNotFound) {
+		return nil, server.Unexpected(errors.Wrapf(metadataErr, "failed to delete device metadata for %#v", req.Data))
+	}
+	if sessionErr != nil && metadataErr != nil {
+		return nil, server.NotFound(errors.Wrapf(metadataErr, "device not found for %#v", req.Data), deviceNotFoundErrorCode)
+	}
+
+	return server.OK[any](), nil
+}
@@ -45,11 +45,14 @@ func (s *service) RegisterRoutes(router *server.Router) {
 	s.setupKYCRoutes(router)
 	s.setupUserRoutes(router)
 	s.setupDevicesRoutes(router)
+	s.setupContactsRoutes(router)
+	s.setupWalletOwnershipRoutes(router)
+	s.setupModerationRoutes(router)
 	s.setupAuthRoutes(router)
 }
 
 func (s *service) Init(ctx context.Context, cancel context.CancelFunc) {
-	s.usersProcessor = users.StartProcessor(ctx, cancel)
+	s.usersProcessor = users.StartProcessor(ctx, cancel, server.Auth(ctx))
 	s.authEmailLinkClient = emaillink.NewClient(ctx, s.usersProcessor, server.Auth(ctx))
 	s.socialRepository = social.New(ctx, s.usersProcessor)
 	s.quizRepository = kycquiz.NewRepository(ctx, s.usersProcessor)
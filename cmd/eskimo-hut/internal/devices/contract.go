@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devices
+
+import (
+	emaillink "github.com/ice-blockchain/eskimo/auth/email_link"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Public API.
+
+type (
+	// Device is what's known about a device of the user: its metadata, if it was ever sent, and its sign in session, if any.
+	Device struct {
+		UpdatedAt      *time.Time               `json:"updatedAt,omitempty" example:"2022-01-03T16:20:52.156534Z"`
+		Session        *emaillink.DeviceSession `json:"session,omitempty"`
+		DeviceUniqueID string                   `json:"deviceUniqueId" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
+		DeviceName     string                   `json:"deviceName,omitempty" example:"John's iPhone"`
+		Brand          string                   `json:"brand,omitempty" example:"Apple"`
+		Model          string                   `json:"model,omitempty" example:"iPhone15,2"`
+		SystemName     string                   `json:"systemName,omitempty" example:"iOS"`
+		SystemVersion  string                   `json:"systemVersion,omitempty" example:"17.1"`
+		Country        string                   `json:"country,omitempty" example:"US"`
+		City           string                   `json:"city,omitempty" example:"New York"`
+		// Whether it's the device that made the request.
+		Current bool `json:"current" example:"true"`
+	}
+)
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devices
+
+import (
+	emaillink "github.com/ice-blockchain/eskimo/auth/email_link"
+	"github.com/ice-blockchain/eskimo/users"
+)
+
+// Merge combines the metadata and the sessions of the same device.
+// The devices with metadata come first, in the order of the metadata, followed by the ones that have only a session.
+func Merge(metadata []*users.DeviceMetadata, sessions []*emaillink.DeviceSession, currentDeviceUniqueID string) []*Device {
+	devices := make([]*Device, 0, len(metadata)+len(sessions))
+	devicesByID := make(map[string]*Device, cap(devices))
+	for _, dm := range metadata {
+		dev := &Device{
+			UpdatedAt:      dm.UpdatedAt,
+			DeviceUniqueID: dm.DeviceUniqueID,
+			DeviceName:     dm.DeviceName,
+			Brand:          dm.Brand,
+			Model:          dm.DeviceID,
+			SystemName:     dm.SystemName,
+			SystemVersion:  dm.SystemVersion,
+			Country:        dm.CountryShort,
+			City:           dm.City,
+		}
+		devices = append(devices, dev)
+		devicesByID[dev.DeviceUniqueID] = dev
+	}
+	for _, session := range sessions {
+		dev, found := devicesByID[session.DeviceUniqueID]
+		if !found {
+			dev = &Device{DeviceUniqueID: session.DeviceUniqueID}
+			devices = append(devices, dev)
+			devicesByID[dev.DeviceUniqueID] = dev
+		}
+		if dev.Session == nil {
+			dev.Session = session
+		}
+	}
+	for _, dev := range devices {
+		dev.Current = currentDeviceUniqueID != "" && dev.DeviceUniqueID == currentDeviceUniqueID
+	}
+
+	return devices
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devices
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	emaillink "github.com/ice-blockchain/eskimo/auth/email_link"
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	updatedAt := time.New(stdlibtime.Date(2024, 1, 2, 3, 4, 5, 0, stdlibtime.UTC))
+	metadata := []*users.DeviceMetadata{
+		{
+			UpdatedAt:  updatedAt,
+			ID:         users.DeviceID{UserID: "bogus", DeviceUniqueID: "phone"},
+			DeviceID:   "iPhone15,2",
+			DeviceName: "John's iPhone",
+			Brand:      "Apple",
+			SystemName: "iOS",
+		},
+		{ID: users.DeviceID{UserID: "bogus", DeviceUniqueID: "tablet"}},
+	}
+	metadata[0].City = "New York"
+	sessions := []*emaillink.DeviceSession{
+		{Email: "second@example.com", DeviceUniqueID: "laptop"},
+		{Email: "first@example.com", DeviceUniqueID: "phone"},
+		{Email: "second@example.com", DeviceUniqueID: "phone"},
+	}
+
+	devices := Merge(metadata, sessions, "laptop")
+	require.Len(t, devices, 3)
+
+	assert.Equal(t, "phone", devices[0].DeviceUniqueID)
+	assert.Equal(t, updatedAt, devices[0].UpdatedAt)
+	assert.Equal(t, "iPhone15,2", devices[0].Model)
+	assert.Equal(t, "John's iPhone", devices[0].DeviceName)
+	assert.Equal(t, "New York", devices[0].City)
+	assert.Same(t, sessions[1], devices[0].Session)
+	assert.False(t, devices[0].Current)
+
+	assert.Equal(t, "tablet", devices[1].DeviceUniqueID)
+	assert.Nil(t, devices[1].Session)
+	assert.False(t, devices[1].Current)
+
+	assert.Equal(t, "laptop", devices[2].DeviceUniqueID)
+	assert.Nil(t, devices[2].UpdatedAt)
+	assert.Same(t, sessions[0], devices[2].Session)
+	assert.True(t, devices[2].Current)
+}
+
+func TestMergeWithoutCurrentDevice(t *testing.T) {
+	t.Parallel()
+
+	devices := Merge(nil, []*emaillink.DeviceSession{{DeviceUniqueID: ""}}, "")
+	require.Len(t, devices, 1)
+	assert.False(t, devices[0].Current)
+	assert.Empty(t, Merge(nil, nil, "phone"))
+}
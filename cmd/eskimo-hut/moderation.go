@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/server"
+)
+
+func (s *service) setupModerationRoutes(router *server.Router) {
+	router.
+		Group("v1w").
+		PUT("users/:userId/blocked-users/:blockedUserId", server.RootHandler(s.BlockUser)).
+		DELETE("users/:userId/blocked-users/:blockedUserId", server.RootHandler(s.UnblockUser)).
+		POST("users/:userId/reports", server.RootHandler(s.ReportUser))
+}
+
+// BlockUser godoc
+//
+//	@Schemes
+//	@Description	Blocks an user. Both users are hidden from each other's searches and removed from each other's contacts.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header	string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header	string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path	string	true	"ID of the user"
+//	@Param			blockedUserId		path	string	true	"ID of the user to block"
+//	@Success		200					"OK"
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if user not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/blocked-users/{blockedUserId} [PUT].
+func (s *service) BlockUser( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[BlockUserArg, any],
+) (*server.Response[any], *server.Response[server.ErrorResponse]) {
+	if err := s.usersProcessor.BlockUser(ctx, req.Data.UserID, req.Data.BlockedUserID); err != nil {
+		err = errors.Wrapf(err, "failed to block user for %#v", req.Data)
+		switch {
+		case errors.Is(err, users.ErrTargetingYourself):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.OK[any](), nil
+}
+
+// UnblockUser godoc
+//
+//	@Schemes
+//	@Description	Unblocks an user. The contacts removed when blocking are matched again on the next contacts sync.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header	string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header	string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path	string	true	"ID of the user"
+//	@Param			blockedUserId		path	string	true	"ID of the user to unblock"
+//	@Success		200					"OK"
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/blocked-users/{blockedUserId} [DELETE].
+func (s *service) UnblockUser( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[BlockUserArg, any],
+) (*server.Response[any], *server.Response[server.ErrorResponse]) {
+	if err := s.usersProcessor.UnblockUser(ctx, req.Data.UserID, req.Data.BlockedUserID); err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to unblock user for %#v", req.Data))
+	}
+
+	return server.OK[any](), nil
+}
+
+// ReportUser godoc
+//
+//	@Schemes
+//	@Description	Reports an user for abuse. The report is sent to moderation.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string					true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string					false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string					true	"ID of the reporting user"
+//	@Param			request				body		ReportUserRequestBody	true	"Request params"
+//	@Success		201					{object}	users.UserReport
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if user not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/reports [POST].
+func (s *service) ReportUser( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[ReportUserRequestBody, users.UserReport],
+) (*server.Response[users.UserReport], *server.Response[server.ErrorResponse]) {
+	if len(req.Data.Comment) > users.MaxUserReportCommentLength {
+		err := errors.Errorf("comment can't be longer than %v characters", users.MaxUserReportCommentLength)
+
+		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
+	report := &users.UserReport{
+		ReporterUserID: req.Data.UserID,
+		ReportedUserID: req.Data.ReportedUserID,
+		Reason:         users.UserReportReason(req.Data.Reason),
+		Comment:        strings.TrimSpace(req.Data.Comment),
+	}
+	if err := s.usersProcessor.ReportUser(ctx, report); err != nil {
+		err = errors.Wrapf(err, "failed to report user for %#v", req.Data)
+		switch {
+		case errors.Is(err, users.ErrTargetingYourself), errors.Is(err, users.ErrInvalidUserReportReason):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.Created(report), nil
+}
@@ -25,7 +25,9 @@ func (s *service) setupUserRoutes(router *server.Router) {
 		Group("v1w").
 		POST("users", server.RootHandler(s.CreateUser)).
 		PATCH("users/:userId", server.RootHandler(s.ModifyUser)).
-		DELETE("users/:userId", server.RootHandler(s.DeleteUser))
+		DELETE("users/:userId", server.RootHandler(s.DeleteUser)).
+		GET("users/:userId/data-export", server.RootHandler(s.ExportUserData)).
+		GET("admin/users/:userId/data-export", server.RootHandler(s.AdminExportUserData))
 }
 
 // CreateUser godoc
@@ -42,6 +44,7 @@ func (s *service) setupUserRoutes(router *server.Router) {
 //	@Success		201					{object}	User
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if the IP is rejected by the risk policy"
 //	@Failure		404					{object}	server.ErrorResponse	"if no such referred by"
 //	@Failure		409					{object}	server.ErrorResponse	"user already exists with that ID, email or phone number"
 //	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
@@ -61,6 +64,8 @@ func (s *service) CreateUser( //nolint:funlen,gocritic // .
 		switch {
 		case errors.Is(err, users.ErrRelationNotFound):
 			return nil, server.NotFound(err, referralNotFoundErrorCode)
+		case errors.Is(err, users.ErrIPRejected):
+			return nil, server.ForbiddenWithCode(err, ipRejectedErrorCode)
 		case errors.Is(err, users.ErrDuplicate):
 			if tErr := terror.As(err); tErr != nil {
 				return nil, server.Conflict(err, duplicateUserErrorCode, tErr.Data)
@@ -117,23 +122,25 @@ func validateCreateUser(req *server.Request[CreateUserRequestBody, User]) *serve
 // ModifyUser godoc
 //
 //	@Schemes
-//	@Description	Modifies an user account
+//	@Description	Modifies an user account. If the mining blockchain account address change has to be confirmed, it's sent to the email of the user and the `loginSession` of the confirmation is returned.
 //	@Tags			Accounts
 //	@Accept			multipart/form-data
 //	@Produce		json
 //
 //	@Param			Authorization		header		string					true	"Insert your access token"		default(Bearer <Add access token here>)
 //	@Param			X-Account-Metadata	header		string					false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			If-Match			header		string					false	"ETag of the user, as returned by the previous GET or PATCH; the modification fails if the user changed since"
 //	@Param			userId				path		string					true	"ID of the user"
 //	@Param			multiPartFormData	formData	ModifyUserRequestBody	true	"Request params"
 //	@Param			profilePicture		formData	file					false	"The new profile picture for the user"
 //	@Success		200					{object}	ModifyUserResponse
+//	@Header			200					{string}	ETag	"Version of the user, to be sent back in the `If-Match` header of the next modification"
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail or user for modification email is blocked"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
-//	@Failure		403					{object}	server.ErrorResponse	"not allowed"
+//	@Failure		403					{object}	server.ErrorResponse	"not allowed; or the mining blockchain account address was changed too recently or its change can't be confirmed via email; or the username was changed too many times recently"
 //	@Failure		404					{object}	server.ErrorResponse	"user is not found; or the referred by is not found"
-//	@Failure		409					{object}	server.ErrorResponse	"if username, email or phoneNumber conflict with another user's"
-//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		409					{object}	server.ErrorResponse	"if username, email or phoneNumber conflict with another user's; or the username was released recently by another user; or if the user changed since the `If-Match` ETag"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails; or a blockchain account address is invalid"
 //	@Failure		500					{object}	server.ErrorResponse
 //	@Failure		504					{object}	server.ErrorResponse	"if request times out"
 //	@Router			/users/{userId} [PATCH].
@@ -145,6 +152,20 @@ func (s *service) ModifyUser( //nolint:gocritic,funlen,revive,cyclop // .
 		return nil, err
 	}
 	usr := buildUserForModification(req)
+	var checksum string
+	if cfg.EnforceIfMatch {
+		checksum = users.ChecksumFromETag(req.Data.IfMatch)
+	}
+	if usr.Email != "" && usr.Email != req.AuthenticatedUser.Email {
+		if errResp := s.verifyIfMatch(ctx, usr.ID, checksum); errResp != nil {
+			return nil, errResp
+		}
+	}
+	var errResp *server.Response[server.ErrorResponse]
+	var miningAddressLoginSession string
+	if usr.MiningBlockchainAccountAddress, miningAddressLoginSession, errResp = s.miningAddressChangeRequested(ctx, &req.AuthenticatedUser, usr, checksum); errResp != nil { //nolint:lll // .
+		return nil, errResp
+	}
 	var err error
 	var loginSession string
 	if usr.Email, loginSession, err = s.emailUpdateRequested(ctx, &req.AuthenticatedUser, usr.Email); err != nil {
@@ -161,18 +182,35 @@ func (s *service) ModifyUser( //nolint:gocritic,funlen,revive,cyclop // .
 			return nil, server.Unexpected(errors.Wrapf(err, "failed to trigger email modification for request:%#v", req.Data))
 		}
 	}
-	err = s.usersProcessor.ModifyUser(users.ContextWithChecksum(ctx, req.Data.Checksum), usr, req.Data.ProfilePicture)
+	actor := &users.AuditActor{Type: users.UserAuditActorType, ID: req.AuthenticatedUser.UserID, Source: applicationYamlKey}
+	if req.AuthenticatedUser.Role == adminRole && req.Data.UserID != req.AuthenticatedUser.UserID {
+		actor.Type = users.AdminAuditActorType
+	}
+	ctx = users.ContextWithAuditActor(users.ContextWithChecksum(ctx, checksum), actor)
+	err = s.usersProcessor.ModifyUser(ctx, usr, req.Data.ProfilePicture)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to modify user for %#v", req.Data)
 		switch {
 		case errors.Is(err, users.ErrRaceCondition):
-			return nil, server.BadRequest(err, raceConditionErrorCode)
+			return nil, server.Conflict(err, raceConditionErrorCode)
 		case errors.Is(err, users.ErrRelationNotFound):
 			return nil, server.NotFound(err, referralNotFoundErrorCode)
 		case errors.Is(err, users.ErrNotFound):
 			return nil, server.NotFound(err, userNotFoundErrorCode)
 		case errors.Is(err, users.ErrInvalidCountry):
 			return nil, server.BadRequest(errors.Errorf("invalid country %v", req.Data.Country), invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrTooManyContacts):
+			return nil, server.BadRequest(err, tooManyContactsErrorCode)
+		case errors.Is(err, users.ErrInvalidBlockchainAccountAddress):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrMiningBlockchainAccountAddressChangeCooldown):
+			return nil, server.ForbiddenWithCode(err, miningAddressChangeCooldownErrorCode)
+		case errors.Is(err, users.ErrMiningBlockchainAccountAddressChangeNotConfirmed):
+			return nil, server.ForbiddenWithCode(err, emailConfirmationRequiredErrorCode)
+		case errors.Is(err, users.ErrUsernameChangeLimitReached):
+			return nil, server.ForbiddenWithCode(err, usernameChangeLimitReachedErrorCode)
+		case errors.Is(err, users.ErrUsernameQuarantined):
+			return nil, server.Conflict(err, usernameQuarantinedErrorCode)
 		case errors.Is(err, users.ErrDuplicate):
 			if tErr := terror.As(err); tErr != nil {
 				return nil, server.Conflict(err, duplicateUserErrorCode, tErr.Data)
@@ -184,7 +222,15 @@ func (s *service) ModifyUser( //nolint:gocritic,funlen,revive,cyclop // .
 		}
 	}
 
-	return server.OK(&ModifyUserResponse{User: &User{User: usr, Checksum: usr.Checksum()}, LoginSession: loginSession}), nil
+	if loginSession == "" {
+		loginSession = miningAddressLoginSession
+	}
+	okResp := server.OK(&ModifyUserResponse{User: &User{User: usr, Checksum: usr.Checksum()}, LoginSession: loginSession})
+	if etag := usr.ETag(); etag != "" {
+		okResp.Headers = map[string]string{etagHeader: etag}
+	}
+
+	return okResp, nil
 }
 
 func validateModifyUser(ctx context.Context, req *server.Request[ModifyUserRequestBody, ModifyUserResponse]) *server.Response[server.ErrorResponse] {
@@ -208,6 +254,27 @@ func validateModifyUser(ctx context.Context, req *server.Request[ModifyUserReque
 	return validateHiddenProfileElements(req)
 }
 
+// The email modification sends the confirmation email right away, so the `If-Match` has to be checked before that,
+// not only when the user is updated.
+func (s *service) verifyIfMatch(ctx context.Context, userID users.UserID, checksum string) *server.Response[server.ErrorResponse] {
+	if checksum == "" {
+		return nil
+	}
+	oldUsr, err := s.usersProcessor.GetUserByID(context.WithValue(ctx, users.RequestingUserIDCtxValueKey, userID), userID) //nolint:revive,staticcheck // .
+	if err != nil {
+		if errors.Is(err, users.ErrNotFound) {
+			return server.NotFound(errors.Wrapf(err, "user with id `%v` was not found", userID), userNotFoundErrorCode)
+		}
+
+		return server.Unexpected(errors.Wrapf(err, "failed to get user by id: %v", userID))
+	}
+	if oldUsr.Checksum() != checksum {
+		return server.Conflict(errors.Wrapf(users.ErrRaceCondition, "user %v changed since checksum %v", userID, checksum), raceConditionErrorCode)
+	}
+
+	return nil
+}
+
 func (s *service) emailUpdateRequested(
 	ctx context.Context,
 	loggedInUser *server.AuthenticatedUser,
@@ -220,16 +287,10 @@ func (s *service) emailUpdateRequested(
 		return newEmail, "", nil
 	}
 	deviceID := loggedInUser.Claims[deviceIDTokenClaim].(string) //nolint:errcheck,forcetypeassert // .
-	language := loggedInUser.Language
-	if language == "" {
-		var oldUser *users.UserProfile
-		oldUser, err = s.usersProcessor.GetUserByID(ctx, loggedInUser.UserID)
-		if err != nil {
-			return "", "", errors.Wrapf(err, "get user %v failed: no language", loggedInUser.UserID)
-		}
-		language = oldUser.Language
+	language, err := s.language(ctx, loggedInUser)
+	if err != nil {
+		return "", "", err
 	}
-
 	if loginSession, err = s.authEmailLinkClient.SendSignInLinkToEmail(
 		users.ConfirmedEmailContext(ctx, loggedInUser.Email),
 		newEmail, deviceID, language, "",
@@ -240,6 +301,78 @@ func (s *service) emailUpdateRequested(
 	return "", loginSession, nil
 }
 
+// The change of an already set mining address might have to be confirmed via email first. If so, the link is sent
+// and the address is left out of the modification, it's changed only when the link is verified.
+// It can be confirmed only by the users themselves, with the ice sessions of their emails.
+func (s *service) miningAddressChangeRequested(
+	ctx context.Context, loggedInUser *server.AuthenticatedUser, usr *users.User, checksum string,
+) (miningAddressForUpdate, loginSession string, errResp *server.Response[server.ErrorResponse]) {
+	if usr.MiningBlockchainAccountAddress == "" {
+		return "", "", nil
+	}
+	required, err := s.usersProcessor.IsMiningBlockchainAccountAddressChangeConfirmationRequired(ctx, usr.ID, usr.MiningBlockchainAccountAddress)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to check the mining blockchain account address change for userID:%v", usr.ID)
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			return "", "", server.NotFound(err, userNotFoundErrorCode)
+		case errors.Is(err, users.ErrInvalidBlockchainAccountAddress):
+			return "", "", server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrMiningBlockchainAccountAddressChangeCooldown):
+			return "", "", server.ForbiddenWithCode(err, miningAddressChangeCooldownErrorCode)
+		case errors.Is(err, users.ErrMiningBlockchainAccountAddressChangeNotConfirmed):
+			return "", "", server.ForbiddenWithCode(err, emailConfirmationRequiredErrorCode)
+		default:
+			return "", "", server.Unexpected(err)
+		}
+	}
+	if !required {
+		return usr.MiningBlockchainAccountAddress, "", nil
+	}
+	if loggedInUser.UserID != usr.ID || loggedInUser.Token.IsFirebase() || loggedInUser.Email == "" {
+		err = errors.Wrapf(users.ErrMiningBlockchainAccountAddressChangeNotConfirmed, "only the user can confirm it, via email, for userID:%v", usr.ID)
+
+		return "", "", server.ForbiddenWithCode(err, emailConfirmationRequiredErrorCode)
+	}
+	if usr.Email != "" && usr.Email != loggedInUser.Email {
+		err = errors.New("the email and the mining blockchain account address can't be changed at the same time")
+
+		return "", "", server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
+	if errResp = s.verifyIfMatch(ctx, usr.ID, checksum); errResp != nil {
+		return "", "", errResp
+	}
+	deviceID := loggedInUser.Claims[deviceIDTokenClaim].(string) //nolint:errcheck,forcetypeassert // .
+	language, err := s.language(ctx, loggedInUser)
+	if err == nil {
+		loginSession, err = s.authEmailLinkClient.SendMiningBlockchainAccountAddressChangeLinkToEmail(
+			ctx, loggedInUser.Email, deviceID, language, usr.MiningBlockchainAccountAddress,
+		)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "can't send mining blockchain account address change link to email:%v", loggedInUser.Email)
+		if errors.Is(err, emaillink.ErrUserBlocked) {
+			return "", "", server.BadRequest(err, userBlockedErrorCode)
+		}
+
+		return "", "", server.Unexpected(err)
+	}
+
+	return "", loginSession, nil
+}
+
+func (s *service) language(ctx context.Context, loggedInUser *server.AuthenticatedUser) (string, error) {
+	if loggedInUser.Language != "" {
+		return loggedInUser.Language, nil
+	}
+	usr, err := s.usersProcessor.GetUserByID(ctx, loggedInUser.UserID)
+	if err != nil {
+		return "", errors.Wrapf(err, "get user %v failed: no language", loggedInUser.UserID)
+	}
+
+	return usr.Language, nil
+}
+
 func validateHiddenProfileElements(req *server.Request[ModifyUserRequestBody, ModifyUserResponse]) *server.Response[server.ErrorResponse] {
 	if req.Data.HiddenProfileElements == nil {
 		return nil
@@ -285,7 +418,7 @@ func buildUserForModification(req *server.Request[ModifyUserRequestBody, ModifyU
 	usr.Email = req.Data.Email
 	usr.AgendaPhoneNumberHashes = &req.Data.AgendaPhoneNumberHashes
 	usr.BlockchainAccountAddress = req.Data.BlockchainAccountAddress
-	usr.MiningBlockchainAccountAddress = strings.ToLower(req.Data.MiningBlockchainAccountAddress)
+	usr.MiningBlockchainAccountAddress = req.Data.MiningBlockchainAccountAddress
 	if req.Data.ClearMiningBlockchainAccountAddress != nil && *req.Data.ClearMiningBlockchainAccountAddress {
 		usr.MiningBlockchainAccountAddress = usr.ID
 	}
@@ -358,7 +491,7 @@ func verifyPhoneNumberAndUsername(phoneNumber, phoneNumberHash, username string)
 // DeleteUser godoc
 //
 //	@Schemes
-//	@Description	Deletes an user account
+//	@Description	Deletes an user account. If a grace period is configured, the account is only marked as pending deletion and it can be restored by signing in again before it expires.
 //	@Tags			Accounts
 //	@Accept			json
 //	@Produce		json
@@ -390,9 +523,81 @@ func (s *service) DeleteUser( //nolint:gocritic // False negative.
 
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to delete user with id: %v", req.Data.UserID))
 	}
-	if err := server.Auth(ctx).DeleteUser(ctx, req.Data.UserID); err != nil && !errors.Is(err, auth.ErrUserNotFound) {
-		return nil, server.Unexpected(errors.Wrapf(err, "failed to delete auth user:%#v", req.Data.UserID))
-	}
 
 	return server.OK[any](), nil
 }
+
+// ExportUserData godoc
+//
+//	@Schemes
+//	@Description	Exports everything stored about the authenticated user, across all modules, as one versioned document.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the User"
+//	@Success		200					{object}	users.UserDataExport
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"user not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/data-export [GET].
+func (s *service) ExportUserData( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[ExportUserDataArg, users.UserDataExport],
+) (*server.Response[users.UserDataExport], *server.Response[server.ErrorResponse]) {
+	if req.Data.UserID != req.AuthenticatedUser.UserID {
+		return nil, server.Forbidden(errors.New("not allowed"))
+	}
+
+	return s.exportUserData(ctx, req.Data.UserID)
+}
+
+// AdminExportUserData godoc
+//
+//	@Schemes
+//	@Description	Exports everything stored about any user, across all modules, as one versioned document. Only for admins.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the User"
+//	@Success		200					{object}	users.UserDataExport
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"user not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/admin/users/{userId}/data-export [GET].
+func (s *service) AdminExportUserData( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[ExportUserDataArg, users.UserDataExport],
+) (*server.Response[users.UserDataExport], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.New("not allowed"))
+	}
+
+	return s.exportUserData(ctx, req.Data.UserID)
+}
+
+func (s *service) exportUserData(
+	ctx context.Context, userID string,
+) (*server.Response[users.UserDataExport], *server.Response[server.ErrorResponse]) {
+	export, err := users.ExportUserData(ctx, userID, s.usersProcessor, s.authEmailLinkClient, s.quizRepository, s.socialRepository)
+	if err != nil {
+		if errors.Is(err, users.ErrNotFound) {
+			return nil, server.NotFound(errors.Wrapf(err, "user with id `%v` was not found", userID), userNotFoundErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to export user data for userID:%v", userID))
+	}
+
+	return server.OK(export), nil
+}
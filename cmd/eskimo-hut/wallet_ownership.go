@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users"
+	"github.com/ice-blockchain/wintr/server"
+	"github.com/ice-blockchain/wintr/terror"
+)
+
+func (s *service) setupWalletOwnershipRoutes(router *server.Router) {
+	router.
+		Group("v1w").
+		POST("users/:userId/wallet-ownership/challenge", server.RootHandler(s.CreateWalletOwnershipChallenge)).
+		POST("users/:userId/wallet-ownership/proof", server.RootHandler(s.VerifyWalletOwnership))
+}
+
+// CreateWalletOwnershipChallenge godoc
+//
+//	@Schemes
+//	@Description	Issues a challenge that has to be signed with the wallet of the provided address, to prove its ownership. It replaces any previous challenge of the user.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string										true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string										false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string										true	"ID of the user"
+//	@Param			request				body		CreateWalletOwnershipChallengeRequestBody	true	"Request params"
+//	@Success		201					{object}	users.WalletOwnershipChallenge
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if user not found"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails; or the address is invalid"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/wallet-ownership/challenge [POST].
+func (s *service) CreateWalletOwnershipChallenge( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[CreateWalletOwnershipChallengeRequestBody, users.WalletOwnershipChallenge],
+) (*server.Response[users.WalletOwnershipChallenge], *server.Response[server.ErrorResponse]) {
+	if req.Data.UserID != req.AuthenticatedUser.UserID {
+		return nil, server.Forbidden(errors.New("not allowed"))
+	}
+	challenge, err := s.usersProcessor.CreateWalletOwnershipChallenge(ctx, req.Data.UserID, req.Data.Address)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to create wallet ownership challenge for userID:%v", req.Data.UserID)
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		case errors.Is(err, users.ErrInvalidBlockchainAccountAddress):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.Created(challenge), nil
+}
+
+// VerifyWalletOwnership godoc
+//
+//	@Schemes
+//	@Description	Verifies the signed challenge and, if valid, stores the address as the verified `blockchainAccountAddress` of the user. Every challenge can be used only once.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string							true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string							false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string							true	"ID of the user"
+//	@Param			request				body		VerifyWalletOwnershipRequestBody	true	"Request params"
+//	@Success		200					{object}	User
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail; or the signature is invalid"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		404					{object}	server.ErrorResponse	"if user not found; or there is no valid challenge for the address"
+//	@Failure		409					{object}	server.ErrorResponse	"if the address is already used by another user"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails; or the address is invalid"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/wallet-ownership/proof [POST].
+func (s *service) VerifyWalletOwnership( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[VerifyWalletOwnershipRequestBody, User],
+) (*server.Response[User], *server.Response[server.ErrorResponse]) {
+	if req.Data.UserID != req.AuthenticatedUser.UserID {
+		return nil, server.Forbidden(errors.New("not allowed"))
+	}
+	proof := &users.WalletOwnershipProof{
+		Address:   req.Data.Address,
+		Signature: req.Data.Signature,
+		PublicKey: req.Data.PublicKey,
+		StateInit: req.Data.StateInit,
+	}
+	actor := &users.AuditActor{Type: users.UserAuditActorType, ID: req.AuthenticatedUser.UserID, Source: applicationYamlKey}
+	usr, err := s.usersProcessor.VerifyWalletOwnership(users.ContextWithAuditActor(ctx, actor), req.Data.UserID, proof)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to verify wallet ownership for userID:%v", req.Data.UserID)
+		switch {
+		case errors.Is(err, users.ErrNotFound):
+			return nil, server.NotFound(err, userNotFoundErrorCode)
+		case errors.Is(err, users.ErrWalletOwnershipChallengeNotFound):
+			return nil, server.NotFound(err, walletOwnershipChallengeNotFoundErrorCode)
+		case errors.Is(err, users.ErrInvalidWalletOwnershipSignature):
+			return nil, server.BadRequest(err, invalidSignatureErrorCode)
+		case errors.Is(err, users.ErrInvalidBlockchainAccountAddress):
+			return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+		case errors.Is(err, users.ErrRaceCondition):
+			return nil, server.Conflict(err, raceConditionErrorCode)
+		case errors.Is(err, users.ErrDuplicate):
+			if tErr := terror.As(err); tErr != nil {
+				return nil, server.Conflict(err, duplicateUserErrorCode, tErr.Data)
+			}
+
+			fallthrough
+		default:
+			return nil, server.Unexpected(err)
+		}
+	}
+
+	return server.OK(&User{User: usr, Checksum: usr.Checksum()}), nil
+}
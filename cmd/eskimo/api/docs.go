@@ -20,9 +20,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/user-statistics/top-countries": {
+        "/admin/user-reports": {
             "get": {
-                "description": "Returns the paginated view of users per country.",
+                "description": "Returns the paginated abuse reports, newest first. Only admins are allowed to see them.",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,7 +30,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Statistics"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
@@ -50,20 +50,20 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "a keyword to look for in all country codes or names",
-                        "name": "keyword",
+                        "description": "Only the reports about this user",
+                        "name": "reportedUserId",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Limit of elements to return. Defaults to 10",
+                        "description": "Limit of entries to return. Defaults to 10",
                         "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of elements to skip before collecting elements to return",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` in the previous page",
+                        "name": "cursor",
                         "in": "query"
                     }
                 ],
@@ -71,10 +71,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/users.CountryStatistics"
-                            }
+                            "$ref": "#/definitions/users.UserReports"
                         }
                     },
                     "400": {
@@ -89,6 +86,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "422": {
                         "description": "if syntax fails",
                         "schema": {
@@ -110,9 +113,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user-statistics/user-growth": {
+        "/admin/users": {
             "get": {
-                "description": "Returns statistics about user growth.",
+                "description": "Returns the users matching exactly all the provided filters. At least one filter is required. Only admins are allowed to use it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -120,7 +123,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Statistics"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
@@ -139,15 +142,51 @@ const docTemplate = `{
                         "in": "header"
                     },
                     {
-                        "type": "integer",
-                        "description": "number of days in the past to look for. Defaults to 3. Max is 90.",
-                        "name": "days",
+                        "type": "string",
+                        "description": "The email of the user",
+                        "name": "email",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Timezone in format +04:30 or -03:45",
-                        "name": "tz",
+                        "description": "The phone number of the user",
+                        "name": "phoneNumber",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The blockchain account address of the user",
+                        "name": "blockchainAccountAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The mining blockchain account address of the user",
+                        "name": "miningBlockchainAccountAddress",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The unique id of one of the devices of the user",
+                        "name": "deviceUniqueId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The id of the user that referred the user",
+                        "name": "referredBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of elements to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elements to skip before starting to look for",
+                        "name": "offset",
                         "in": "query"
                     }
                 ],
@@ -155,7 +194,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.UserGrowthStatistics"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/users.User"
+                            }
                         }
                     },
                     "400": {
@@ -170,6 +212,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
                     "422": {
                         "description": "if syntax fails",
                         "schema": {
@@ -191,9 +239,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/user-views/username": {
+        "/referral-leaderboard": {
             "get": {
-                "description": "Returns public information about an user account based on an username, making sure the username is valid first.",
+                "description": "Returns the paginated ranking of the top referrers, refreshed periodically. Only verified users that didn't hide their global rank or referral count are ranked.",
                 "consumes": [
                     "application/json"
                 ],
@@ -201,7 +249,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Accounts"
+                    "Referrals"
                 ],
                 "parameters": [
                     {
@@ -221,17 +269,49 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "username of the user. It will validate it first",
-                        "name": "username",
-                        "in": "query",
-                        "required": true
+                        "enum": [
+                            "T1",
+                            "T2"
+                        ],
+                        "description": "Tier of the referrals to rank by. Defaults to T1",
+                        "name": "tier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "all",
+                            "7d",
+                            "30d"
+                        ],
+                        "description": "Period to count the referrals for. Defaults to all",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 3166 country code to restrict the ranking to",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of elements to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` in the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.UserProfile"
+                            "$ref": "#/definitions/users.ReferralLeaderboard"
                         }
                     },
                     "400": {
@@ -246,12 +326,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "if not found",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
-                        }
-                    },
                     "422": {
                         "description": "if syntax fails",
                         "schema": {
@@ -273,9 +347,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/user-statistics/top-cities": {
             "get": {
-                "description": "Returns a list of user account based on the provided query parameters.",
+                "description": "Returns the paginated view of users per city.",
                 "consumes": [
                     "application/json"
                 ],
@@ -283,7 +357,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Accounts"
+                    "Statistics"
                 ],
                 "parameters": [
                     {
@@ -303,10 +377,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "A keyword to look for in the usernames",
+                        "description": "ISO 3166 country code to restrict the cities to",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the beginning of the city names to look for",
                         "name": "keyword",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "integer",
@@ -316,7 +395,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Elements to skip before starting to look for",
+                        "description": "Number of elements to skip before collecting elements to return",
                         "name": "offset",
                         "in": "query"
                     }
@@ -327,7 +406,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/users.MinimalUserProfile"
+                                "$ref": "#/definitions/users.CityStatistics"
                             }
                         }
                     },
@@ -364,9 +443,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{userId}": {
+        "/user-statistics/top-countries": {
             "get": {
-                "description": "Returns an user's account.",
+                "description": "Returns the paginated view of users per country, including the max number of users active in a single day within the last ` + "`" + `days` + "`" + ` days.",
                 "consumes": [
                     "application/json"
                 ],
@@ -374,7 +453,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Accounts"
+                    "Statistics"
                 ],
                 "parameters": [
                     {
@@ -394,17 +473,47 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "a keyword to look for in all country codes or names",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "enum": [
+                            "total",
+                            "active"
+                        ],
+                        "description": "what to sort the countries by. Defaults to total",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of days, today included, to count the active users for. Defaults to 1, max 90",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of elements to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of elements to skip before collecting elements to return",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/users.CountryStatistics"
+                            }
                         }
                     },
                     "400": {
@@ -419,12 +528,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "if not found",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
-                        }
-                    },
                     "422": {
                         "description": "if syntax fails",
                         "schema": {
@@ -446,9 +549,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{userId}/referral-acquisition-history": {
+        "/user-statistics/user-growth": {
             "get": {
-                "description": "Returns the history of referral acquisition for the provided user id.",
+                "description": "Returns statistics about user growth.",
                 "consumes": [
                     "application/json"
                 ],
@@ -456,7 +559,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Referrals"
+                    "Statistics"
                 ],
                 "parameters": [
                     {
@@ -474,17 +577,22 @@ const docTemplate = `{
                         "name": "X-Account-Metadata",
                         "in": "header"
                     },
+                    {
+                        "type": "integer",
+                        "description": "number of days in the past to look for. Defaults to 3. Max is 90 for ` + "`" + `day` + "`" + ` granularity and 730 for the others.",
+                        "name": "days",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "The size of each time series data point: ` + "`" + `day` + "`" + `, ` + "`" + `week` + "`" + ` or ` + "`" + `month` + "`" + `. Defaults to ` + "`" + `day` + "`" + `",
+                        "name": "granularity",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Always is 5, cannot be changed due to DB schema",
-                        "name": "days",
+                        "type": "string",
+                        "description": "Timezone in format +04:30 or -03:45 or an IANA zone name, like Europe/Berlin",
+                        "name": "tz",
                         "in": "query"
                     }
                 ],
@@ -492,10 +600,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/users.ReferralAcquisition"
-                            }
+                            "$ref": "#/definitions/users.UserGrowthStatistics"
                         }
                     },
                     "400": {
@@ -510,12 +615,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "if not allowed",
-                        "schema": {
-                            "$ref": "#/definitions/server.ErrorResponse"
-                        }
-                    },
                     "422": {
                         "description": "if syntax fails",
                         "schema": {
@@ -537,9 +636,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{userId}/referrals": {
+        "/user-views/username": {
             "get": {
-                "description": "Returns the referrals of an user.",
+                "description": "Returns public information about an user account based on an username, making sure the username is valid first.",
                 "consumes": [
                     "application/json"
                 ],
@@ -547,7 +646,7 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Referrals"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
@@ -567,28 +666,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "ID of the user",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Type of referrals: ` + "`" + `CONTACTS` + "`" + ` or ` + "`" + `T1` + "`" + ` or ` + "`" + `T2` + "`" + `",
-                        "name": "type",
+                        "description": "username of the user. It will validate it first",
+                        "name": "username",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Limit of elements to return. Defaults to 10",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of elements to skip before collecting elements to return",
-                        "name": "offset",
+                        "type": "boolean",
+                        "description": "If true and nobody has the username, returns the user that changed it recently",
+                        "name": "resolvePrevious",
                         "in": "query"
                     }
                 ],
@@ -596,7 +682,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.Referrals"
+                            "$ref": "#/definitions/users.UserProfile"
                         }
                     },
                     "400": {
@@ -611,8 +697,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "if not allowed",
+                    "404": {
+                        "description": "if not found",
                         "schema": {
                             "$ref": "#/definitions/server.ErrorResponse"
                         }
@@ -637,38 +723,1254 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.User": {
-            "type": "object",
-            "properties": {
-                "agendaPhoneNumberHashes": {
-                    "type": "string",
-                    "example": "Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2"
-                },
-                "blockchainAccountAddress": {
-                    "type": "string",
-                    "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
-                },
-                "checksum": {
-                    "type": "string",
-                    "example": "1232412415326543647657"
-                },
-                "city": {
-                    "type": "string",
-                    "example": "New York"
-                },
-                "clientData": {
-                    "$ref": "#/definitions/users.JSON"
-                },
-                "country": {
-                    "type": "string",
-                    "example": "US"
-                },
-                "createdAt": {
-                    "type": "string",
-                    "example": "2022-01-03T16:20:52.156534Z"
+        },
+        "/users": {
+            "get": {
+                "description": "Returns a list of user account based on the provided query parameters.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Accounts"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "A keyword to look for in the usernames",
+                        "name": "keyword",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of elements to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Elements to skip before starting to look for. Ignored if ` + "`" + `cursor` + "`" + ` is provided",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.MinimalUserProfiles"
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}": {
+            "get": {
+                "description": "Returns an user's account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Accounts"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user, to be sent back in the ` + "`" + `If-Match` + "`" + ` header when modifying it. Missing for other users"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "if not found",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/audit": {
+            "get": {
+                "description": "Returns the paginated log of changes made to an user, newest first. Only admins are allowed to see it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Accounts"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of entries to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` in the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.UserAuditLog"
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/device-risk": {
+            "get": {
+                "description": "Returns why the user was flagged as possibly being part of a device farm, if it was. Only admins are allowed to see it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Accounts"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.DeviceRisk"
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/referral-acquisition-history": {
+            "get": {
+                "description": "Returns the history of referral acquisition for the provided user id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Referrals"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of days to return, including today. Defaults to and is capped by the server side maximum",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/users.ReferralAcquisition"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/referral-tree": {
+            "get": {
+                "description": "Returns the referral tree of an user, up to the provided depth, with counts per level and a paginated list of nodes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Referrals"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max depth of the tree. Defaults to and is capped by the server side maximum",
+                        "name": "maxDepth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of nodes to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` in the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.ReferralTree"
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{userId}/referrals": {
+            "get": {
+                "description": "Returns the referrals of an user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Referrals"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003cAdd access token here\u003e",
+                        "description": "Insert your access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "\u003cAdd metadata token here\u003e",
+                        "description": "Insert your metadata token",
+                        "name": "X-Account-Metadata",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of referrals: ` + "`" + `CONTACTS` + "`" + ` or ` + "`" + `T1` + "`" + ` or ` + "`" + `T2` + "`" + `",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit of elements to return. Defaults to 10",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of elements to skip before collecting elements to return. Ignored if ` + "`" + `cursor` + "`" + ` is provided",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor returned as ` + "`" + `nextCursor` + "`" + ` in the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.Referrals"
+                        }
+                    },
+                    "400": {
+                        "description": "if validations fail",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "if not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "if not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "if syntax fails",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "if request times out",
+                        "schema": {
+                            "$ref": "#/definitions/server.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.User": {
+            "type": "object",
+            "properties": {
+                "agendaPhoneNumberHashes": {
+                    "type": "string",
+                    "example": "Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2"
+                },
+                "blockchainAccountAddress": {
+                    "type": "string",
+                    "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "blockchainAccountAddressVerifiedAt": {
+                    "description": "It's set only if the user proved the ownership of the ` + "`" + `blockchainAccountAddress` + "`" + `, by signing a challenge with the wallet.",
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "checksum": {
+                    "type": "string",
+                    "example": "1232412415326543647657"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "New York"
+                },
+                "clientData": {
+                    "$ref": "#/definitions/users.JSON"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "US"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "jdoe@gmail.com"
+                },
+                "firstName": {
+                    "type": "string",
+                    "example": "John"
+                },
+                "hiddenProfileElements": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "globalRank",
+                            "referralCount",
+                            "level",
+                            "role",
+                            "badges"
+                        ]
+                    },
+                    "example": [
+                        "level"
+                    ]
+                },
+                "id": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "kycStepBlocked": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.KYCStep"
+                        }
+                    ],
+                    "example": 0
+                },
+                "kycStepPassed": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.KYCStep"
+                        }
+                    ],
+                    "example": 0
+                },
+                "kycStepsCreatedAt": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "2022-01-03T16:20:52.156534Z"
+                    ]
+                },
+                "kycStepsLastUpdatedAt": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "2022-01-03T16:20:52.156534Z"
+                    ]
+                },
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "lastName": {
+                    "type": "string",
+                    "example": "Doe"
+                },
+                "miningBlockchainAccountAddress": {
+                    "type": "string",
+                    "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "pendingDeletionAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "phoneNumber": {
+                    "type": "string",
+                    "example": "+12099216581"
+                },
+                "profilePictureUrl": {
+                    "type": "string",
+                    "example": "https://somecdn.com/p1.jpg"
+                },
+                "referredBy": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "repeatableKYCSteps": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "t1ReferralCount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "t2ReferralCount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jdoe"
+                },
+                "verified": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "server.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "SOMETHING_NOT_FOUND"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "error": {
+                    "type": "string",
+                    "example": "something is missing"
+                }
+            }
+        },
+        "users.AuditActor": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "The user id of the user/admin, if any.",
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "source": {
+                    "description": "The module that triggered the change.",
+                    "type": "string",
+                    "example": "eskimo-hut"
+                },
+                "type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.AuditActorType"
+                        }
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
+        "users.AuditActorType": {
+            "type": "string",
+            "enum": [
+                "user",
+                "admin",
+                "apiKey",
+                "internal"
+            ],
+            "x-enum-varnames": [
+                "UserAuditActorType",
+                "AdminAuditActorType",
+                "APIKeyAuditActorType",
+                "InternalAuditActorType"
+            ]
+        },
+        "users.CityStatistics": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "type": "string",
+                    "example": "New York"
+                },
+                "country": {
+                    "description": "ISO 3166 country code.",
+                    "type": "string",
+                    "example": "US"
+                },
+                "userCount": {
+                    "type": "integer",
+                    "example": 12121212
+                }
+            }
+        },
+        "users.CountryStatistics": {
+            "type": "object",
+            "properties": {
+                "activeUserCount": {
+                    "description": "The max number of users that were active in a single day, within the requested days.",
+                    "type": "integer",
+                    "example": 1212
+                },
+                "country": {
+                    "description": "ISO 3166 country code.",
+                    "type": "string",
+                    "example": "US"
+                },
+                "userCount": {
+                    "type": "integer",
+                    "example": 12121212
+                }
+            }
+        },
+        "users.DeviceRisk": {
+            "type": "object",
+            "properties": {
+                "deviceAccounts": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "deviceUniqueId": {
+                    "type": "string",
+                    "example": "FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"
+                },
+                "fingerprintAccounts": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "flaggedAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "reasons": {
+                    "type": "array",
+                    "example": [
+                        "sharedFingerprint"
+                    ],
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "sharedFingerprint",
+                            "sharedDevice",
+                            "emulator",
+                            "riskyIP"
+                        ]
+                    }
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                }
+            }
+        },
+        "users.JSON": {
+            "type": "object",
+            "additionalProperties": {}
+        },
+        "users.KYCStep": {
+            "type": "integer",
+            "enum": [
+                0,
+                1,
+                2,
+                3,
+                4,
+                5,
+                6,
+                7,
+                8,
+                9,
+                10
+            ],
+            "x-enum-varnames": [
+                "NoneKYCStep",
+                "FacialRecognitionKYCStep",
+                "LivenessDetectionKYCStep",
+                "Social1KYCStep",
+                "QuizKYCStep",
+                "Social2KYCStep",
+                "Social3KYCStep",
+                "Social4KYCStep",
+                "Social5KYCStep",
+                "Social6KYCStep",
+                "Social7KYCStep"
+            ]
+        },
+        "users.MinimalUserProfile": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "city": {
+                    "type": "string",
+                    "example": "New York"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "US"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "jdoe@gmail.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "phoneNumber": {
+                    "type": "string",
+                    "example": "+12099216581"
+                },
+                "pinged": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "profilePictureUrl": {
+                    "type": "string",
+                    "example": "https://somecdn.com/p1.jpg"
+                },
+                "referralType": {
+                    "enum": [
+                        "CONTACTS",
+                        "T0",
+                        "T1",
+                        "T2"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.ReferralType"
+                        }
+                    ],
+                    "example": "T1"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jdoe"
+                },
+                "verified": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "users.MinimalUserProfiles": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJ1c2VybmFtZSI6Impkb2UifQ"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.MinimalUserProfile"
+                    }
+                }
+            }
+        },
+        "users.ReferralAcquisition": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2022-01-03"
+                },
+                "t1": {
+                    "type": "integer",
+                    "example": 22
+                },
+                "t2": {
+                    "type": "integer",
+                    "example": 13
+                }
+            }
+        },
+        "users.ReferralLeaderboard": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.ReferralLeaderboardEntry"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJyYW5rIjoxMX0"
+                }
+            }
+        },
+        "users.ReferralLeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "description": "ISO 3166 country code.",
+                    "type": "string",
+                    "example": "US"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "profilePictureUrl": {
+                    "type": "string",
+                    "example": "https://somecdn.com/p1.jpg"
+                },
+                "rank": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "referralCount": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jdoe"
+                }
+            }
+        },
+        "users.ReferralTree": {
+            "type": "object",
+            "properties": {
+                "levels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.ReferralTreeLevel"
+                    }
+                },
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"
+                },
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.ReferralTreeNode"
+                    }
+                }
+            }
+        },
+        "users.ReferralTreeLevel": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer",
+                    "example": 11
+                },
+                "depth": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 11
+                }
+            }
+        },
+        "users.ReferralTreeNode": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "depth": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "id": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "profilePictureUrl": {
+                    "type": "string",
+                    "example": "https://somecdn.com/p1.jpg"
+                },
+                "referredBy": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "jdoe"
+                }
+            }
+        },
+        "users.ReferralType": {
+            "type": "string",
+            "enum": [
+                "CONTACTS",
+                "T1",
+                "T2",
+                "TEAM"
+            ],
+            "x-enum-varnames": [
+                "ContactsReferrals",
+                "Tier1Referrals",
+                "Tier2Referrals",
+                "TeamReferrals"
+            ]
+        },
+        "users.Referrals": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer",
+                    "example": 11
+                },
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"
+                },
+                "referrals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.MinimalUserProfile"
+                    }
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 11
+                }
+            }
+        },
+        "users.User": {
+            "type": "object",
+            "properties": {
+                "agendaPhoneNumberHashes": {
+                    "type": "string",
+                    "example": "Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2,Ef86A6021afCDe5673511376B2"
+                },
+                "blockchainAccountAddress": {
+                    "type": "string",
+                    "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "blockchainAccountAddressVerifiedAt": {
+                    "description": "It's set only if the user proved the ownership of the ` + "`" + `blockchainAccountAddress` + "`" + `, by signing a challenge with the wallet.",
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "city": {
+                    "type": "string",
+                    "example": "New York"
+                },
+                "clientData": {
+                    "$ref": "#/definitions/users.JSON"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "US"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
                 },
                 "email": {
                     "type": "string",
@@ -744,6 +2046,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
                 },
+                "pendingDeletionAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
                 "phoneNumber": {
                     "type": "string",
                     "example": "+12099216581"
@@ -762,14 +2068,6 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
-                "t1ReferralCount": {
-                    "type": "integer",
-                    "example": 100
-                },
-                "t2ReferralCount": {
-                    "type": "integer",
-                    "example": 100
-                },
                 "updatedAt": {
                     "type": "string",
                     "example": "2022-01-03T16:20:52.156534Z"
@@ -784,177 +2082,54 @@ const docTemplate = `{
                 }
             }
         },
-        "server.ErrorResponse": {
+        "users.UserAuditLog": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string",
-                    "example": "SOMETHING_NOT_FOUND"
-                },
-                "data": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.UserAuditLogEntry"
+                    }
                 },
-                "error": {
+                "nextCursor": {
                     "type": "string",
-                    "example": "something is missing"
+                    "example": "eyJyYW5rIjoxMX0"
                 }
             }
         },
-        "users.CountryStatistics": {
+        "users.UserAuditLogEntry": {
             "type": "object",
             "properties": {
-                "country": {
-                    "description": "ISO 3166 country code.",
-                    "type": "string",
-                    "example": "US"
+                "actor": {
+                    "$ref": "#/definitions/users.AuditActor"
                 },
-                "userCount": {
-                    "type": "integer",
-                    "example": 12121212
-                }
-            }
-        },
-        "users.JSON": {
-            "type": "object",
-            "additionalProperties": {}
-        },
-        "users.KYCStep": {
-            "type": "integer",
-            "enum": [
-                0,
-                1,
-                2,
-                3,
-                4,
-                5,
-                6,
-                7,
-                8,
-                9,
-                10
-            ],
-            "x-enum-varnames": [
-                "NoneKYCStep",
-                "FacialRecognitionKYCStep",
-                "LivenessDetectionKYCStep",
-                "Social1KYCStep",
-                "QuizKYCStep",
-                "Social2KYCStep",
-                "Social3KYCStep",
-                "Social4KYCStep",
-                "Social5KYCStep",
-                "Social6KYCStep",
-                "Social7KYCStep"
-            ]
-        },
-        "users.MinimalUserProfile": {
-            "type": "object",
-            "properties": {
-                "active": {
-                    "type": "boolean",
-                    "example": true
+                "after": {
+                    "$ref": "#/definitions/users.JSON"
                 },
-                "city": {
-                    "type": "string",
-                    "example": "New York"
+                "before": {
+                    "$ref": "#/definitions/users.JSON"
                 },
-                "country": {
-                    "type": "string",
-                    "example": "US"
+                "changedFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "username",
+                        "firstName"
+                    ]
                 },
-                "email": {
+                "createdAt": {
                     "type": "string",
-                    "example": "jdoe@gmail.com"
+                    "example": "2022-01-03T16:20:52.156534Z"
                 },
                 "id": {
-                    "type": "string",
-                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
-                },
-                "phoneNumber": {
-                    "type": "string",
-                    "example": "+12099216581"
-                },
-                "pinged": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "profilePictureUrl": {
-                    "type": "string",
-                    "example": "https://somecdn.com/p1.jpg"
-                },
-                "referralType": {
-                    "enum": [
-                        "CONTACTS",
-                        "T0",
-                        "T1",
-                        "T2"
-                    ],
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/users.ReferralType"
-                        }
-                    ],
-                    "example": "T1"
-                },
-                "username": {
-                    "type": "string",
-                    "example": "jdoe"
-                },
-                "verified": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "users.ReferralAcquisition": {
-            "type": "object",
-            "properties": {
-                "date": {
-                    "type": "string",
-                    "example": "2022-01-03"
-                },
-                "t1": {
-                    "type": "integer",
-                    "example": 22
-                },
-                "t2": {
-                    "type": "integer",
-                    "example": 13
-                }
-            }
-        },
-        "users.ReferralType": {
-            "type": "string",
-            "enum": [
-                "CONTACTS",
-                "T1",
-                "T2",
-                "TEAM"
-            ],
-            "x-enum-varnames": [
-                "ContactsReferrals",
-                "Tier1Referrals",
-                "Tier2Referrals",
-                "TeamReferrals"
-            ]
-        },
-        "users.Referrals": {
-            "type": "object",
-            "properties": {
-                "active": {
                     "type": "integer",
                     "example": 11
                 },
-                "referrals": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/users.MinimalUserProfile"
-                    }
-                },
-                "total": {
-                    "type": "integer",
-                    "example": 11
+                "userId": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
                 }
             }
         },
@@ -1005,6 +2180,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
                 },
+                "blockchainAccountAddressVerifiedAt": {
+                    "description": "It's set only if the user proved the ownership of the ` + "`" + `blockchainAccountAddress` + "`" + `, by signing a challenge with the wallet.",
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
                 "city": {
                     "type": "string",
                     "example": "New York"
@@ -1094,6 +2274,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
                 },
+                "pendingDeletionAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
                 "phoneNumber": {
                     "type": "string",
                     "example": "+12099216581"
@@ -1133,6 +2317,71 @@ const docTemplate = `{
                     "example": true
                 }
             }
+        },
+        "users.UserReport": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "example": "keeps sending me links"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2022-01-03T16:20:52.156534Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "reason": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/users.UserReportReason"
+                        }
+                    ],
+                    "example": "spam"
+                },
+                "reportedUserId": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                },
+                "reporterUserId": {
+                    "type": "string",
+                    "example": "did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"
+                }
+            }
+        },
+        "users.UserReportReason": {
+            "enum": [
+                "spam",
+                "harassment",
+                "impersonation",
+                "inappropriateContent",
+                "other"
+            ],
+            "type": "string",
+            "x-enum-varnames": [
+                "SpamUserReportReason",
+                "HarassmentUserReportReason",
+                "ImpersonationUserReportReason",
+                "InappropriateContentUserReportReason",
+                "OtherUserReportReason"
+            ]
+        },
+        "users.UserReports": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "type": "string",
+                    "example": "eyJyYW5rIjoxMX0"
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/users.UserReport"
+                    }
+                }
+            }
         }
     }
 }`
@@ -14,6 +14,7 @@ import (
 type (
 	GetUsersArg struct {
 		Keyword string `form:"keyword" required:"true" example:"john"`
+		Cursor  string `form:"cursor" example:"eyJ1c2VybmFtZSI6Impkb2UifQ"`
 		Limit   uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
 		Offset  uint64 `form:"offset" example:"5"`
 	}
@@ -22,26 +23,74 @@ type (
 	}
 	GetUserByUsernameArg struct {
 		Username string `form:"username" required:"true" example:"jdoe"`
+		// If true and nobody has the username, the user that changed it recently is returned, with the current username.
+		ResolvePrevious bool `form:"resolvePrevious" example:"true"`
 	}
 	GetTopCountriesArg struct {
 		Keyword string `form:"keyword" example:"united states"`
+		SortBy  string `form:"sortBy" example:"active" enums:"total,active"` // Total by default.
+		Days    uint64 `form:"days" maximum:"90" example:"7"`                // 1 by default.
+		Limit   uint64 `form:"limit" maximum:"1000" example:"10"`            // 10 by default.
+		Offset  uint64 `form:"offset" example:"5"`
+	}
+	GetTopCitiesArg struct {
+		Country string `form:"country" example:"US"`
+		Keyword string `form:"keyword" example:"new"`
 		Limit   uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
 		Offset  uint64 `form:"offset" example:"5"`
 	}
 	GetUserGrowthArg struct {
-		TZ   string `form:"tz" example:"+4:30"`
-		Days uint64 `form:"days" example:"7"`
+		TZ          string `form:"tz" example:"+4:30"`
+		Granularity string `form:"granularity" example:"week" enums:"day,week,month"`
+		Days        uint64 `form:"days" example:"7"`
 	}
 	GetReferralAcquisitionHistoryArg struct {
 		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
-		Days   uint64 `form:"days" maximum:"30" example:"5"`
+		Days   uint64 `form:"days" maximum:"30" example:"5"` // Server side maxDaysReferralsHistory by default.
 	}
 	GetReferralsArg struct {
 		UserID string `uri:"userId" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 		Type   string `form:"type" required:"true" example:"T1" enums:"T1,T2,CONTACTS"`
+		Cursor string `form:"cursor" example:"eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"`
 		Limit  uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
 		Offset uint64 `form:"offset" example:"5"`
 	}
+	GetReferralTreeArg struct {
+		UserID   string `uri:"userId" allowForbiddenGet:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Cursor   string `form:"cursor" example:"eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"`
+		MaxDepth uint64 `form:"maxDepth" maximum:"10" example:"5"` // Server side maxReferralTreeDepth by default.
+		Limit    uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
+	}
+	GetReferralLeaderboardArg struct {
+		Tier    string `form:"tier" example:"T1" enums:"T1,T2"`        // T1 by default.
+		Period  string `form:"period" example:"7d" enums:"all,7d,30d"` // All by default.
+		Country string `form:"country" example:"US"`
+		Cursor  string `form:"cursor" example:"eyJyYW5rIjoxMX0"`
+		Limit   uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
+	}
+	SearchUsersArg struct {
+		Email                          string `form:"email" example:"jdoe@gmail.com"`
+		PhoneNumber                    string `form:"phoneNumber" example:"+12099216581"`
+		BlockchainAccountAddress       string `form:"blockchainAccountAddress" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		MiningBlockchainAccountAddress string `form:"miningBlockchainAccountAddress" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		DeviceUniqueID                 string `form:"deviceUniqueId" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
+		ReferredBy                     string `form:"referredBy" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Limit                          uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
+		Offset                         uint64 `form:"offset" example:"5"`
+	}
+	GetUserAuditLogArg struct {
+		UserID string `uri:"userId" allowForbiddenGet:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Cursor string `form:"cursor" example:"eyJyYW5rIjoxMX0"`
+		Limit  uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
+	}
+	GetDeviceRiskArg struct {
+		UserID string `uri:"userId" allowForbiddenGet:"true" required:"true" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	GetUserReportsArg struct {
+		ReportedUserID string `form:"reportedUserId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Cursor         string `form:"cursor" example:"eyJyYW5rIjoxMX0"`
+		Limit          uint64 `form:"limit" maximum:"1000" example:"10"` // 10 by default.
+	}
 	User struct {
 		*users.UserProfile
 		Checksum string `json:"checksum,omitempty" example:"1232412415326543647657"`
@@ -53,7 +102,9 @@ type (
 const (
 	applicationYamlKey                  = "cmd/eskimo"
 	swaggerRoot                         = "/users/r"
+	defaultMaxReferralTreeDepth         = 10
 	everythingNotAllowedInUsernameRegex = `[^.a-zA-Z0-9]+`
+	etagHeader                          = "ETag"
 )
 
 // Values for server.ErrorResponse#Code.
@@ -81,7 +132,8 @@ type (
 		iceClient       emaillink.IceUserIDClient
 	}
 	config struct {
-		Host    string `yaml:"host"`
-		Version string `yaml:"version"`
+		Host                 string `yaml:"host"`
+		Version              string `yaml:"version"`
+		MaxReferralTreeDepth uint64 `yaml:"maxReferralTreeDepth"`
 	}
 )
@@ -58,7 +58,7 @@ func (s *service) Close(ctx context.Context) error {
 
 func (s *service) CheckHealth(ctx context.Context) error {
 	log.Debug("checking health...", "package", "users")
-	_, err := s.usersRepository.GetTopCountries(ctx, "", 1, 0)
+	_, err := s.usersRepository.GetTopCountries(ctx, "", users.TotalCountryStatisticsSortBy, 1, 1, 0)
 
 	return errors.Wrapf(err, "get top countries failed")
 }
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -16,7 +17,9 @@ func (s *service) setupUserReferralRoutes(router *server.Router) {
 	router.
 		Group("v1r").
 		GET("users/:userId/referral-acquisition-history", server.RootHandler(s.GetReferralAcquisitionHistory)).
-		GET("users/:userId/referrals", server.RootHandler(s.GetReferrals))
+		GET("users/:userId/referrals", server.RootHandler(s.GetReferrals)).
+		GET("users/:userId/referral-tree", server.RootHandler(s.GetReferralTree)).
+		GET("referral-leaderboard", server.RootHandler(s.GetReferralLeaderboard))
 }
 
 // GetReferralAcquisitionHistory godoc
@@ -29,7 +32,7 @@ func (s *service) setupUserReferralRoutes(router *server.Router) {
 //	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
 //	@Param			userId				path		string	true	"ID of the user"
-//	@Param			days				query		uint64	false	"Always is 5, cannot be changed due to DB schema"
+//	@Param			days				query		uint64	false	"Number of days to return, including today. Defaults to and is capped by the server side maximum"
 //	@Success		200					{array}		users.ReferralAcquisition
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
@@ -42,7 +45,7 @@ func (s *service) GetReferralAcquisitionHistory( //nolint:gocritic // False nega
 	ctx context.Context,
 	req *server.Request[GetReferralAcquisitionHistoryArg, []*users.ReferralAcquisition],
 ) (*server.Response[[]*users.ReferralAcquisition], *server.Response[server.ErrorResponse]) {
-	res, err := s.usersRepository.GetReferralAcquisitionHistory(ctx, req.Data.UserID)
+	res, err := s.usersRepository.GetReferralAcquisitionHistory(ctx, req.Data.UserID, req.Data.Days)
 	if err != nil {
 		return nil, server.Unexpected(errors.Wrapf(err, "error getting referral acquisition history for %#v", req.Data))
 	}
@@ -62,7 +65,8 @@ func (s *service) GetReferralAcquisitionHistory( //nolint:gocritic // False nega
 //	@Param			userId				path		string	true	"ID of the user"
 //	@Param			type				query		string	true	"Type of referrals: `CONTACTS` or `T1` or `T2`"
 //	@Param			limit				query		uint64	false	"Limit of elements to return. Defaults to 10"
-//	@Param			offset				query		uint64	false	"Number of elements to skip before collecting elements to return"
+//	@Param			offset				query		uint64	false	"Number of elements to skip before collecting elements to return. Ignored if `cursor` is provided"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` in the previous page"
 //	@Success		200					{object}	users.Referrals
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
@@ -92,10 +96,122 @@ func (s *service) GetReferrals( //nolint:gocritic // False negative.
 		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
 	}
 
-	referrals, err := s.usersRepository.GetReferrals(ctx, req.Data.UserID, users.ReferralType(strings.ToUpper(req.Data.Type)), req.Data.Limit, req.Data.Offset)
+	referrals, err := s.usersRepository.GetReferrals(ctx, req.Data.UserID, users.ReferralType(strings.ToUpper(req.Data.Type)), req.Data.Limit, req.Data.Offset, req.Data.Cursor) //nolint:lll // .
 	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid cursor for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to get referrals for %#v", req.Data))
 	}
 
 	return server.OK(referrals), nil
 }
+
+// GetReferralTree godoc
+//
+//	@Schemes
+//	@Description	Returns the referral tree of an user, up to the provided depth, with counts per level and a paginated list of nodes.
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the user"
+//	@Param			maxDepth			query		uint64	false	"Max depth of the tree. Defaults to and is capped by the server side maximum"
+//	@Param			limit				query		uint64	false	"Limit of nodes to return. Defaults to 10"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` in the previous page"
+//	@Success		200					{object}	users.ReferralTree
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/referral-tree [GET].
+func (s *service) GetReferralTree( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetReferralTreeArg, users.ReferralTree],
+) (*server.Response[users.ReferralTree], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole && req.Data.UserID != req.AuthenticatedUser.UserID {
+		return nil, server.Forbidden(errors.Errorf("not allowed to see referral tree of %v", req.Data.UserID))
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	maxDepth := cfg.MaxReferralTreeDepth
+	if maxDepth == 0 {
+		maxDepth = defaultMaxReferralTreeDepth
+	}
+	if req.Data.MaxDepth == 0 || req.Data.MaxDepth > maxDepth {
+		req.Data.MaxDepth = maxDepth
+	}
+	tree, err := s.usersRepository.GetReferralTree(ctx, req.Data.UserID, req.Data.MaxDepth, req.Data.Limit, req.Data.Cursor)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid cursor for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get referral tree for %#v", req.Data))
+	}
+
+	return server.OK(tree), nil
+}
+
+// GetReferralLeaderboard godoc
+//
+//	@Schemes
+//	@Description	Returns the paginated ranking of the top referrers, refreshed periodically. Only verified users that didn't hide their global rank or referral count are ranked.
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			tier				query		string	false	"Tier of the referrals to rank by. Defaults to T1"	Enums(T1,T2)
+//	@Param			period				query		string	false	"Period to count the referrals for. Defaults to all"	Enums(all,7d,30d)
+//	@Param			country				query		string	false	"ISO 3166 country code to restrict the ranking to"
+//	@Param			limit				query		uint64	false	"Limit of elements to return. Defaults to 10"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` in the previous page"
+//	@Success		200					{object}	users.ReferralLeaderboard
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/referral-leaderboard [GET].
+func (s *service) GetReferralLeaderboard( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetReferralLeaderboardArg, users.ReferralLeaderboard],
+) (*server.Response[users.ReferralLeaderboard], *server.Response[server.ErrorResponse]) {
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	tier := users.ReferralType(strings.ToUpper(req.Data.Tier))
+	if tier == "" {
+		tier = users.Tier1Referrals
+	}
+	if !slices.Contains(users.ReferralLeaderboardTiers, tier) {
+		err := errors.Errorf("tier '%v' is invalid, valid tiers are %v", req.Data.Tier, users.ReferralLeaderboardTiers)
+
+		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
+	period := users.ReferralLeaderboardPeriod(strings.ToLower(req.Data.Period))
+	if period == "" {
+		period = users.AllTimeReferralLeaderboardPeriod
+	}
+	if !slices.Contains(users.ReferralLeaderboardPeriods, period) {
+		err := errors.Errorf("period '%v' is invalid, valid periods are %v", req.Data.Period, users.ReferralLeaderboardPeriods)
+
+		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
+	leaderboard, err := s.usersRepository.GetReferralLeaderboard(ctx, tier, period, strings.ToUpper(req.Data.Country), req.Data.Limit, req.Data.Cursor)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) || errors.Is(err, users.ErrInvalidReferralLeaderboardPeriod) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid properties for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get referral leaderboard for %#v", req.Data))
+	}
+
+	return server.OK(leaderboard), nil
+}
@@ -4,6 +4,9 @@ package main
 
 import (
 	"context"
+	"slices"
+	"strconv"
+	"strings"
 	stdlibtime "time"
 
 	"github.com/pkg/errors"
@@ -16,19 +19,22 @@ func (s *service) setupUserStatisticsRoutes(router *server.Router) {
 	router.
 		Group("v1r").
 		GET("user-statistics/top-countries", server.RootHandler(s.GetTopCountries)).
+		GET("user-statistics/top-cities", server.RootHandler(s.GetTopCities)).
 		GET("user-statistics/user-growth", server.RootHandler(s.GetUserGrowth))
 }
 
 // GetTopCountries godoc
 //
 //	@Schemes
-//	@Description	Returns the paginated view of users per country.
+//	@Description	Returns the paginated view of users per country, including the max number of users active in a single day within the last `days` days.
 //	@Tags			Statistics
 //	@Accept			json
 //	@Produce		json
 //	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
 //	@Param			keyword				query		string	false	"a keyword to look for in all country codes or names"
+//	@Param			sortBy				query		string	false	"what to sort the countries by. Defaults to total"	Enums(total,active)
+//	@Param			days				query		uint64	false	"number of days, today included, to count the active users for. Defaults to 1, max 90"
 //	@Param			limit				query		uint64	false	"Limit of elements to return. Defaults to 10"
 //	@Param			offset				query		uint64	false	"Number of elements to skip before collecting elements to return"
 //	@Success		200					{array}		users.CountryStatistics
@@ -42,10 +48,26 @@ func (s *service) GetTopCountries( //nolint:gocritic // False negative.
 	ctx context.Context,
 	req *server.Request[GetTopCountriesArg, []*users.CountryStatistics],
 ) (*server.Response[[]*users.CountryStatistics], *server.Response[server.ErrorResponse]) {
+	const defaultDays, maxDays = 1, users.MaxTopCountriesDays
 	if req.Data.Limit == 0 {
 		req.Data.Limit = 10
 	}
-	result, err := s.usersRepository.GetTopCountries(ctx, req.Data.Keyword, req.Data.Limit, req.Data.Offset)
+	sortBy := users.CountryStatisticsSortBy(strings.ToLower(req.Data.SortBy))
+	if sortBy == "" {
+		sortBy = users.TotalCountryStatisticsSortBy
+	}
+	if !slices.Contains(users.CountryStatisticsSortByValues, sortBy) {
+		err := errors.Errorf("sortBy '%v' is invalid, valid values are %v", req.Data.SortBy, users.CountryStatisticsSortByValues)
+
+		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
+	if req.Data.Days == 0 {
+		req.Data.Days = defaultDays
+	}
+	if req.Data.Days > maxDays {
+		req.Data.Days = maxDays
+	}
+	result, err := s.usersRepository.GetTopCountries(ctx, req.Data.Keyword, sortBy, req.Data.Days, req.Data.Limit, req.Data.Offset)
 	if err != nil {
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to get top countries for: %#v", req.Data))
 	}
@@ -53,6 +75,41 @@ func (s *service) GetTopCountries( //nolint:gocritic // False negative.
 	return server.OK(&result), nil
 }
 
+// GetTopCities godoc
+//
+//	@Schemes
+//	@Description	Returns the paginated view of users per city.
+//	@Tags			Statistics
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			country				query		string	false	"ISO 3166 country code to restrict the cities to"
+//	@Param			keyword				query		string	false	"the beginning of the city names to look for"
+//	@Param			limit				query		uint64	false	"Limit of elements to return. Defaults to 10"
+//	@Param			offset				query		uint64	false	"Number of elements to skip before collecting elements to return"
+//	@Success		200					{array}		users.CityStatistics
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/user-statistics/top-cities [GET].
+func (s *service) GetTopCities( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetTopCitiesArg, []*users.CityStatistics],
+) (*server.Response[[]*users.CityStatistics], *server.Response[server.ErrorResponse]) {
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	result, err := s.usersRepository.GetTopCities(ctx, req.Data.Country, req.Data.Keyword, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get top cities for: %#v", req.Data))
+	}
+
+	return server.OK(&result), nil
+}
+
 // GetUserGrowth godoc
 //
 //	@Schemes
@@ -62,8 +119,9 @@ func (s *service) GetTopCountries( //nolint:gocritic // False negative.
 //	@Produce		json
 //	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
-//	@Param			days				query		uint64	false	"number of days in the past to look for. Defaults to 3. Max is 90."
-//	@Param			tz					query		string	false	"Timezone in format +04:30 or -03:45"
+//	@Param			days				query		uint64	false	"number of days in the past to look for. Defaults to 3. Max is 90 for `day` granularity and 730 for the others."
+//	@Param			granularity			query		string	false	"The size of each time series data point: `day`, `week` or `month`. Defaults to `day`"
+//	@Param			tz					query		string	false	"Timezone in format +04:30 or -03:45 or an IANA zone name, like Europe/Berlin"
 //	@Success		200					{object}	users.UserGrowthStatistics
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
@@ -75,29 +133,63 @@ func (s *service) GetUserGrowth( //nolint:gocritic // False negative.
 	ctx context.Context,
 	req *server.Request[GetUserGrowthArg, users.UserGrowthStatistics],
 ) (*server.Response[users.UserGrowthStatistics], *server.Response[server.ErrorResponse]) {
-	const defaultDays, maxDays = 3, 90
+	const defaultDays, maxDays, maxRollupDays = 3, 90, 730
+	granularity := users.UserGrowthGranularity(strings.ToLower(req.Data.Granularity))
+	if granularity == "" {
+		granularity = users.DayUserGrowthGranularity
+	}
+	if !slices.Contains(users.UserGrowthGranularities, granularity) {
+		err := errors.Errorf("granularity '%v' is invalid, valid granularities are %v", req.Data.Granularity, users.UserGrowthGranularities)
+
+		return nil, server.UnprocessableEntity(err, invalidPropertiesErrorCode)
+	}
 	if req.Data.Days == 0 {
 		req.Data.Days = defaultDays
 	}
-	if req.Data.Days > maxDays {
+	if granularity == users.DayUserGrowthGranularity && req.Data.Days > maxDays {
 		req.Data.Days = maxDays
 	}
-	tz := stdlibtime.UTC
-	if req.Data.TZ != "" {
-		var invertedTZ string
-		if req.Data.TZ[0] == '-' {
-			invertedTZ = "+" + req.Data.TZ[1:]
-		} else {
-			invertedTZ = "-" + req.Data.TZ[1:]
-		}
-		if t, err := stdlibtime.Parse("-07:00", invertedTZ); err == nil {
-			tz = t.Location()
-		}
+	if req.Data.Days > maxRollupDays {
+		req.Data.Days = maxRollupDays
 	}
-	result, err := s.usersRepository.GetUserGrowth(ctx, req.Data.Days, tz)
+	result, err := s.usersRepository.GetUserGrowth(ctx, req.Data.Days, granularity, invertedTZ(req.Data.TZ))
 	if err != nil {
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to get user growth stats for: %#v", req.Data))
 	}
 
 	return server.OK(result), nil
 }
+
+// The repository expects the timezone with its offset inverted, so `+04:30` becomes `-04:30`.
+// Unknown or invalid timezones fall back to UTC.
+func invertedTZ(tz string) *stdlibtime.Location {
+	if tz == "" {
+		return stdlibtime.UTC
+	}
+	if tz[0] != '+' && tz[0] != '-' {
+		loc, err := stdlibtime.LoadLocation(tz)
+		if err != nil {
+			return stdlibtime.UTC
+		}
+		_, offset := stdlibtime.Now().In(loc).Zone()
+
+		return stdlibtime.FixedZone(tz, -offset)
+	}
+	hoursAndMinutes := strings.SplitN(tz[1:], ":", 2) //nolint:gomnd // Hours and minutes.
+	hours, err := strconv.Atoi(hoursAndMinutes[0])
+	if err != nil || hours > 14 { //nolint:gomnd // Max UTC offset.
+		return stdlibtime.UTC
+	}
+	var minutes int
+	if len(hoursAndMinutes) > 1 {
+		if minutes, err = strconv.Atoi(hoursAndMinutes[1]); err != nil || minutes >= 60 { //nolint:gomnd // Minutes in an hour.
+			return stdlibtime.UTC
+		}
+	}
+	offset := int((stdlibtime.Duration(hours)*stdlibtime.Hour + stdlibtime.Duration(minutes)*stdlibtime.Minute) / stdlibtime.Second)
+	if tz[0] == '+' {
+		offset = -offset
+	}
+
+	return stdlibtime.FixedZone("", offset)
+}
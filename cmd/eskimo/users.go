@@ -17,7 +17,11 @@ func (s *service) setupUserRoutes(router *server.Router) {
 		Group("v1r").
 		GET("users", server.RootHandler(s.GetUsers)).
 		GET("users/:userId", server.RootHandler(s.GetUserByID)).
-		GET("user-views/username", server.RootHandler(s.GetUserByUsername))
+		GET("users/:userId/audit", server.RootHandler(s.GetUserAuditLog)).
+		GET("users/:userId/device-risk", server.RootHandler(s.GetDeviceRisk)).
+		GET("user-views/username", server.RootHandler(s.GetUserByUsername)).
+		GET("admin/users", server.RootHandler(s.SearchUsers)).
+		GET("admin/user-reports", server.RootHandler(s.GetUserReports))
 }
 
 // GetUsers godoc
@@ -31,8 +35,9 @@ func (s *service) setupUserRoutes(router *server.Router) {
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
 //	@Param			keyword				query		string	true	"A keyword to look for in the usernames"
 //	@Param			limit				query		uint64	false	"Limit of elements to return. Defaults to 10"
-//	@Param			offset				query		uint64	false	"Elements to skip before starting to look for"
-//	@Success		200					{array}		users.MinimalUserProfile
+//	@Param			offset				query		uint64	false	"Elements to skip before starting to look for. Ignored if `cursor` is provided"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` by the previous page"
+//	@Success		200					{object}	users.MinimalUserProfiles
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
 //	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
@@ -41,8 +46,8 @@ func (s *service) setupUserRoutes(router *server.Router) {
 //	@Router			/users [GET].
 func (s *service) GetUsers( //nolint:gocritic // False negative.
 	ctx context.Context,
-	req *server.Request[GetUsersArg, []*users.MinimalUserProfile],
-) (*server.Response[[]*users.MinimalUserProfile], *server.Response[server.ErrorResponse]) {
+	req *server.Request[GetUsersArg, users.MinimalUserProfiles],
+) (*server.Response[users.MinimalUserProfiles], *server.Response[server.ErrorResponse]) {
 	key := string(everythingNotAllowedInUsernamePattern.ReplaceAll([]byte(strings.ToLower(req.Data.Keyword)), []byte("")))
 	if key == "" || !strings.EqualFold(key, req.Data.Keyword) {
 		err := errors.Errorf("username: %v is invalid, it should match regex: %v", req.Data.Keyword, everythingNotAllowedInUsernamePattern)
@@ -52,12 +57,15 @@ func (s *service) GetUsers( //nolint:gocritic // False negative.
 	if req.Data.Limit == 0 {
 		req.Data.Limit = 10
 	}
-	resp, err := s.usersRepository.GetUsers(ctx, req.Data.Keyword, req.Data.Limit, req.Data.Offset)
+	resp, err := s.usersRepository.GetUsers(ctx, req.Data.Keyword, req.Data.Limit, req.Data.Offset, req.Data.Cursor)
 	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid cursor for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to get users by %#v", req.Data))
 	}
-
-	return server.OK(&resp), nil
+	return server.OK(resp), nil
 }
 
 // GetUserByID godoc
@@ -71,6 +79,7 @@ func (s *service) GetUsers( //nolint:gocritic // False negative.
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
 //	@Param			userId				path		string	true	"ID of the user"
 //	@Success		200					{object}	User
+//	@Header			200					{string}	ETag	"Version of the user, to be sent back in the `If-Match` header when modifying it. Missing for other users"
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
 //	@Failure		404					{object}	server.ErrorResponse	"if not found"
@@ -94,7 +103,12 @@ func (s *service) GetUserByID( //nolint:gocritic // False negative.
 		return nil, server.Unexpected(errors.Wrapf(err, "failed to get user by id: %v", req.Data.UserID))
 	}
 
-	return server.OK(&User{UserProfile: usr, Checksum: usr.Checksum()}), nil
+	okResp := server.OK(&User{UserProfile: usr, Checksum: usr.Checksum()})
+	if etag := usr.ETag(); etag != "" {
+		okResp.Headers = map[string]string{etagHeader: etag}
+	}
+
+	return okResp, nil
 }
 
 // GetUserByUsername godoc
@@ -107,6 +121,7 @@ func (s *service) GetUserByID( //nolint:gocritic // False negative.
 //	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
 //	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
 //	@Param			username			query		string	true	"username of the user. It will validate it first"
+//	@Param			resolvePrevious		query		bool	false	"If true and nobody has the username, returns the user that changed it recently"
 //	@Success		200					{object}	users.UserProfile
 //	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
 //	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
@@ -125,7 +140,7 @@ func (s *service) GetUserByUsername( //nolint:gocritic // False negative.
 		return nil, server.BadRequest(err, invalidUsernameErrorCode)
 	}
 
-	resp, err := s.usersRepository.GetUserByUsername(ctx, strings.ToLower(req.Data.Username))
+	resp, err := s.usersRepository.GetUserByUsername(ctx, strings.ToLower(req.Data.Username), req.Data.ResolvePrevious)
 	if err != nil {
 		if errors.Is(err, users.ErrNotFound) {
 			return nil, server.NotFound(errors.Wrapf(err, "user with username `%v` was not found", req.Data.Username), userNotFoundErrorCode)
@@ -136,3 +151,175 @@ func (s *service) GetUserByUsername( //nolint:gocritic // False negative.
 
 	return server.OK(resp), nil
 }
+
+// GetUserAuditLog godoc
+//
+//	@Schemes
+//	@Description	Returns the paginated log of changes made to an user, newest first. Only admins are allowed to see it.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the user"
+//	@Param			limit				query		uint64	false	"Limit of entries to return. Defaults to 10"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` in the previous page"
+//	@Success		200					{object}	users.UserAuditLog
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/audit [GET].
+func (s *service) GetUserAuditLog( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetUserAuditLogArg, users.UserAuditLog],
+) (*server.Response[users.UserAuditLog], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("not allowed to see audit log of %v", req.Data.UserID))
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	auditLog, err := s.usersRepository.GetUserAuditLog(ctx, req.Data.UserID, req.Data.Limit, req.Data.Cursor)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid cursor for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get audit log for %#v", req.Data))
+	}
+
+	return server.OK(auditLog), nil
+}
+
+// GetDeviceRisk godoc
+//
+//	@Schemes
+//	@Description	Returns why the user was flagged as possibly being part of a device farm, if it was. Only admins are allowed to see it.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			userId				path		string	true	"ID of the user"
+//	@Success		200					{object}	users.DeviceRisk
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/users/{userId}/device-risk [GET].
+func (s *service) GetDeviceRisk( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetDeviceRiskArg, users.DeviceRisk],
+) (*server.Response[users.DeviceRisk], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.Errorf("not allowed to see device risk of %v", req.Data.UserID))
+	}
+	risk, err := s.usersRepository.GetDeviceRisk(ctx, req.Data.UserID)
+	if err != nil {
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get device risk for %#v", req.Data))
+	}
+
+	return server.OK(risk), nil
+}
+
+// SearchUsers godoc
+//
+//	@Schemes
+//	@Description	Returns the users matching exactly all the provided filters. At least one filter is required. Only admins are allowed to use it.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization					header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata				header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			email							query		string	false	"The email of the user"
+//	@Param			phoneNumber						query		string	false	"The phone number of the user"
+//	@Param			blockchainAccountAddress		query		string	false	"The blockchain account address of the user"
+//	@Param			miningBlockchainAccountAddress	query		string	false	"The mining blockchain account address of the user"
+//	@Param			deviceUniqueId					query		string	false	"The unique id of one of the devices of the user"
+//	@Param			referredBy						query		string	false	"The id of the user that referred the user"
+//	@Param			limit							query		uint64	false	"Limit of elements to return. Defaults to 10"
+//	@Param			offset							query		uint64	false	"Elements to skip before starting to look for"
+//	@Success		200								{array}		users.User
+//	@Failure		400								{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401								{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403								{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422								{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500								{object}	server.ErrorResponse
+//	@Failure		504								{object}	server.ErrorResponse	"if request times out"
+//	@Router			/admin/users [GET].
+func (s *service) SearchUsers( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[SearchUsersArg, []*users.User],
+) (*server.Response[[]*users.User], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.New("not allowed to search users"))
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	filter := &users.UserSearchFilter{
+		Email:                          strings.TrimSpace(req.Data.Email),
+		PhoneNumber:                    strings.TrimSpace(req.Data.PhoneNumber),
+		BlockchainAccountAddress:       strings.TrimSpace(req.Data.BlockchainAccountAddress),
+		MiningBlockchainAccountAddress: strings.TrimSpace(req.Data.MiningBlockchainAccountAddress),
+		DeviceUniqueID:                 strings.TrimSpace(req.Data.DeviceUniqueID),
+		ReferredBy:                     strings.TrimSpace(req.Data.ReferredBy),
+	}
+	resp, err := s.usersRepository.SearchUsers(ctx, filter, req.Data.Limit, req.Data.Offset)
+	if err != nil {
+		if errors.Is(err, users.ErrNoSearchFilter) {
+			return nil, server.BadRequest(errors.Wrapf(err, "invalid search for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to search users by %#v", req.Data))
+	}
+
+	return server.OK(&resp), nil
+}
+
+// GetUserReports godoc
+//
+//	@Schemes
+//	@Description	Returns the paginated abuse reports, newest first. Only admins are allowed to see them.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization		header		string	true	"Insert your access token"		default(Bearer <Add access token here>)
+//	@Param			X-Account-Metadata	header		string	false	"Insert your metadata token"	default(<Add metadata token here>)
+//	@Param			reportedUserId		query		string	false	"Only the reports about this user"
+//	@Param			limit				query		uint64	false	"Limit of entries to return. Defaults to 10"
+//	@Param			cursor				query		string	false	"Opaque cursor returned as `nextCursor` in the previous page"
+//	@Success		200					{object}	users.UserReports
+//	@Failure		400					{object}	server.ErrorResponse	"if validations fail"
+//	@Failure		401					{object}	server.ErrorResponse	"if not authorized"
+//	@Failure		403					{object}	server.ErrorResponse	"if not allowed"
+//	@Failure		422					{object}	server.ErrorResponse	"if syntax fails"
+//	@Failure		500					{object}	server.ErrorResponse
+//	@Failure		504					{object}	server.ErrorResponse	"if request times out"
+//	@Router			/admin/user-reports [GET].
+func (s *service) GetUserReports( //nolint:gocritic // False negative.
+	ctx context.Context,
+	req *server.Request[GetUserReportsArg, users.UserReports],
+) (*server.Response[users.UserReports], *server.Response[server.ErrorResponse]) {
+	if req.AuthenticatedUser.Role != adminRole {
+		return nil, server.Forbidden(errors.New("not allowed to see user reports"))
+	}
+	if req.Data.Limit == 0 {
+		req.Data.Limit = 10
+	}
+	reports, err := s.usersRepository.GetUserReports(ctx, req.Data.ReportedUserID, req.Data.Limit, req.Data.Cursor)
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidCursor) {
+			return nil, server.UnprocessableEntity(errors.Wrapf(err, "invalid cursor for %#v", req.Data), invalidPropertiesErrorCode)
+		}
+
+		return nil, server.Unexpected(errors.Wrapf(err, "failed to get user reports for %#v", req.Data))
+	}
+
+	return server.OK(reports), nil
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/eskimo/users"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+const (
+	searchBenchmarkUserCount  = 100_000
+	searchBenchmarkT1Count    = 1_000
+	searchBenchmarkBatchSize  = 5_000
+	searchBenchmarkUserPrefix = "search-benchmark-"
+)
+
+// BenchmarkGetUsers benchmarks the user search against the network of a user with 1k T1 and 99k T2 referrals.
+func BenchmarkGetUsers(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := users.New(ctx, cancel)
+	defer func() { require.NoError(b, repo.Close()) }()
+	requestingUserID, usernames := seedSearchBenchmark(ctx, b)
+	reqCtx := context.WithValue(ctx, users.RequestingUserIDCtxValueKey, requestingUserID) //nolint:revive,staticcheck // That's how the API sets it.
+	for _, bc := range []struct {
+		keyword func(username string) string
+		name    string
+	}{
+		{name: "username part", keyword: func(username string) string { return strings.Split(username, ".")[0] }},
+		{name: "inside a username part", keyword: func(username string) string { return strings.Split(username, ".")[0][1:] }},
+		{name: "typo", keyword: func(username string) string {
+			keyword := []rune(strings.Split(username, ".")[0])
+			keyword[1], keyword[2] = keyword[2], keyword[1]
+
+			return string(keyword)
+		}},
+		{name: "shorter than a trigram", keyword: func(username string) string { return username[:2] }},
+	} {
+		keywordOf := bc.keyword
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := repo.GetUsers(reqCtx, keywordOf(usernames[(i*7919)%len(usernames)]), 10, 0, "")
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// The seeded users are kept between runs, so only the first run pays for the seeding.
+func seedSearchBenchmark(ctx context.Context, b *testing.B) (requestingUserID string, usernames []string) {
+	b.Helper()
+	db := storage.MustConnect(ctx, "", "users")
+	defer func() { require.NoError(b, db.Close()) }()
+	requestingUserID = searchBenchmarkUserPrefix + "requester"
+	now := stdlibtime.Now()
+	_, err := storage.Exec(ctx, db, insertSearchBenchmarkUsersSQL, []string{requestingUserID}, []string{"search.benchmark.requester"}, []string{"icenetwork"}, now)
+	require.NoError(b, err)
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // Reproducible dataset.
+	usernames = make([]string, 0, searchBenchmarkUserCount)
+	for len(usernames) < searchBenchmarkUserCount {
+		ids, batchUsernames, referredBy := make([]string, 0, searchBenchmarkBatchSize), make([]string, 0, searchBenchmarkBatchSize), make([]string, 0, searchBenchmarkBatchSize) //nolint:lll // .
+		for i := len(usernames); i < len(usernames)+searchBenchmarkBatchSize; i++ {
+			ids = append(ids, fmt.Sprintf("%v%v", searchBenchmarkUserPrefix, i))
+			batchUsernames = append(batchUsernames, fmt.Sprintf("%v.%v%v", randomSearchBenchmarkWord(rnd), randomSearchBenchmarkWord(rnd), i))
+			if i < searchBenchmarkT1Count {
+				referredBy = append(referredBy, requestingUserID)
+			} else {
+				referredBy = append(referredBy, fmt.Sprintf("%v%v", searchBenchmarkUserPrefix, i%searchBenchmarkT1Count))
+			}
+		}
+		_, err = storage.Exec(ctx, db, insertSearchBenchmarkUsersSQL, ids, batchUsernames, referredBy, now)
+		require.NoError(b, err)
+		usernames = append(usernames, batchUsernames...)
+	}
+	_, err = storage.Exec(ctx, db, `ANALYZE users`)
+	require.NoError(b, err)
+
+	return requestingUserID, usernames
+}
+
+const insertSearchBenchmarkUsersSQL = `
+	INSERT INTO users (created_at, updated_at, phone_number, phone_number_hash, email, id, username, profile_picture_name, referred_by, 
+					   city, country, mining_blockchain_account_address, blockchain_account_address, lookup)
+		SELECT $4, $4, id, id, id, id, username, 'default-profile-picture-1.png', referred_by,
+			   'Bucharest', 'RO', id, id, array_to_tsvector(array_remove(array_append(string_to_array(lower(username), '.'), lower(username)), ''))
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS x(id, username, referred_by)
+	ON CONFLICT (id) DO NOTHING`
+
+func randomSearchBenchmarkWord(rnd *rand.Rand) string {
+	const letters, minLength, maxLength = "abcdefghijklmnopqrstuvwxyz", 4, 9
+	word := make([]byte, minLength+rnd.Intn(maxLength-minLength))
+	for i := range word {
+		word[i] = letters[rnd.Intn(len(letters))]
+	}
+
+	return string(word)
+}
@@ -45,8 +45,8 @@ type (
 
 //nolint:funlen // Concurrency logic.
 func main() {
-	usersProcessor := users.StartProcessor(context.Background(), func() {})
 	authClient := auth.New(context.Background(), applicationYamlAuthKey)
+	usersProcessor := users.StartProcessor(context.Background(), func() {}, authClient)
 	authEmailLinkClient := emaillink.NewClient(context.Background(), usersProcessor, authClient)
 	db := storage.MustConnect(context.Background(), ddl, applicationYamlEskimoKey)
 	defer db.Close()
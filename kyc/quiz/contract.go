@@ -29,6 +29,7 @@ type (
 		io.Closer
 		GetQuizStatus(ctx context.Context, userIDs ...string) (map[string]*QuizStatus, error)
 		CheckHealth(ctx context.Context) error
+		users.UserDataExporter
 	}
 	Repository interface {
 		ReadRepository
@@ -95,6 +96,8 @@ const (
 
 	clientTypeCtxValueKey = "clientTypeCtxValueKey"
 
+	userDataExportSectionName = "quiz"
+
 	requestDeadline = 25 * stdlibtime.Second
 )
 
@@ -103,6 +106,14 @@ var (
 	ddl string
 
 	errSessionExpired = newError("session expired")
+
+	//nolint:gochecknoglobals // It's read only.
+	userDataExportTableQueries = map[string]string{
+		"quiz_sessions":                `SELECT * FROM quiz_sessions WHERE user_id = $1`,
+		"failed_quiz_sessions":         `SELECT * FROM failed_quiz_sessions WHERE user_id = $1`,
+		"failed_quiz_sessions_history": `SELECT * FROM failed_quiz_sessions_history WHERE user_id = $1`,
+		"quiz_resets":                  `SELECT * FROM quiz_resets WHERE user_id = $1`,
+	}
 )
 
 type (
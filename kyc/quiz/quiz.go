@@ -120,6 +120,15 @@ func (r *readRepository) Close() (err error) {
 	return
 }
 
+func (r *readRepository) ExportUserData(ctx context.Context, userID UserID) (*users.UserDataExportSection, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "export user data failed because context failed")
+	}
+	section, err := users.SelectUserDataExportSection(ctx, r.DB, userDataExportSectionName, userDataExportTableQueries, userID)
+
+	return section, errors.Wrapf(err, "failed to export quiz data for userID:%v", userID)
+}
+
 func (r *repositoryImpl) CheckUserKYC(ctx context.Context, userID UserID) error {
 	profile, err := r.Users.GetUserByID(ctx, userID)
 	if err != nil {
@@ -914,6 +923,8 @@ func (r *repositoryImpl) modifyUser(ctx context.Context, success, blocked bool,
 	}
 	(*usr.KYCStepsLastUpdatedAt)[int(newKYCStep)-1] = now
 
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.UserAuditActorType, ID: usr.ID, Source: applicationYamlKey})
+
 	return errors.Wrapf(r.Users.ModifyUser(ctx, usr, nil), "failed to modify user %#v", usr)
 }
 
@@ -639,3 +639,14 @@ func TestSessionManager(t *testing.T) {
 
 	require.NoError(t, repo.Close())
 }
+
+func TestUserDataExportTableQueries(t *testing.T) {
+	t.Parallel()
+
+	tables := make([]string, 0, len(userDataExportTableQueries))
+	for table, sql := range userDataExportTableQueries {
+		tables = append(tables, table)
+		require.Contains(t, sql, "FROM "+table+" WHERE user_id = $1", table)
+	}
+	require.ElementsMatch(t, []string{"quiz_sessions", "failed_quiz_sessions", "failed_quiz_sessions_history", "quiz_resets"}, tables)
+}
@@ -69,6 +69,7 @@ type (
 		io.Closer
 		VerifyPost(ctx context.Context, metadata *VerificationMetadata) (*Verification, error)
 		SkipVerification(ctx context.Context, kycStep users.KYCStep, userID string) error
+		users.UserDataExporter
 	}
 	UserRepository interface {
 		GetUserByID(ctx context.Context, userID string) (*users.UserProfile, error)
@@ -81,6 +82,8 @@ type (
 const (
 	applicationYamlKey = "kyc/social"
 
+	userDataExportSectionName = "social"
+
 	requestDeadline = 25 * stdlibtime.Second
 )
 
@@ -102,6 +105,13 @@ var (
 	allLanguageTemplateType = [1]languageTemplateType{postContentLanguageTemplateType}
 	//nolint:gochecknoglobals // Its loaded once at startup.
 	allTemplates = make(map[users.KYCStep]map[Type]map[languageTemplateType]map[languageCode]*languageTemplate, len(AllSupportedKYCSteps))
+
+	//nolint:gochecknoglobals // It's read only.
+	userDataExportTableQueries = map[string]string{
+		"social_kyc_steps":                 `SELECT * FROM social_kyc_steps WHERE user_id = $1`,
+		"socials":                          `SELECT * FROM socials WHERE user_id = $1`,
+		"social_kyc_unsuccessful_attempts": `SELECT * FROM social_kyc_unsuccessful_attempts WHERE user_id = $1`,
+	}
 )
 
 type (
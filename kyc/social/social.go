@@ -85,6 +85,15 @@ func (r *repository) Close() error {
 	return errors.Wrap(r.db.Close(), "closing kyc/social repository failed")
 }
 
+func (r *repository) ExportUserData(ctx context.Context, userID users.UserID) (*users.UserDataExportSection, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "export user data failed because context failed")
+	}
+	section, err := users.SelectUserDataExportSection(ctx, r.db, userDataExportSectionName, userDataExportTableQueries, userID)
+
+	return section, errors.Wrapf(err, "failed to export social kyc data for userID:%v", userID)
+}
+
 func (r *repository) SkipVerification(ctx context.Context, kycStep users.KYCStep, userID string) error {
 	now := time.Now()
 	user, err := r.user.GetUserByID(ctx, userID)
@@ -298,6 +307,8 @@ func (r *repository) modifyUser(ctx context.Context, success, skip bool, kycStep
 		}
 	}
 
+	ctx = users.ContextWithAuditActor(ctx, &users.AuditActor{Type: users.UserAuditActorType, ID: usr.ID, Source: applicationYamlKey})
+
 	return errors.Wrapf(r.user.ModifyUser(ctx, usr, nil), "[skip:%v]failed to modify user %#v", skip, usr)
 }
 
@@ -50,3 +50,14 @@ func TestSocialSave(t *testing.T) {
 
 	require.NoError(t, db.Close())
 }
+
+func TestUserDataExportTableQueries(t *testing.T) {
+	t.Parallel()
+
+	tables := make([]string, 0, len(userDataExportTableQueries))
+	for table, sql := range userDataExportTableQueries {
+		tables = append(tables, table)
+		require.Contains(t, sql, "FROM "+table+" WHERE user_id = $1", table)
+	}
+	require.ElementsMatch(t, []string{"social_kyc_steps", "socials", "social_kyc_unsuccessful_attempts"}, tables)
+}
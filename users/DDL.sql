@@ -47,6 +47,9 @@ END $$;
 ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_step_blocked smallint NOT NULL DEFAULT 0;
 ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_steps_last_updated_at timestamp[];
 ALTER TABLE users ADD COLUMN IF NOT EXISTS kyc_steps_created_at timestamp[];
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_deletion_at timestamp;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mining_blockchain_account_address_changed_at timestamp;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS blockchain_account_address_verified_at timestamp;
 INSERT INTO users (created_at,updated_at,phone_number,phone_number_hash,email,id,username,profile_picture_name,referred_by,city,country,mining_blockchain_account_address,blockchain_account_address, lookup)
                          VALUES (current_timestamp,current_timestamp,'bogus','bogus','bogus','bogus','bogus','bogus.jpg','bogus','bogus','RO','bogus','bogus',to_tsvector('bogus')),
                                 (current_timestamp,current_timestamp,'icenetwork','icenetwork','icenetwork','icenetwork','icenetwork','icenetwork.jpg','icenetwork','icenetwork','RO','icenetwork','icenetwork',to_tsvector('icenetwork'))
@@ -54,10 +57,133 @@ ON CONFLICT DO NOTHING;
 CREATE INDEX IF NOT EXISTS users_referred_by_ix ON users (referred_by);
 CREATE EXTENSION IF NOT EXISTS btree_gin;
 CREATE INDEX IF NOT EXISTS users_lookup_gin_idx ON users USING GIN (lookup);
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+----
+-- It runs alone (see the separators), so it can commit each batch instead of rewriting every user in one transaction.
+-- The index is created last, so an interrupted rebuild is resumed on the next start.
+DO $$
+DECLARE
+    last_id text := '';
+    batch_last_id text;
+BEGIN
+    if to_regclass('users_username_trgm_gin_idx') is null then
+        loop
+            SELECT max(id) INTO batch_last_id FROM (SELECT id FROM users WHERE id > last_id ORDER BY id LIMIT 10000) batch;
+            exit when batch_last_id is null;
+            UPDATE users
+               SET lookup = array_to_tsvector(array_remove(array_append(string_to_array(lower(username), '.'), lower(username)), ''))
+             WHERE id > last_id
+               AND id <= batch_last_id
+               AND lookup != array_to_tsvector(array_remove(array_append(string_to_array(lower(username), '.'), lower(username)), ''));
+            last_id := batch_last_id;
+            COMMIT;
+        end loop;
+        CREATE INDEX users_username_trgm_gin_idx ON users USING GIN (lower(username) gin_trgm_ops);
+    end if;
+END $$;
+----
+-- It mirrors the normalization of the TON addresses done by the application: the raw `workchain:hex` form.
+CREATE OR REPLACE FUNCTION canonical_ton_address(address text) RETURNS text AS $$
+DECLARE
+    decoded bytea;
+BEGIN
+    if address ~ '^(0|-1):[0-9a-fA-F]{64}$' then
+        return lower(address);
+    end if;
+    if address !~ '^[A-Za-z0-9+/_-]{48}$' then
+        return address;
+    end if;
+    decoded := decode(translate(address, '-_', '+/'), 'base64');
+    if (get_byte(decoded, 0) & 127) not in (17, 81) or get_byte(decoded, 1) not in (0, 255) then
+        return address;
+    end if;
+    return (CASE get_byte(decoded, 1) WHEN 255 THEN '-1' ELSE '0' END) || ':' || encode(substring(decoded from 3 for 32), 'hex');
+END $$ LANGUAGE plpgsql IMMUTABLE;
+CREATE TABLE IF NOT EXISTS users_data_migrations (
+                    done_at timestamp NOT NULL,
+                    name text primary key);
+----
+-- It runs alone (see the separators), so it can commit each batch. The TON addresses stored before they were normalized are converted,
+-- unless another user already has the same account. Its row in users_data_migrations marks the conversion as done.
+DO $$
+DECLARE
+    last_id text := '';
+    batch_last_id text;
+BEGIN
+    if not exists (SELECT 1 FROM users_data_migrations WHERE name = 'canonical_ton_addresses') then
+        loop
+            SELECT max(id) INTO batch_last_id FROM (SELECT id FROM users WHERE id > last_id ORDER BY id LIMIT 10000) batch;
+            exit when batch_last_id is null;
+            UPDATE users
+               SET blockchain_account_address = batch.address
+              FROM (SELECT DISTINCT ON (canonical_ton_address(blockchain_account_address)) id, canonical_ton_address(blockchain_account_address) AS address
+                      FROM users
+                     WHERE id > last_id
+                       AND id <= batch_last_id
+                     ORDER BY canonical_ton_address(blockchain_account_address), id) batch
+             WHERE users.id = batch.id
+               AND users.blockchain_account_address != batch.address
+               AND NOT EXISTS (SELECT 1 FROM users other WHERE other.blockchain_account_address = batch.address);
+            UPDATE users
+               SET mining_blockchain_account_address = batch.address
+              FROM (SELECT DISTINCT ON (canonical_ton_address(mining_blockchain_account_address)) id, canonical_ton_address(mining_blockchain_account_address) AS address
+                      FROM users
+                     WHERE id > last_id
+                       AND id <= batch_last_id
+                     ORDER BY canonical_ton_address(mining_blockchain_account_address), id) batch
+             WHERE users.id = batch.id
+               AND users.mining_blockchain_account_address != batch.address
+               AND NOT EXISTS (SELECT 1 FROM users other WHERE other.mining_blockchain_account_address = batch.address);
+            last_id := batch_last_id;
+            COMMIT;
+        end loop;
+        INSERT INTO users_data_migrations (done_at, name) VALUES (now(), 'canonical_ton_addresses') ON CONFLICT DO NOTHING;
+    end if;
+END $$;
+----
+CREATE INDEX IF NOT EXISTS users_pending_deletion_at_ix ON users (pending_deletion_at) WHERE pending_deletion_at IS NOT NULL;
 CREATE TABLE IF NOT EXISTS users_per_country  (
                     user_count BIGINT NOT NULL DEFAULT 0,
                     country text primary key
                      );
+CREATE TABLE IF NOT EXISTS active_users_per_country_per_day  (
+                    user_count BIGINT NOT NULL DEFAULT 0,
+                    date date NOT NULL,
+                    country text NOT NULL,
+                    primary key(date, country)
+                     );
+DO $$ BEGIN
+    if to_regclass('users_per_city') is null then
+        CREATE TABLE users_per_city  (
+                    user_count BIGINT NOT NULL DEFAULT 0,
+                    country text NOT NULL,
+                    city text NOT NULL,
+                    primary key(country, city)
+                     );
+        -- One time backfill, when the table is created, with the same users and the same city format (see NormalizeCity) as the live aggregation.
+        INSERT INTO users_per_city (country, city, user_count)
+        SELECT country, city, count(1)
+        FROM (SELECT u.country,
+                     (CASE
+                         WHEN btrim(u.city) IN ('', '-') THEN ''
+                         ELSE (SELECT string_agg((SELECT string_agg(upper(left(p.part, 1)) || substr(p.part, 2), '-' ORDER BY p.ix)
+                                                  FROM unnest(string_to_array(lower(w.word), '-')) WITH ORDINALITY AS p(part, ix)),
+                                                 ' ' ORDER BY w.ix)
+                               FROM unnest(regexp_split_to_array(btrim(u.city), '\s+')) WITH ORDINALITY AS w(word, ix))
+                      END) AS city
+              FROM users u
+              WHERE u.kyc_step_passed >= 2
+                AND u.last_mining_started_at IS NOT NULL
+                AND u.kyc_steps_created_at[2] IS NOT NULL
+                AND u.kyc_steps_last_updated_at[2] IS NOT NULL
+                AND u.kyc_steps_created_at[2] < u.last_mining_started_at
+                AND COALESCE(u.country, '') != ''
+                AND u.city IS NOT NULL) x
+        WHERE city != ''
+        GROUP BY country, city;
+    end if;
+END $$;
+CREATE INDEX IF NOT EXISTS users_per_city_user_count_ix ON users_per_city (user_count DESC);
 
 CREATE TABLE IF NOT EXISTS kyc_steps_reset_requests  (
                     user_id text primary key,
@@ -123,6 +249,31 @@ CREATE TABLE IF NOT EXISTS device_metadata  (
                     usage_type              text,
                     primary key(user_id, device_unique_id))
                     WITH (FILLFACTOR = 70);
+CREATE TABLE IF NOT EXISTS device_accounts  (
+                    kind                    text NOT NULL,
+                    value                   text NOT NULL,
+                    user_id                 text NOT NULL,
+                    primary key(kind, value, user_id));
+ALTER TABLE device_accounts DROP CONSTRAINT IF EXISTS device_accounts_user_id_fkey;
+CREATE INDEX IF NOT EXISTS device_accounts_user_id_ix ON device_accounts (user_id);
+CREATE TABLE IF NOT EXISTS device_risk_flags  (
+                    flagged_at              timestamp NOT NULL,
+                    fingerprint_accounts    bigint NOT NULL DEFAULT 0,
+                    device_accounts         bigint NOT NULL DEFAULT 0,
+                    user_id                 text NOT NULL PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                    device_unique_id        text NOT NULL,
+                    reasons                 text[] NOT NULL);
+CREATE TABLE IF NOT EXISTS ip_risk_assessments  (
+                    created_at              timestamp NOT NULL,
+                    user_id                 text NOT NULL DEFAULT '',
+                    device_unique_id        text NOT NULL DEFAULT '',
+                    route                   text NOT NULL,
+                    client_ip               text NOT NULL,
+                    classification          text NOT NULL,
+                    action                  text NOT NULL,
+                    usage_type              text NOT NULL DEFAULT '');
+CREATE INDEX IF NOT EXISTS ip_risk_assessments_user_id_created_at_ix ON ip_risk_assessments (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS ip_risk_assessments_created_at_ix ON ip_risk_assessments (created_at);
 CREATE TABLE IF NOT EXISTS global  (
                     value bigint NOT NULL,
                     key text primary key)
@@ -131,20 +282,56 @@ INSERT INTO global (key,value) VALUES ('TOTAL_USERS', 0) ON CONFLICT DO NOTHING;
 
 CREATE TABLE IF NOT EXISTS referral_acquisition_history (
      T1                      BIGINT DEFAULT 0,
-     T1_TODAY                BIGINT DEFAULT 0,
-     T1_TODAY_MINUS_1        BIGINT DEFAULT 0,
-     T1_TODAY_MINUS_2        BIGINT DEFAULT 0,
-     T1_TODAY_MINUS_3        BIGINT DEFAULT 0,
-     T1_TODAY_MINUS_4        BIGINT DEFAULT 0,
      T2                      BIGINT DEFAULT 0,
-     T2_TODAY                BIGINT DEFAULT 0,
-     T2_TODAY_MINUS_1        BIGINT DEFAULT 0,
-     T2_TODAY_MINUS_2        BIGINT DEFAULT 0,
-     T2_TODAY_MINUS_3        BIGINT DEFAULT 0,
-     T2_TODAY_MINUS_4        BIGINT DEFAULT 0,
-     DATE                    DATE NOT NULL,
      USER_ID                 TEXT PRIMARY KEY
 );
+CREATE TABLE IF NOT EXISTS referral_acquisition_history_per_day (
+     T1                      BIGINT DEFAULT 0 NOT NULL,
+     T2                      BIGINT DEFAULT 0 NOT NULL,
+     DATE                    DATE NOT NULL,
+     USER_ID                 TEXT NOT NULL,
+     PRIMARY KEY (user_id, date)
+);
+CREATE INDEX IF NOT EXISTS referral_acquisition_history_per_day_date_ix ON referral_acquisition_history_per_day (date);
+
+DO $$ BEGIN
+    if exists (select column_name from information_schema.columns where table_name = 'referral_acquisition_history' and column_name = 't1_today') then
+        INSERT INTO referral_acquisition_history_per_day (user_id, date, t1, t2)
+            SELECT h.user_id, h.date - days.day, COALESCE(days.t1, 0), COALESCE(days.t2, 0)
+            FROM referral_acquisition_history h,
+                 LATERAL (VALUES (0, h.t1_today, h.t2_today),
+                                 (1, h.t1_today_minus_1, h.t2_today_minus_1),
+                                 (2, h.t1_today_minus_2, h.t2_today_minus_2),
+                                 (3, h.t1_today_minus_3, h.t2_today_minus_3),
+                                 (4, h.t1_today_minus_4, h.t2_today_minus_4)) AS days(day, t1, t2)
+            WHERE COALESCE(days.t1, 0) > 0 OR COALESCE(days.t2, 0) > 0
+        ON CONFLICT (user_id, date) DO NOTHING;
+        ALTER TABLE referral_acquisition_history
+            DROP COLUMN t1_today,
+            DROP COLUMN t1_today_minus_1,
+            DROP COLUMN t1_today_minus_2,
+            DROP COLUMN t1_today_minus_3,
+            DROP COLUMN t1_today_minus_4,
+            DROP COLUMN t2_today,
+            DROP COLUMN t2_today_minus_1,
+            DROP COLUMN t2_today_minus_2,
+            DROP COLUMN t2_today_minus_3,
+            DROP COLUMN t2_today_minus_4,
+            DROP COLUMN date;
+    end if;
+END $$;
+
+CREATE TABLE IF NOT EXISTS referral_leaderboard (
+     RANK                    BIGINT NOT NULL,
+     COUNTRY_RANK            BIGINT NOT NULL,
+     REFERRAL_COUNT          BIGINT NOT NULL,
+     PERIOD                  TEXT NOT NULL,
+     TIER                    TEXT NOT NULL,
+     COUNTRY                 TEXT NOT NULL,
+     USER_ID                 TEXT NOT NULL,
+     PRIMARY KEY (period, tier, rank)
+);
+CREATE INDEX IF NOT EXISTS referral_leaderboard_country_ix ON referral_leaderboard (period, tier, country, country_rank);
 
 CREATE TABLE IF NOT EXISTS processed_referrals (
                             processed_at            TIMESTAMP,
@@ -163,4 +350,61 @@ DO $$ BEGIN
         ALTER TABLE processed_referrals
             ADD CONSTRAINT processed_referrals_id_refby_deleted_pkey PRIMARY KEY(user_id, referred_by, deleted);
     end if;
-END $$;
\ No newline at end of file
+END $$;
+CREATE TABLE IF NOT EXISTS users_outbox (
+                            id                      BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+                            created_at              TIMESTAMP NOT NULL,
+                            topic                   TEXT NOT NULL,
+                            key                     TEXT NOT NULL,
+                            value                   BYTEA
+);
+CREATE INDEX IF NOT EXISTS users_outbox_topic_key_id_ix ON users_outbox (topic, key, id);
+CREATE TABLE IF NOT EXISTS agenda_contacts_sync (
+                            synced_at               TIMESTAMP NOT NULL,
+                            version                 BIGINT NOT NULL DEFAULT 0,
+                            user_id                 TEXT NOT NULL PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS wallet_ownership_challenges (
+                            expires_at              TIMESTAMP NOT NULL,
+                            user_id                 TEXT NOT NULL PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                            address                 TEXT NOT NULL,
+                            nonce                   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS user_blocks (
+                            created_at              TIMESTAMP NOT NULL,
+                            user_id                 TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            blocked_user_id         TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            PRIMARY KEY (user_id, blocked_user_id)
+);
+CREATE INDEX IF NOT EXISTS user_blocks_blocked_user_id_ix ON user_blocks (blocked_user_id);
+CREATE TABLE IF NOT EXISTS user_reports (
+                            id                      BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+                            created_at              TIMESTAMP NOT NULL,
+                            reporter_user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            reported_user_id        TEXT NOT NULL,
+                            reason                  TEXT NOT NULL,
+                            comment                 TEXT NOT NULL DEFAULT ''
+);
+ALTER TABLE user_reports DROP CONSTRAINT IF EXISTS user_reports_reported_user_id_fkey;
+CREATE INDEX IF NOT EXISTS user_reports_reported_user_id_id_ix ON user_reports (reported_user_id, id);
+CREATE TABLE IF NOT EXISTS user_audit_log (
+                            id                      BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+                            created_at              TIMESTAMP NOT NULL,
+                            user_id                 TEXT NOT NULL,
+                            actor_type              TEXT NOT NULL,
+                            actor_id                TEXT,
+                            source                  TEXT NOT NULL,
+                            changed_fields          TEXT[] NOT NULL,
+                            before                  JSONB,
+                            after                   JSONB
+);
+CREATE INDEX IF NOT EXISTS user_audit_log_user_id_id_ix ON user_audit_log (user_id, id);
+CREATE INDEX IF NOT EXISTS user_audit_log_created_at_ix ON user_audit_log (created_at);
+CREATE TABLE IF NOT EXISTS username_history (
+                            changed_at              TIMESTAMP NOT NULL,
+                            user_id                 TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                            old_username            TEXT NOT NULL,
+                            new_username            TEXT NOT NULL,
+                            PRIMARY KEY (user_id, changed_at)
+);
+CREATE INDEX IF NOT EXISTS username_history_old_username_changed_at_ix ON username_history (old_username, changed_at DESC);
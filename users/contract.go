@@ -15,9 +15,15 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/blockchain/address"
+	"github.com/ice-blockchain/eskimo/users/internal/dataexport"
 	"github.com/ice-blockchain/eskimo/users/internal/device"
 	devicemetadata "github.com/ice-blockchain/eskimo/users/internal/device/metadata"
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
+	"github.com/ice-blockchain/eskimo/users/internal/referral/reassignment"
+	usernamepolicy "github.com/ice-blockchain/eskimo/users/internal/username/policy"
 	"github.com/ice-blockchain/wintr/analytics/tracking"
+	"github.com/ice-blockchain/wintr/auth"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/multimedia/picture"
@@ -29,6 +35,7 @@ import (
 const (
 	UsernameRegex               = `^[.a-zA-Z0-9]{4,30}$`
 	RequestingUserIDCtxValueKey = "requestingUserIDCtxValueKey"
+	UserDataExportVersion       = dataexport.Version
 )
 
 const (
@@ -46,6 +53,47 @@ const (
 	TeamReferrals     ReferralType = "TEAM"
 )
 
+const (
+	ReplaceContactsSyncMode ContactsSyncMode = "replace"
+	DeltaContactsSyncMode   ContactsSyncMode = "delta"
+)
+
+const (
+	SpamUserReportReason                 UserReportReason = "spam"
+	HarassmentUserReportReason           UserReportReason = "harassment"
+	ImpersonationUserReportReason        UserReportReason = "impersonation"
+	InappropriateContentUserReportReason UserReportReason = "inappropriateContent"
+	OtherUserReportReason                UserReportReason = "other"
+
+	MaxUserReportCommentLength = 1000
+)
+
+const (
+	AllTimeReferralLeaderboardPeriod    ReferralLeaderboardPeriod = "all"
+	SevenDaysReferralLeaderboardPeriod  ReferralLeaderboardPeriod = "7d"
+	ThirtyDaysReferralLeaderboardPeriod ReferralLeaderboardPeriod = "30d"
+)
+
+const (
+	TotalCountryStatisticsSortBy  CountryStatisticsSortBy = "total"
+	ActiveCountryStatisticsSortBy CountryStatisticsSortBy = "active"
+
+	MaxTopCountriesDays = 90
+)
+
+const (
+	DayUserGrowthGranularity   UserGrowthGranularity = "day"
+	WeekUserGrowthGranularity  UserGrowthGranularity = "week"
+	MonthUserGrowthGranularity UserGrowthGranularity = "month"
+)
+
+const (
+	UserAuditActorType     AuditActorType = "user"
+	AdminAuditActorType    AuditActorType = "admin"
+	APIKeyAuditActorType   AuditActorType = "apiKey"
+	InternalAuditActorType AuditActorType = "internal"
+)
+
 const (
 	NoneKYCStep KYCStep = iota
 	FacialRecognitionKYCStep
@@ -66,11 +114,56 @@ var (
 	ErrDuplicate          = storage.ErrDuplicate
 	ErrInvalidAppVersion  = devicemetadata.ErrInvalidAppVersion
 	ErrOutdatedAppVersion = devicemetadata.ErrOutdatedAppVersion
+	ErrIPRejected         = devicemetadata.ErrIPRejected
 	ErrInvalidCountry     = errors.New("country invalid")
 	ErrRaceCondition      = errors.New("race condition")
+	ErrInvalidCursor      = pagination.ErrInvalidCursor
+	ErrNoSearchFilter     = errors.New("no search filter")
+	ErrInvalidGranularity = errors.New("invalid granularity")
+	ErrInvalidSortBy      = errors.New("invalid sort by")
+
+	ErrInvalidReferralLeaderboardTier   = errors.New("invalid referral leaderboard tier")
+	ErrInvalidReferralLeaderboardPeriod = errors.New("invalid referral leaderboard period")
+
+	ErrInvalidContactsSyncMode     = errors.New("invalid contacts sync mode")
+	ErrContactsSyncVersionMismatch = errors.New("contacts sync version mismatch")
+	ErrTooManyContacts             = errors.New("too many contacts")
+
+	ErrInvalidBlockchainAccountAddress                  = address.ErrInvalid
+	ErrMiningBlockchainAccountAddressChangeCooldown     = errors.New("mining blockchain account address changed too recently")
+	ErrMiningBlockchainAccountAddressChangeNotConfirmed = errors.New("mining blockchain account address change was not confirmed via email")
+	ErrUsernameChangeLimitReached                       = usernamepolicy.ErrChangeLimitReached
+	ErrUsernameQuarantined                              = usernamepolicy.ErrQuarantined
+	ErrWalletOwnershipChallengeNotFound                 = errors.New("wallet ownership challenge not found")
+	ErrInvalidWalletOwnershipSignature                  = address.ErrInvalidSignature
+
+	ErrTargetingYourself       = errors.New("users can't block or report themselves")
+	ErrInvalidUserReportReason = errors.New("invalid user report reason")
 	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
 	ReferralTypes = Enum[ReferralType]{ContactsReferrals, Tier1Referrals, Tier2Referrals, TeamReferrals}
 	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	ContactsSyncModes = Enum[ContactsSyncMode]{ReplaceContactsSyncMode, DeltaContactsSyncMode}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	UserReportReasons = Enum[UserReportReason]{
+		SpamUserReportReason,
+		HarassmentUserReportReason,
+		ImpersonationUserReportReason,
+		InappropriateContentUserReportReason,
+		OtherUserReportReason,
+	}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	ReferralLeaderboardTiers = Enum[ReferralType]{Tier1Referrals, Tier2Referrals}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	ReferralLeaderboardPeriods = Enum[ReferralLeaderboardPeriod]{
+		AllTimeReferralLeaderboardPeriod,
+		SevenDaysReferralLeaderboardPeriod,
+		ThirtyDaysReferralLeaderboardPeriod,
+	}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	UserGrowthGranularities = Enum[UserGrowthGranularity]{DayUserGrowthGranularity, WeekUserGrowthGranularity, MonthUserGrowthGranularity}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
+	CountryStatisticsSortByValues = Enum[CountryStatisticsSortBy]{TotalCountryStatisticsSortBy, ActiveCountryStatisticsSortBy}
+	//nolint:gochecknoglobals // It's just for more descriptive validation messages.
 	HiddenProfileElements = Enum[HiddenProfileElement]{
 		GlobalRankHiddenProfileElement,
 		ReferralCountHiddenProfileElement,
@@ -82,14 +175,21 @@ var (
 )
 
 type (
-	KYCStep                  int8
-	ReferralType             string
-	HiddenProfileElement     string
-	NotExpired               bool
-	Enum[T ~string]          []T
-	JSON                     map[string]any
-	UserID                   = string
-	SensitiveUserInformation struct {
+	KYCStep                   int8
+	ReferralType              string
+	ReferralLeaderboardPeriod string
+	ContactsSyncMode          string
+	UserReportReason          string
+	HiddenProfileElement      string
+	AuditActorType            string
+	UserGrowthGranularity     string
+	CountryStatisticsSortBy   string
+	NotExpired                bool
+	Enum[T ~string]           []T
+	JSON                      map[string]any
+	UserID                    = string
+	Cursor                    = string
+	SensitiveUserInformation  struct {
 		PhoneNumber string `json:"phoneNumber,omitempty" example:"+12099216581" swaggertype:"string" db:"phone_number"`
 		Email       string `json:"email,omitempty" example:"jdoe@gmail.com" swaggertype:"string" db:"email"`
 	}
@@ -105,21 +205,25 @@ type (
 		ProfilePictureURL string `json:"profilePictureUrl,omitempty" example:"https://somecdn.com/p1.jpg" db:"profile_picture_name"`
 	}
 	User struct {
-		CreatedAt               *time.Time                  `json:"createdAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"created_at"`
-		UpdatedAt               *time.Time                  `json:"updatedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"updated_at"`
-		LastMiningStartedAt     *time.Time                  `json:"lastMiningStartedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_mining_started_at"`                                       //nolint:lll // .
-		LastMiningEndedAt       *time.Time                  `json:"lastMiningEndedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_mining_ended_at"`                                           //nolint:lll // .
-		LastPingCooldownEndedAt *time.Time                  `json:"lastPingCooldownEndedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_ping_cooldown_ended_at"`                              //nolint:lll // .
-		HiddenProfileElements   *Enum[HiddenProfileElement] `json:"hiddenProfileElements,omitempty" swaggertype:"array,string" example:"level" enums:"globalRank,referralCount,level,role,badges" db:"hidden_profile_elements"` //nolint:lll // .
-		RandomReferredBy        *bool                       `json:"randomReferredBy,omitempty" example:"true" swaggerignore:"true" db:"random_referred_by"`
-		Verified                *bool                       `json:"verified,omitempty" example:"true" db:"-"`
-		QuizCompleted           *bool                       `json:"-" db:"quiz_completed"`
-		KYCStepsLastUpdatedAt   *[]*time.Time               `json:"kycStepsLastUpdatedAt,omitempty" swaggertype:"array,string" example:"2022-01-03T16:20:52.156534Z" db:"kyc_steps_last_updated_at"` //nolint:lll // .
-		KYCStepsCreatedAt       *[]*time.Time               `json:"kycStepsCreatedAt,omitempty" swaggertype:"array,string" example:"2022-01-03T16:20:52.156534Z" db:"kyc_steps_created_at"`          //nolint:lll // .
-		KYCStepPassed           *KYCStep                    `json:"kycStepPassed,omitempty" example:"0" db:"kyc_step_passed"`
-		KYCStepBlocked          *KYCStep                    `json:"kycStepBlocked,omitempty" example:"0" db:"kyc_step_blocked"`
-		ClientData              *JSON                       `json:"clientData,omitempty" db:"client_data"`
-		RepeatableKYCSteps      *map[KYCStep]*time.Time     `json:"repeatableKYCSteps,omitempty" db:"-"` //nolint:tagliatelle // Nope.
+		CreatedAt                               *time.Time                  `json:"createdAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"created_at"`
+		UpdatedAt                               *time.Time                  `json:"updatedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"updated_at"`
+		LastMiningStartedAt                     *time.Time                  `json:"lastMiningStartedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_mining_started_at"`                                       //nolint:lll // .
+		LastMiningEndedAt                       *time.Time                  `json:"lastMiningEndedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_mining_ended_at"`                                           //nolint:lll // .
+		LastPingCooldownEndedAt                 *time.Time                  `json:"lastPingCooldownEndedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" swaggerignore:"true" db:"last_ping_cooldown_ended_at"`                              //nolint:lll // .
+		HiddenProfileElements                   *Enum[HiddenProfileElement] `json:"hiddenProfileElements,omitempty" swaggertype:"array,string" example:"level" enums:"globalRank,referralCount,level,role,badges" db:"hidden_profile_elements"` //nolint:lll // .
+		RandomReferredBy                        *bool                       `json:"randomReferredBy,omitempty" example:"true" swaggerignore:"true" db:"random_referred_by"`
+		Verified                                *bool                       `json:"verified,omitempty" example:"true" db:"-"`
+		QuizCompleted                           *bool                       `json:"-" db:"quiz_completed"`
+		KYCStepsLastUpdatedAt                   *[]*time.Time               `json:"kycStepsLastUpdatedAt,omitempty" swaggertype:"array,string" example:"2022-01-03T16:20:52.156534Z" db:"kyc_steps_last_updated_at"` //nolint:lll // .
+		KYCStepsCreatedAt                       *[]*time.Time               `json:"kycStepsCreatedAt,omitempty" swaggertype:"array,string" example:"2022-01-03T16:20:52.156534Z" db:"kyc_steps_created_at"`          //nolint:lll // .
+		PendingDeletionAt                       *time.Time                  `json:"pendingDeletionAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"pending_deletion_at"`
+		KYCStepPassed                           *KYCStep                    `json:"kycStepPassed,omitempty" example:"0" db:"kyc_step_passed"`
+		KYCStepBlocked                          *KYCStep                    `json:"kycStepBlocked,omitempty" example:"0" db:"kyc_step_blocked"`
+		MiningBlockchainAccountAddressChangedAt *time.Time                  `json:"-" db:"mining_blockchain_account_address_changed_at"`
+		// | It's set only if the user proved the ownership of the `blockchainAccountAddress`, by signing a challenge with the wallet.
+		BlockchainAccountAddressVerifiedAt *time.Time              `json:"blockchainAccountAddressVerifiedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"blockchain_account_address_verified_at"` //nolint:lll // .
+		ClientData                         *JSON                   `json:"clientData,omitempty" db:"client_data"`
+		RepeatableKYCSteps                 *map[KYCStep]*time.Time `json:"repeatableKYCSteps,omitempty" db:"-"` //nolint:tagliatelle // Nope.
 		PrivateUserInformation
 		PublicUserInformation
 		ReferredBy                     UserID   `json:"referredBy,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2" db:"referred_by"`
@@ -146,13 +250,76 @@ type (
 		T1ReferralCount *uint64 `json:"t1ReferralCount,omitempty" example:"100"`
 		T2ReferralCount *uint64 `json:"t2ReferralCount,omitempty" example:"100"`
 	}
+	MinimalUserProfiles struct {
+		Users      []*MinimalUserProfile `json:"users"`
+		NextCursor Cursor                `json:"nextCursor,omitempty" example:"eyJ1c2VybmFtZSI6Impkb2UifQ"`
+	}
 	Referrals struct {
-		Referrals []*MinimalUserProfile `json:"referrals"`
+		Referrals  []*MinimalUserProfile `json:"referrals"`
+		NextCursor Cursor                `json:"nextCursor,omitempty" example:"eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"`
+		UserCount
+	}
+	ReferralTreeLevel struct {
 		UserCount
+		Depth uint64 `json:"depth" example:"3"`
+	}
+	ReferralTreeNode struct {
+		Active *NotExpired `json:"active,omitempty" example:"true"`
+		PublicUserInformation
+		ReferredBy UserID `json:"referredBy,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Depth      uint64 `json:"depth" example:"3"`
+	}
+	ReferralTree struct {
+		Levels     []*ReferralTreeLevel `json:"levels"`
+		Nodes      []*ReferralTreeNode  `json:"nodes"`
+		NextCursor Cursor               `json:"nextCursor,omitempty" example:"eyJpZCI6ImRpZDpldGhyOjB4NEIifQ"`
 	}
 	UserSnapshot struct {
 		*User
 		Before *User `json:"before,omitempty"`
+		// It's set only when `referredBy` was changed because the previous referrer was deleted. One of `random`, `t0`, `houseAccount`, `unassigned`.
+		ReferredByReassignmentStrategy string `json:"referredByReassignmentStrategy,omitempty"`
+	}
+	// UserSearchFilter holds exact match filters. The ones provided are combined with AND; at least one is required.
+	UserSearchFilter struct {
+		Email                          string `json:"email,omitempty" example:"jdoe@gmail.com"`
+		PhoneNumber                    string `json:"phoneNumber,omitempty" example:"+12099216581"`
+		BlockchainAccountAddress       string `json:"blockchainAccountAddress,omitempty" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		MiningBlockchainAccountAddress string `json:"miningBlockchainAccountAddress,omitempty" example:"0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		DeviceUniqueID                 string `json:"deviceUniqueId,omitempty" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9"`
+		ReferredBy                     UserID `json:"referredBy,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+	}
+	// AuditActor is whoever triggered a change: an user, an admin, an API key or an internal consumer.
+	AuditActor struct {
+		Type AuditActorType `json:"type" example:"admin" enums:"user,admin,apiKey,internal"`
+		// The user id of the user/admin, if any.
+		ID UserID `json:"id,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// The module that triggered the change.
+		Source string `json:"source" example:"eskimo-hut"`
+	}
+	UserAuditLogEntry struct {
+		CreatedAt     *time.Time `json:"createdAt" example:"2022-01-03T16:20:52.156534Z"`
+		Before        *JSON      `json:"before,omitempty"`
+		After         *JSON      `json:"after,omitempty"`
+		Actor         AuditActor `json:"actor"`
+		ChangedFields []string   `json:"changedFields" example:"username,firstName"`
+		UserID        UserID     `json:"userId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		ID            int64      `json:"id" example:"11"`
+	}
+	UserAuditLog struct {
+		Entries    []*UserAuditLogEntry `json:"entries"`
+		NextCursor Cursor               `json:"nextCursor,omitempty" example:"eyJyYW5rIjoxMX0"`
+	}
+	ReferralLeaderboardEntry struct {
+		PublicUserInformation
+		// ISO 3166 country code.
+		Country       devicemetadata.Country `json:"country,omitempty" example:"US"`
+		ReferralCount uint64                 `json:"referralCount" example:"100"`
+		Rank          uint64                 `json:"rank" example:"1"`
+	}
+	ReferralLeaderboard struct {
+		Entries    []*ReferralLeaderboardEntry `json:"entries"`
+		NextCursor Cursor                      `json:"nextCursor,omitempty" example:"eyJyYW5rIjoxMX0"`
 	}
 	ReferralAcquisition struct {
 		Date *time.Time `json:"date" example:"2022-01-03"`
@@ -163,6 +330,14 @@ type (
 		// ISO 3166 country code.
 		Country   devicemetadata.Country `json:"country" example:"US"`
 		UserCount uint64                 `json:"userCount" example:"12121212"`
+		// The max number of users that were active in a single day, within the requested days.
+		ActiveUserCount uint64 `json:"activeUserCount" example:"1212"`
+	}
+	CityStatistics struct {
+		// ISO 3166 country code.
+		Country   devicemetadata.Country `json:"country" example:"US"`
+		City      devicemetadata.City    `json:"city" example:"New York"`
+		UserCount uint64                 `json:"userCount" example:"12121212"`
 	}
 	UserCount struct {
 		Active uint64 `json:"active" example:"11"`
@@ -183,27 +358,98 @@ type (
 	Contact struct {
 		UserID        UserID `json:"userId,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
 		ContactUserID UserID `json:"contactUserId,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		// Set if the contact was removed from the user's agenda.
+		Deleted bool `json:"deleted,omitempty" example:"false"`
+	}
+	ContactsSync struct {
+		Mode ContactsSyncMode `json:"mode" example:"delta" enums:"replace,delta"`
+		// Used only in `replace` mode: the hashes of all the phone numbers in the agenda.
+		PhoneNumberHashes []string `json:"phoneNumberHashes,omitempty" example:"Ef86A6021afCDe5673511376B2"`
+		// Used only in `delta` mode: the hashes of the phone numbers added to the agenda since the last sync.
+		AddedPhoneNumberHashes []string `json:"addedPhoneNumberHashes,omitempty" example:"Ef86A6021afCDe5673511376B2"`
+		// Used only in `delta` mode: the hashes of the phone numbers removed from the agenda since the last sync.
+		RemovedPhoneNumberHashes []string `json:"removedPhoneNumberHashes,omitempty" example:"Ef86A6021afCDe5673511376B2"`
+		// The version returned by the last sync. Used only in `delta` mode, which fails if it's not the current one.
+		Version uint64 `json:"version,omitempty" example:"3"`
+	}
+	ContactsSyncResult struct {
+		Version      uint64 `json:"version" example:"4"`
+		ContactCount uint64 `json:"contactCount" example:"12"`
+	}
+	// UserReport is an abuse report, sent to moderation through the message broker as well.
+	UserReport struct {
+		CreatedAt      *time.Time       `json:"createdAt" example:"2022-01-03T16:20:52.156534Z"`
+		ReporterUserID UserID           `json:"reporterUserId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		ReportedUserID UserID           `json:"reportedUserId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Reason         UserReportReason `json:"reason" example:"spam" enums:"spam,harassment,impersonation,inappropriateContent,other"`
+		Comment        string           `json:"comment,omitempty" example:"keeps sending me links"`
+		ID             int64            `json:"id" example:"1"`
+	}
+	UserReports struct {
+		Reports    []*UserReport `json:"reports"`
+		NextCursor Cursor        `json:"nextCursor,omitempty" example:"eyJyYW5rIjoxMX0"`
 	}
-	ReadRepository interface {
-		GetUsers(ctx context.Context, keyword string, limit, offset uint64) ([]*MinimalUserProfile, error)
-		GetUserByUsername(ctx context.Context, username string) (*UserProfile, error)
+	// WalletOwnershipChallenge is the message the user has to sign with the wallet of the address, before it expires.
+	WalletOwnershipChallenge struct {
+		ExpiresAt *time.Time `json:"expiresAt" example:"2022-01-03T16:20:52.156534Z"`
+		Address   string     `json:"address" example:"0x4b73c58370aefcef86a6021afcde5673511376b2"`
+		Message   string     `json:"message" example:"Sign this message to prove that you own the wallet 0x4b73c58370aefcef86a6021afcde5673511376b2..."`
+		Nonce     string     `json:"nonce" example:"c2b2b4d0c7a94e4e9a8f0c5f0f2f1e3d"`
+	}
+	// WalletOwnershipProof is the signed challenge.
+	// EVM signatures are EIP-191 `personal_sign` ones; TON/ION signatures are ed25519 ones and require the wallet's public key
+	// and its state init, which proves that the key is the one of the address.
+	WalletOwnershipProof struct {
+		Address   string `json:"address" example:"0x4b73c58370aefcef86a6021afcde5673511376b2"`
+		Signature string `json:"signature" example:"0x2c6401216c9031b9a6fb8cbfccab4fcec6c951cdf40e2320108d1856eb532250576865fbcd452bcdc4c57321b619ed7a9cfd38bd973c3e1e0243ac2777fe9d5b1b"` //nolint:lll // .
+		PublicKey string `json:"publicKey,omitempty" example:"7c6c9d3cbd5a2e7cc4ef1a9d7bdb0bbfd5f1f0a2b5f6a7e8e6e9d1c2b3a4f5e6"`
+		StateInit string `json:"stateInit,omitempty" example:"te6cckECBAEAAEcAAgE0AQMBFndhbGxldCBjb2RlAgAObGlicmFyeQBRAAAAAAAAAAB8bJ08vVoufMTvGp172wu/1fHworX2p+jm6dHCs6T15kDSoyyS"`
+	}
+	UserDataExportSection = dataexport.Section
+	UserDataExport        = dataexport.Export
+	UserDataExporter      = dataexport.Exporter
+	ReadRepository        interface {
+		UserDataExporter
+
+		GetUsers(ctx context.Context, keyword string, limit, offset uint64, cursor Cursor) (*MinimalUserProfiles, error)
+		GetUserByUsername(ctx context.Context, username string, resolvePrevious bool) (*UserProfile, error)
 		GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*User, error)
 		GetUserByID(ctx context.Context, userID string) (*UserProfile, error)
+		SearchUsers(ctx context.Context, filter *UserSearchFilter, limit, offset uint64) ([]*User, error)
 
-		GetTopCountries(ctx context.Context, keyword string, limit, offset uint64) ([]*CountryStatistics, error)
-		GetUserGrowth(ctx context.Context, days uint64, tz *stdlibtime.Location) (*UserGrowthStatistics, error)
+		GetTopCountries(ctx context.Context, keyword string, sortBy CountryStatisticsSortBy, days, limit, offset uint64) ([]*CountryStatistics, error)
+		GetTopCities(ctx context.Context, country devicemetadata.Country, keyword string, limit, offset uint64) ([]*CityStatistics, error)
+		GetUserGrowth(ctx context.Context, days uint64, granularity UserGrowthGranularity, tz *stdlibtime.Location) (*UserGrowthStatistics, error)
 
-		GetReferrals(ctx context.Context, userID string, referralType ReferralType, limit, offset uint64) (*Referrals, error)
-		GetReferralAcquisitionHistory(ctx context.Context, userID string) ([]*ReferralAcquisition, error)
+		GetReferrals(ctx context.Context, userID string, referralType ReferralType, limit, offset uint64, cursor Cursor) (*Referrals, error)
+		GetReferralAcquisitionHistory(ctx context.Context, userID string, days uint64) ([]*ReferralAcquisition, error)
+		GetReferralTree(ctx context.Context, userID string, maxDepth, limit uint64, cursor Cursor) (*ReferralTree, error)
+		GetReferralLeaderboard(
+			ctx context.Context, tier ReferralType, period ReferralLeaderboardPeriod, country devicemetadata.Country, limit uint64, cursor Cursor,
+		) (*ReferralLeaderboard, error)
+
+		GetUserAuditLog(ctx context.Context, userID string, limit uint64, cursor Cursor) (*UserAuditLog, error)
+		GetUserReports(ctx context.Context, reportedUserID UserID, limit uint64, cursor Cursor) (*UserReports, error)
 
 		IsEmailUsedBySomebodyElse(ctx context.Context, userID, email string) (bool, error)
+		IsMiningBlockchainAccountAddressChangeConfirmationRequired(ctx context.Context, userID UserID, address string) (bool, error)
 	}
 	WriteRepository interface {
 		CreateUser(ctx context.Context, usr *User, clientIP net.IP) error
 		DeleteUser(ctx context.Context, userID UserID) error
+		RestoreUser(ctx context.Context, userID UserID) error
 		ModifyUser(ctx context.Context, usr *User, profilePicture *multipart.FileHeader) error
 
 		TryResetKYCSteps(ctx context.Context, userID string) (*User, error)
+
+		SyncContacts(ctx context.Context, userID UserID, sync *ContactsSync) (*ContactsSyncResult, error)
+
+		CreateWalletOwnershipChallenge(ctx context.Context, userID UserID, address string) (*WalletOwnershipChallenge, error)
+		VerifyWalletOwnership(ctx context.Context, userID UserID, proof *WalletOwnershipProof) (*User, error)
+
+		BlockUser(ctx context.Context, userID, blockedUserID UserID) error
+		UnblockUser(ctx context.Context, userID, blockedUserID UserID) error
+		ReportUser(ctx context.Context, report *UserReport) error
 	}
 	// Repository main API exposed that handles all the features of this package.
 	Repository interface {
@@ -225,33 +471,61 @@ type (
 	DeviceMetadataSnapshot = devicemetadata.DeviceMetadataSnapshot
 	DeviceMetadata         = devicemetadata.DeviceMetadata
 	DeviceLocation         = devicemetadata.DeviceLocation
+	DeviceRisk             = devicemetadata.DeviceRisk
+	AppVersionStatus       = devicemetadata.AppVersionStatus
+	IPRiskAssessment       = devicemetadata.IPRiskAssessment
+	IPRiskRoute            = devicemetadata.IPRiskRoute
+)
+
+const (
+	SignUpIPRiskRoute         = devicemetadata.SignUpIPRiskRoute
+	SignInIPRiskRoute         = devicemetadata.SignInIPRiskRoute
+	DeviceMetadataIPRiskRoute = devicemetadata.DeviceMetadataIPRiskRoute
+	AllowIPRiskAction         = devicemetadata.AllowIPRiskAction
 )
 
 // Private API.
 
 const (
 	hoursInOneDay                       = 24
+	minTrigramKeywordLength             = 3
 	applicationYamlKey                  = "users"
 	dayFormat, hourFormat, minuteFormat = "2006-01-02", "2006-01-02T15", "2006-01-02T15:04"
 	totalUsersGlobalKey                 = "TOTAL_USERS"
 	totalActiveUsersGlobalKey           = "TOTAL_ACTIVE_USERS"
 	checksumCtxValueKey                 = "versioningChecksumCtxValueKey"
 	confirmedEmailCtxValueKey           = "confirmedEmailCtxValueKey"
+	confirmedMiningAddressCtxValueKey   = "confirmedMiningAddressCtxValueKey"
 	authorizationCtxValueKey            = "authorizationCtxValueKey"
 	xAccountMetadataCtxValueKey         = "xAccountMetadataCtxValueKey"
+	auditActorCtxValueKey               = "auditActorCtxValueKey"
 	totalNoOfDefaultProfilePictures     = 20
 	defaultProfilePictureName           = "default-profile-picture-%v.png"
 	defaultProfilePictureNameRegex      = "default-profile-picture-\\d+[.]png"
 	usernameDBColumnName                = "username"
 	requestDeadline                     = 25 * stdlibtime.Second
 
-	maxDaysReferralsHistory = 5
+	defaultMaxDaysReferralsHistory = 5
+
+	defaultReferralLeaderboardRefreshInterval = 10 * stdlibtime.Minute
+	defaultMaxContactsPerUser                 = 5000
+	defaultWalletOwnershipChallengeTTL        = 10 * stdlibtime.Minute
+	defaultUserAuditLogRetention              = 365 * 24 * stdlibtime.Hour
+	userAuditLogCleanupBatchSize              = 10_000
+	walletOwnershipChallengeNonceLength       = 16
+	walletOwnershipChallengeMessageFormat     = "Sign this message to prove that you own the wallet %v.\n\nUser: %v\nNonce: %v\nExpires at: %v"
+	referralLeaderboardMaxSize                = 10_000
+
+	outboxRelayInterval  = 1 * stdlibtime.Second
+	outboxRelayBatchSize = 10
 )
 
 var (
 	//go:embed DDL.sql
 	ddl string
 
+	errUserRestored = errors.New("user was restored")
+
 	_ sql.Scanner        = (*JSON)(nil)
 	_ sql.Scanner        = (*NotExpired)(nil)
 	_ pgtype.ArraySetter = (*Enum[HiddenProfileElement])(nil)
@@ -277,15 +551,37 @@ type (
 		*processor
 	}
 
+	cursoredMinimalUserProfile struct {
+		*MinimalUserProfile
+		CreatedAt   *time.Time
+		ContactRank int64
+	}
+	contactsSyncState struct {
+		AgendaContactUserIDs []UserID `db:"agenda_contact_user_ids"`
+		Version              uint64   `db:"version"`
+	}
+	referralTreeRow struct {
+		*ReferralTreeNode
+		CreatedAt   *time.Time
+		IDX         uint64
+		Total       uint64
+		ActiveCount uint64
+	}
+	// | pageCursor is the decoded form of the opaque Cursor used for keyset pagination.
+	pageCursor = pagination.Cursor
+
 	// | repository implements the public API that this package exposes.
 	repository struct {
 		cfg *config
 		db  *storage.DB
 		mb  messagebroker.Client
 		devicemetadata.DeviceMetadataRepository
-		pictureClient  picture.Client
-		trackingClient tracking.Client
-		shutdown       func() error
+		pictureClient        picture.Client
+		trackingClient       tracking.Client
+		authClient           auth.Client
+		referralReassignment reassignment.Strategy
+		blockchainAddresses  *address.Registry
+		shutdown             func() error
 	}
 
 	processor struct {
@@ -303,7 +599,21 @@ type (
 			Child                    stdlibtime.Duration `yaml:"child"`
 		} `yaml:"globalAggregationInterval"`
 		//nolint:tagliatelle // .
-		IntervalBetweenRepeatableKYCSteps stdlibtime.Duration `yaml:"intervalBetweenRepeatableKYCSteps" mapstructure:"intervalBetweenRepeatableKYCSteps"`
-		DisableConsumer                   bool                `yaml:"disableConsumer"`
+		IntervalBetweenRepeatableKYCSteps  stdlibtime.Duration `yaml:"intervalBetweenRepeatableKYCSteps" mapstructure:"intervalBetweenRepeatableKYCSteps"`
+		DeletionGracePeriod                stdlibtime.Duration `yaml:"deletionGracePeriod" mapstructure:"deletionGracePeriod"`
+		UserAuditLogRetention              stdlibtime.Duration `yaml:"userAuditLogRetention" mapstructure:"userAuditLogRetention"`
+		ReferralLeaderboardRefreshInterval stdlibtime.Duration `yaml:"referralLeaderboardRefreshInterval" mapstructure:"referralLeaderboardRefreshInterval"`
+		MaxDaysReferralsHistory            uint64              `yaml:"maxDaysReferralsHistory" mapstructure:"maxDaysReferralsHistory"`
+		WalletOwnershipChallengeTTL        stdlibtime.Duration `yaml:"walletOwnershipChallengeTTL" mapstructure:"walletOwnershipChallengeTTL"`
+		MaxContactsPerUser                 uint64              `yaml:"maxContactsPerUser" mapstructure:"maxContactsPerUser"`
+		ReferralReassignment               reassignment.Config `yaml:"referralReassignment" mapstructure:"referralReassignment"`
+		// | MiningBlockchainAccountAddressChange controls how often an already set mining blockchain account address can be changed
+		// and if the change has to be confirmed via a link sent to the email of the user.
+		MiningBlockchainAccountAddressChange struct {
+			Cooldown              stdlibtime.Duration `yaml:"cooldown"`
+			RequireConfirmedEmail bool                `yaml:"requireConfirmedEmail" mapstructure:"requireConfirmedEmail"`
+		} `yaml:"miningBlockchainAccountAddressChange" mapstructure:"miningBlockchainAccountAddressChange"`
+		UsernameChange  usernamepolicy.Config `yaml:"usernameChange" mapstructure:"usernameChange"`
+		DisableConsumer bool                  `yaml:"disableConsumer"`
 	}
 )
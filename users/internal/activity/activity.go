@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package activity
+
+import (
+	stdlibtime "time"
+)
+
+// IncrActiveIntervals returns the beginning of every interval the mining session made the user active in,
+// that wasn't already counted by the previous one.
+func (ms *MiningSession) IncrActiveIntervals(interval stdlibtime.Duration) []stdlibtime.Time {
+	intervals := make([]stdlibtime.Time, 0)
+	start, end := ms.EndedAt.Add(-ms.Extension), *ms.EndedAt.Time
+	if !ms.LastNaturalMiningStartedAt.Equal(*ms.StartedAt.Time) ||
+		(!ms.PreviouslyEndedAt.IsNil() && ms.StartedAt.Truncate(interval).Equal(ms.PreviouslyEndedAt.Truncate(interval))) {
+		start = start.Add(interval)
+	}
+	start = start.Truncate(interval)
+	end = end.Truncate(interval)
+	for start.Before(end) {
+		intervals = append(intervals, start)
+		start = start.Add(interval)
+	}
+	if ms.PreviouslyEndedAt.IsNil() || !end.Equal(ms.PreviouslyEndedAt.Truncate(interval)) {
+		intervals = append(intervals, end)
+	}
+
+	return intervals
+}
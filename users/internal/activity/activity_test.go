@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package activity
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+//nolint:funlen // It's a table.
+func TestIncrActiveIntervals(t *testing.T) {
+	t.Parallel()
+	day := 24 * stdlibtime.Hour
+	at := func(date string) *time.Time {
+		parsed, err := stdlibtime.Parse(stdlibtime.RFC3339, date)
+		if err != nil {
+			panic(err)
+		}
+
+		return time.New(parsed)
+	}
+	days := func(dates ...string) []stdlibtime.Time {
+		res := make([]stdlibtime.Time, 0, len(dates))
+		for _, date := range dates {
+			res = append(res, *at(date + "T00:00:00Z").Time)
+		}
+
+		return res
+	}
+	for _, tc := range []struct {
+		name     string
+		session  *MiningSession
+		expected []stdlibtime.Time
+		interval stdlibtime.Duration
+	}{
+		{
+			name: "first session",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T10:00:00Z"),
+				StartedAt:                  at("2024-01-01T10:00:00Z"),
+				EndedAt:                    at("2024-01-02T10:00:00Z"),
+				Extension:                  day,
+			},
+			expected: days("2024-01-01", "2024-01-02"),
+		},
+		{
+			name: "first session spanning several days",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T10:00:00Z"),
+				StartedAt:                  at("2024-01-01T10:00:00Z"),
+				EndedAt:                    at("2024-01-04T10:00:00Z"),
+				Extension:                  3 * day,
+			},
+			expected: days("2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"),
+		},
+		{
+			name: "started on the day the previous one ended",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T10:00:00Z"),
+				StartedAt:                  at("2024-01-01T10:00:00Z"),
+				EndedAt:                    at("2024-01-02T10:00:00Z"),
+				PreviouslyEndedAt:          at("2024-01-01T08:00:00Z"),
+				Extension:                  day,
+			},
+			expected: days("2024-01-02"),
+		},
+		{
+			name: "started on a later day than the previous one ended",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-03T10:00:00Z"),
+				StartedAt:                  at("2024-01-03T10:00:00Z"),
+				EndedAt:                    at("2024-01-04T10:00:00Z"),
+				PreviouslyEndedAt:          at("2024-01-01T08:00:00Z"),
+				Extension:                  day,
+			},
+			expected: days("2024-01-03", "2024-01-04"),
+		},
+		{
+			name: "extension of the previous session",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T10:00:00Z"),
+				StartedAt:                  at("2024-01-02T10:00:00Z"),
+				EndedAt:                    at("2024-01-03T10:00:00Z"),
+				PreviouslyEndedAt:          at("2024-01-02T10:00:00Z"),
+				Extension:                  day,
+			},
+			expected: days("2024-01-03"),
+		},
+		{
+			name: "ended on the day the previous one ended",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T01:00:00Z"),
+				StartedAt:                  at("2024-01-01T01:00:00Z"),
+				EndedAt:                    at("2024-01-01T23:00:00Z"),
+				PreviouslyEndedAt:          at("2024-01-01T00:30:00Z"),
+				Extension:                  22 * stdlibtime.Hour,
+			},
+			expected: days(),
+		},
+		{
+			name: "hourly intervals",
+			session: &MiningSession{
+				LastNaturalMiningStartedAt: at("2024-01-01T10:30:00Z"),
+				StartedAt:                  at("2024-01-01T10:30:00Z"),
+				EndedAt:                    at("2024-01-01T12:30:00Z"),
+				Extension:                  2 * stdlibtime.Hour,
+			},
+			expected: []stdlibtime.Time{
+				*at("2024-01-01T10:00:00Z").Time,
+				*at("2024-01-01T11:00:00Z").Time,
+				*at("2024-01-01T12:00:00Z").Time,
+			},
+			interval: stdlibtime.Hour,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			interval := tc.interval
+			if interval == 0 {
+				interval = day
+			}
+			assert.Equal(t, tc.expected, tc.session.IncrActiveIntervals(interval))
+		})
+	}
+}
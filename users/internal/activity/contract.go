@@ -0,0 +1,22 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package activity
+
+import (
+	stdlibtime "time"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Public API.
+
+type (
+	// MiningSession is the part of a mining session that tells for how long the user was active.
+	MiningSession struct {
+		LastNaturalMiningStartedAt *time.Time
+		StartedAt                  *time.Time
+		EndedAt                    *time.Time
+		PreviouslyEndedAt          *time.Time
+		Extension                  stdlibtime.Duration
+	}
+)
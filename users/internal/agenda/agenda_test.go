@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package agenda
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyDelta(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name                    string
+		current, removed, added []UserID
+		expected                []UserID
+	}{
+		{name: "nothing changes", current: []UserID{"a", "b"}, expected: []UserID{"a", "b"}},
+		{name: "first contacts", added: []UserID{"a", "b"}, expected: []UserID{"a", "b"}},
+		{name: "added", current: []UserID{"a"}, added: []UserID{"b", "c"}, expected: []UserID{"a", "b", "c"}},
+		{name: "already added", current: []UserID{"a", "b"}, added: []UserID{"b", "c", "c"}, expected: []UserID{"a", "b", "c"}},
+		{name: "removed", current: []UserID{"a", "b", "c"}, removed: []UserID{"b"}, expected: []UserID{"a", "c"}},
+		{name: "unknown removed", current: []UserID{"a"}, removed: []UserID{"b"}, expected: []UserID{"a"}},
+		{name: "removed and added back", current: []UserID{"a", "b"}, removed: []UserID{"a"}, added: []UserID{"a"}, expected: []UserID{"b", "a"}},
+		{name: "everything removed", current: []UserID{"a", "b"}, removed: []UserID{"b", "a"}, expected: []UserID{}},
+	} {
+		assert.Equal(t, tc.expected, ApplyDelta(tc.current, tc.removed, tc.added), tc.name)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name                           string
+		before, after                  []UserID
+		expectedAdded, expectedRemoved []UserID
+	}{
+		{name: "no contacts"},
+		{name: "same contacts", before: []UserID{"a", "b"}, after: []UserID{"b", "a"}},
+		{name: "first contacts", after: []UserID{"a", "b"}, expectedAdded: []UserID{"a", "b"}},
+		{name: "every contact removed", before: []UserID{"a", "b"}, expectedRemoved: []UserID{"a", "b"}},
+		{name: "replaced", before: []UserID{"a", "b"}, after: []UserID{"b", "c"}, expectedAdded: []UserID{"c"}, expectedRemoved: []UserID{"a"}},
+	} {
+		added, removed := Diff(tc.before, tc.after)
+		assert.Equal(t, tc.expectedAdded, added, tc.name)
+		assert.Equal(t, tc.expectedRemoved, removed, tc.name)
+	}
+}
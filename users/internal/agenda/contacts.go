@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package agenda
+
+import (
+	"slices"
+)
+
+// ApplyDelta removes the `removed` contacts from the current ones, then appends the `added` ones that are not there yet.
+// The order of the current contacts is kept, so a contact that is both removed and added ends up last.
+func ApplyDelta(current, removed, added []UserID) []UserID {
+	next := make([]UserID, 0, len(current)+len(added))
+	for _, contactID := range current {
+		if !slices.Contains(removed, contactID) {
+			next = append(next, contactID)
+		}
+	}
+	for _, contactID := range added {
+		if !slices.Contains(next, contactID) {
+			next = append(next, contactID)
+		}
+	}
+
+	return next
+}
+
+// Diff returns the contacts that are in `after` but not in `before` and the ones that are in `before` but not in `after`.
+func Diff(before, after []UserID) (added, removed []UserID) {
+	for _, contactID := range after {
+		if !slices.Contains(before, contactID) {
+			added = append(added, contactID)
+		}
+	}
+	for _, contactID := range before {
+		if !slices.Contains(after, contactID) {
+			removed = append(removed, contactID)
+		}
+	}
+
+	return added, removed
+}
@@ -0,0 +1,9 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package agenda
+
+// Public API.
+
+type (
+	UserID = string
+)
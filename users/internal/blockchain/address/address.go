@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// New creates a registry with the provided validators or, if none, with the ones of all the chains we support.
+func New(validators ...Validator) *Registry {
+	if len(validators) == 0 {
+		validators = []Validator{&evmValidator{chain: EVMChain}, &tonValidator{chain: TONChain}}
+	}
+
+	return &Registry{validators: validators}
+}
+
+// Normalize returns the normalized address and the first chain that accepts it.
+func (r *Registry) Normalize(address string) (normalized string, chain Chain, err error) {
+	address = strings.TrimSpace(address)
+	for _, validator := range r.validators {
+		if normalized, err = validator.Normalize(address); err == nil {
+			return normalized, validator.Chain(), nil
+		}
+	}
+
+	return "", "", errors.Wrapf(ErrInvalid, "`%v` is not a valid address for any of the supported chains", address)
+}
+
+func (v *evmValidator) Chain() Chain {
+	return v.chain
+}
+
+// Normalize lowercases the address, after verifying its EIP-55 checksum, if it has one.
+func (v *evmValidator) Normalize(address string) (string, error) {
+	if !evmAddressRegex.MatchString(address) {
+		return "", errors.Wrapf(ErrInvalid, "`%v` is not a valid %v address", address, v.chain)
+	}
+	hexPart := address[2:]
+	lower := strings.ToLower(hexPart)
+	if hexPart != lower && hexPart != strings.ToUpper(hexPart) && hexPart != eip55Checksum(lower) {
+		return "", errors.Wrapf(ErrInvalid, "`%v` has an invalid EIP-55 checksum", address)
+	}
+
+	return "0x" + lower, nil
+}
+
+func eip55Checksum(lowerHex string) string {
+	digest := hex.EncodeToString(keccak256([]byte(lowerHex)))
+	checksummed := []byte(lowerHex)
+	for i, char := range checksummed {
+		if char >= 'a' && digest[i] >= '8' {
+			checksummed[i] = char - 'a' + 'A'
+		}
+	}
+
+	return string(checksummed)
+}
+
+func (v *tonValidator) Chain() Chain {
+	return v.chain
+}
+
+// Normalize lowercases raw addresses and converts user-friendly ones to the raw form.
+// The flags of the user-friendly ones (bounceable, testnet) aren't part of the account, so they're dropped.
+func (v *tonValidator) Normalize(address string) (string, error) {
+	if tonRawAddressRegex.MatchString(address) {
+		return strings.ToLower(address), nil
+	}
+	if !tonUserFriendlyAddressRegex.MatchString(address) {
+		return "", errors.Wrapf(ErrInvalid, "`%v` is not a valid %v address", address, v.chain)
+	}
+	decoded, err := base64.URLEncoding.DecodeString(strings.NewReplacer("+", "-", "/", "_").Replace(address))
+	if err != nil || len(decoded) != tonUserFriendlyAddressLength {
+		return "", errors.Wrapf(ErrInvalid, "`%v` is not a valid base64 %v address", address, v.chain)
+	}
+	if tag := decoded[0] &^ tonTestnetFlag; tag != tonBounceableTag && tag != tonNonBounceableTag {
+		return "", errors.Wrapf(ErrInvalid, "`%v` has an invalid %v address tag", address, v.chain)
+	}
+	if decoded[1] != 0 && decoded[1] != tonMasterchainID {
+		return "", errors.Wrapf(ErrInvalid, "`%v` has an invalid %v workchain", address, v.chain)
+	}
+	if crc16(decoded[:34]) != binary.BigEndian.Uint16(decoded[34:]) {
+		return "", errors.Wrapf(ErrInvalid, "`%v` has an invalid %v address checksum", address, v.chain)
+	}
+
+	return fmt.Sprintf("%v:%v", int8(decoded[1]), hex.EncodeToString(decoded[2:34])), nil
+}
+
+// CRC-16/XMODEM, as used by the TON user-friendly addresses.
+func crc16(data []byte) uint16 {
+	const poly = 0x1021
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8 //nolint:gomnd // Not a magic number.
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ poly
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEVMValidatorNormalize(t *testing.T) {
+	t.Parallel()
+	validator := &evmValidator{chain: EVMChain}
+	for _, valid := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+	} {
+		normalized, err := validator.Normalize(valid)
+		require.NoError(t, err, valid)
+		assert.Equal(t, strings.ToLower(valid), normalized)
+	}
+	for _, invalid := range []string{
+		"",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAez",
+		"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2",
+	} {
+		_, err := validator.Normalize(invalid)
+		require.ErrorIs(t, err, ErrInvalid, invalid)
+	}
+}
+
+func TestTONValidatorNormalize(t *testing.T) {
+	t.Parallel()
+	validator := &tonValidator{chain: TONChain}
+	for valid, expected := range map[string]string{
+		"EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N":                    "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
+		"UQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqEBI":                    "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
+		"kQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqKYH":                    "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
+		"Ef8zMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzM0vF":                    "-1:3333333333333333333333333333333333333333333333333333333333333333",
+		"0:83DFD552E63729B472FCBCC8C45EBCC6691702558B68EC7527E1BA403A0F31A8":  "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
+		"-1:3333333333333333333333333333333333333333333333333333333333333333": "-1:3333333333333333333333333333333333333333333333333333333333333333",
+	} {
+		normalized, err := validator.Normalize(valid)
+		require.NoError(t, err, valid)
+		assert.Equal(t, expected, normalized)
+	}
+	for _, invalid := range []string{
+		"",
+		"EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2M",
+		"eqcd39vs5jcpthl8vmjexrzgarccvyto7hun4bpaog8xqb2n",
+		"EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2",
+		"1:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	} {
+		_, err := validator.Normalize(invalid)
+		require.ErrorIs(t, err, ErrInvalid, invalid)
+	}
+}
+
+func TestRegistryNormalize(t *testing.T) {
+	t.Parallel()
+	registry := New()
+	normalized, chain, err := registry.Normalize(" 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed ")
+	require.NoError(t, err)
+	assert.Equal(t, "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", normalized)
+	assert.Equal(t, EVMChain, chain)
+
+	normalized, chain, err = registry.Normalize("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	require.NoError(t, err)
+	assert.Equal(t, "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8", normalized)
+	assert.Equal(t, TONChain, chain)
+
+	_, _, err = registry.Normalize("bogus")
+	require.ErrorIs(t, err, ErrInvalid)
+}
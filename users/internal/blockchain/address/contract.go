@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Public API.
+
+// The chains are told apart only by the format of their addresses, so every format has a single chain:
+// the BSC (and the other EVM compatible chains) addresses are EVM ones and the ION addresses are TON ones.
+const (
+	EVMChain Chain = "evm"
+	TONChain Chain = "ton"
+)
+
+var (
+	ErrInvalid          = errors.New("invalid blockchain account address")
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+type (
+	Chain = string
+	// Validator validates the addresses of a specific chain and returns their normalized form, that's the one to be stored.
+	// It also verifies that a message was signed by the owner of an address, using the signing scheme of the chain.
+	Validator interface {
+		Chain() Chain
+		Normalize(address string) (string, error)
+		VerifySignature(normalizedAddress string, signed *SignedMessage) error
+	}
+	// SignedMessage is a message signed by a wallet. The signature, the public key and the state init are either hex or base64 encoded.
+	// The public key and the state init (the serialized bag of cells the address is derived from) are required only by TON.
+	SignedMessage struct {
+		Message   string
+		Signature string
+		PublicKey string
+		StateInit string
+	}
+	// Registry holds the validators of all the chains we support. The first one that accepts an address wins.
+	Registry struct {
+		validators []Validator
+	}
+)
+
+// Private API.
+
+const (
+	tonUserFriendlyAddressLength = 36
+	tonBounceableTag             = 0x11
+	tonNonBounceableTag          = 0x51
+	tonTestnetFlag               = 0x80
+	tonMasterchainID             = 0xff
+	tonBagOfCellsMagic           = 0xb5ee9c72
+	tonMaxCellRefs               = 4
+	tonPublicKeyBits             = 256
+
+	eip191MessagePrefix       = "\x19Ethereum Signed Message:\n%v"
+	eip191SignatureLength     = 65
+	eip191RecoveryIDOffset    = 27
+	secp256k1CompactSigPrefix = 27
+)
+
+var (
+	//nolint:gochecknoglobals // It's stateless.
+	evmAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	//nolint:gochecknoglobals // It's stateless.
+	tonRawAddressRegex = regexp.MustCompile(`^(0|-1):[0-9a-fA-F]{64}$`)
+	//nolint:gochecknoglobals // It's stateless.
+	tonUserFriendlyAddressRegex = regexp.MustCompile(`^[A-Za-z0-9+/_-]{48}$`)
+	// The public key is stored right after the seqno (v1, v2), after the seqno and the wallet id (v3, v4)
+	// or after the signature flag, the seqno and the wallet id (v5) in the data of the wallets.
+	//nolint:gochecknoglobals // It's stateless.
+	tonWalletPublicKeyOffsets = []int{32, 64, 65}
+)
+
+type (
+	// | evmValidator validates EVM compatible addresses, enforcing the EIP-55 checksum if the address is mixed case.
+	// Signatures are EIP-191 `personal_sign` ones, from which the signer's address is recovered.
+	evmValidator struct {
+		chain Chain
+	}
+	// | tonValidator validates both the raw (`workchain:hex`) and the user-friendly (base64, with CRC16 checksum) TON addresses
+	// and normalizes them to the raw form, so the same account is always stored the same way.
+	// Signatures are ed25519 ones, verified with the public key of the wallet, which has to be the one in the state init of the address.
+	tonValidator struct {
+		chain Chain
+	}
+	// | tonCell is an ordinary cell of a bag of cells, with its representation hash.
+	tonCell struct {
+		data    []byte
+		refs    []*tonCell
+		hash    []byte
+		bitsLen int
+		depth   uint16
+	}
+)
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// VerifySignature verifies the signature using the signing scheme of the first chain that accepts the address.
+func (r *Registry) VerifySignature(address string, signed *SignedMessage) error {
+	address = strings.TrimSpace(address)
+	for _, validator := range r.validators {
+		if normalized, err := validator.Normalize(address); err == nil {
+			return errors.Wrapf(validator.VerifySignature(normalized, signed), "invalid %v signature", validator.Chain())
+		}
+	}
+
+	return errors.Wrapf(ErrInvalid, "`%v` is not a valid address for any of the supported chains", address)
+}
+
+// VerifySignature recovers the signer of the EIP-191 `personal_sign` message and checks that it's the expected address.
+func (v *evmValidator) VerifySignature(normalizedAddress string, signed *SignedMessage) error {
+	sig, err := decodeBytes(signed.Signature)
+	if err != nil || len(sig) != eip191SignatureLength {
+		return errors.Wrapf(ErrInvalidSignature, "signature must be %v bytes", eip191SignatureLength)
+	}
+	recoveryID := sig[eip191SignatureLength-1]
+	if recoveryID >= eip191RecoveryIDOffset {
+		recoveryID -= eip191RecoveryIDOffset
+	}
+	if recoveryID > 1 {
+		return errors.Wrapf(ErrInvalidSignature, "invalid recovery id %v", sig[eip191SignatureLength-1])
+	}
+	compact := make([]byte, 0, eip191SignatureLength)
+	compact = append(append(compact, secp256k1CompactSigPrefix+recoveryID), sig[:eip191SignatureLength-1]...)
+	publicKey, _, err := ecdsa.RecoverCompact(compact, keccak256([]byte(fmt.Sprintf(eip191MessagePrefix, len(signed.Message))+signed.Message)))
+	if err != nil {
+		return errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+	if signer := "0x" + hex.EncodeToString(keccak256(publicKey.SerializeUncompressed()[1:])[12:]); signer != normalizedAddress {
+		return errors.Wrapf(ErrInvalidSignature, "message was signed by %v", signer)
+	}
+
+	return nil
+}
+
+// VerifySignature checks the ed25519 signature with the provided public key of the wallet.
+// TON addresses are derived from the whole state init of the wallet contract, not just from its key,
+// so the state init is required too: it must hash to the address and hold the public key in its data.
+func (*tonValidator) VerifySignature(normalizedAddress string, signed *SignedMessage) error {
+	key, err := decodeBytes(signed.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.Wrapf(ErrInvalidSignature, "public key must be %v bytes", ed25519.PublicKeySize)
+	}
+	sig, err := decodeBytes(signed.Signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.Wrapf(ErrInvalidSignature, "signature must be %v bytes", ed25519.SignatureSize)
+	}
+	stateInit, err := decodeBytes(signed.StateInit)
+	if err != nil || len(stateInit) == 0 {
+		return errors.Wrap(ErrInvalidSignature, "state init is required")
+	}
+	if err = verifyTONWalletPublicKey(normalizedAddress, stateInit, key); err != nil {
+		return errors.Wrap(ErrInvalidSignature, err.Error())
+	}
+	if !ed25519.Verify(key, []byte(signed.Message), sig) {
+		return errors.Wrap(ErrInvalidSignature, "signature doesn't match the public key")
+	}
+
+	return nil
+}
+
+// Wallets return either hex (optionally `0x` prefixed) or base64 encoded signatures and keys.
+func decodeBytes(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if decoded, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x")); err == nil {
+		return decoded, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.NewReplacer("-", "+", "_", "/").Replace(encoded))
+
+	return decoded, errors.Wrap(err, "neither hex nor base64")
+}
+
+func keccak256(data []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(data) //nolint:errcheck,revive // It never fails.
+
+	return hash.Sum(nil)
+}
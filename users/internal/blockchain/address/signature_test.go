@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEVMValidatorVerifySignature(t *testing.T) {
+	t.Parallel()
+	privateKey := secp256k1.PrivKeyFromBytes(keccak256([]byte("some seed")))
+	address := "0x" + hex.EncodeToString(keccak256(privateKey.PubKey().SerializeUncompressed()[1:])[12:])
+	message := "some nonce to sign"
+	signature := signEIP191(privateKey, message)
+	validator := &evmValidator{chain: EVMChain}
+
+	require.NoError(t, validator.VerifySignature(address, &SignedMessage{Message: message, Signature: signature}))
+	require.NoError(t, validator.VerifySignature(address, &SignedMessage{Message: message, Signature: "0x" + signature}))
+	require.ErrorIs(t, validator.VerifySignature(address, &SignedMessage{Message: "another message", Signature: signature}), ErrInvalidSignature)
+	require.ErrorIs(t, validator.VerifySignature("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", &SignedMessage{Message: message, Signature: signature}), ErrInvalidSignature) //nolint:lll // .
+	require.ErrorIs(t, validator.VerifySignature(address, &SignedMessage{Message: message, Signature: signature[:10]}), ErrInvalidSignature)
+	require.ErrorIs(t, validator.VerifySignature(address, &SignedMessage{Message: message, Signature: signature[:128] + "1f"}), ErrInvalidSignature)
+}
+
+func TestTONValidatorVerifySignature(t *testing.T) {
+	t.Parallel()
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherPublicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	message := "some nonce to sign"
+	signature := ed25519.Sign(privateKey, []byte(message))
+	validator := &tonValidator{chain: TONChain}
+	for _, dataPrefix := range []string{bitsOf(make([]byte, 4)), bitsOf(make([]byte, 8)), "1" + bitsOf(make([]byte, 8))} {
+		stateInit := newTestStateInit(dataPrefix, publicKey)
+		address := "0:" + hex.EncodeToString(stateInit.hash)
+		boc := serializeBagOfCells(stateInit)
+		signed := &SignedMessage{
+			Message:   message,
+			Signature: hex.EncodeToString(signature),
+			PublicKey: hex.EncodeToString(publicKey),
+			StateInit: base64.StdEncoding.EncodeToString(boc),
+		}
+		require.NoError(t, validator.VerifySignature(address, signed))
+		require.NoError(t, validator.VerifySignature(address, &SignedMessage{
+			Message:   message,
+			Signature: base64.StdEncoding.EncodeToString(signature),
+			PublicKey: base64.URLEncoding.EncodeToString(publicKey),
+			StateInit: hex.EncodeToString(boc),
+		}))
+		for _, invalid := range []*SignedMessage{
+			{Message: "another message", Signature: signed.Signature, PublicKey: signed.PublicKey, StateInit: signed.StateInit},
+			{Message: message, Signature: signed.Signature, PublicKey: hex.EncodeToString(otherPublicKey), StateInit: signed.StateInit},
+			{Message: message, Signature: signed.Signature, PublicKey: signed.PublicKey},
+			{Message: message, Signature: signed.Signature, PublicKey: signed.PublicKey, StateInit: base64.StdEncoding.EncodeToString(boc[:len(boc)-1])},
+			{Message: message, Signature: signed.Signature, PublicKey: "", StateInit: signed.StateInit},
+		} {
+			require.ErrorIs(t, validator.VerifySignature(address, invalid), ErrInvalidSignature)
+		}
+		require.ErrorIs(t, validator.VerifySignature("0:"+strings.Repeat("3", 64), signed), ErrInvalidSignature)
+		// The key has to be the one of the wallet, even if the signature is valid for it.
+		otherStateInit := newTestStateInit(dataPrefix, otherPublicKey)
+		require.ErrorIs(t, validator.VerifySignature("0:"+hex.EncodeToString(otherStateInit.hash), &SignedMessage{
+			Message:   message,
+			Signature: signed.Signature,
+			PublicKey: signed.PublicKey,
+			StateInit: hex.EncodeToString(serializeBagOfCells(otherStateInit)),
+		}), ErrInvalidSignature)
+	}
+}
+
+func TestParseBagOfCells(t *testing.T) {
+	t.Parallel()
+	emptyCell, err := base64.StdEncoding.DecodeString("te6cckEBAQEAAgAAAEysuc0=")
+	require.NoError(t, err)
+	root, err := parseBagOfCells(emptyCell)
+	require.NoError(t, err)
+	assert.Equal(t, "96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7", hex.EncodeToString(root.hash))
+	emptyCell[len(emptyCell)-1]++
+	_, err = parseBagOfCells(emptyCell)
+	require.Error(t, err)
+
+	stateInit := newTestStateInit("1", make([]byte, ed25519.PublicKeySize))
+	root, err = parseBagOfCells(serializeBagOfCells(stateInit))
+	require.NoError(t, err)
+	assert.Equal(t, stateInit.hash, root.hash)
+	assert.Equal(t, uint16(2), root.depth)
+	data, err := root.stateInitData()
+	require.NoError(t, err)
+	assert.Equal(t, 1+ed25519.PublicKeySize*8+1, data.bitsLen)
+
+	_, err = parseBagOfCells([]byte("bogus"))
+	require.Error(t, err)
+}
+
+func TestRegistryVerifySignature(t *testing.T) {
+	t.Parallel()
+	privateKey := secp256k1.PrivKeyFromBytes(keccak256([]byte("some other seed")))
+	address := "0x" + hex.EncodeToString(keccak256(privateKey.PubKey().SerializeUncompressed()[1:])[12:])
+	message := "some nonce to sign"
+
+	require.NoError(t, New().VerifySignature(address, &SignedMessage{Message: message, Signature: signEIP191(privateKey, message)}))
+	require.ErrorIs(t, New().VerifySignature("bogus", &SignedMessage{Message: message, Signature: signEIP191(privateKey, message)}), ErrInvalid)
+}
+
+// It returns the `r || s || v` hex signature, like wallets do for `personal_sign`.
+func signEIP191(privateKey *secp256k1.PrivateKey, message string) string {
+	compact := ecdsa.SignCompact(privateKey, keccak256([]byte(fmt.Sprintf(eip191MessagePrefix, len(message))+message)), false)
+
+	return hex.EncodeToString(append(compact[1:], compact[0]))
+}
+
+// It builds the state init of a wallet, whose data has the public key after the provided bits and an empty dictionary after it.
+func newTestStateInit(dataPrefix string, publicKey []byte) *tonCell {
+	code := newTestCell(bitsOf([]byte("wallet code")), newTestCell(bitsOf([]byte("library"))))
+	data := newTestCell(dataPrefix + bitsOf(publicKey) + "0")
+
+	return newTestCell("00110", code, data)
+}
+
+func newTestCell(bitString string, refs ...*tonCell) *tonCell {
+	cell := &tonCell{data: make([]byte, len(bitString)/8+1), bitsLen: len(bitString), refs: refs}
+	for i, bit := range bitString {
+		if bit == '1' {
+			cell.data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	if len(bitString)%8 == 0 {
+		cell.data = cell.data[:len(bitString)/8]
+	} else {
+		cell.data[len(bitString)/8] |= 1 << (7 - len(bitString)%8)
+	}
+	cell.computeHash()
+
+	return cell
+}
+
+func bitsOf(data []byte) string {
+	var bitString strings.Builder
+	for _, b := range data {
+		bitString.WriteString(fmt.Sprintf("%08b", b))
+	}
+
+	return bitString.String()
+}
+
+// It serializes the cells in depth-first order, with a CRC, so it doesn't support shared cells.
+func serializeBagOfCells(root *tonCell) []byte {
+	var cells []*tonCell
+	var visit func(*tonCell)
+	visit = func(cell *tonCell) {
+		cells = append(cells, cell)
+		for _, ref := range cell.refs {
+			visit(ref)
+		}
+	}
+	visit(root)
+	indexes := make(map[*tonCell]byte, len(cells))
+	for i, cell := range cells {
+		indexes[cell] = byte(i)
+	}
+	var payload []byte
+	for _, cell := range cells {
+		payload = append(payload, byte(len(cell.refs)), byte(cell.bitsLen/8+(cell.bitsLen+7)/8))
+		payload = append(payload, cell.data...)
+		for _, ref := range cell.refs {
+			payload = append(payload, indexes[ref])
+		}
+	}
+	boc := binary.BigEndian.AppendUint32(nil, tonBagOfCellsMagic)
+	boc = append(boc, 0x41, 2, byte(len(cells)), 1, 0)
+	boc = binary.BigEndian.AppendUint16(boc, uint16(len(payload)))
+	boc = append(append(boc, 0), payload...)
+
+	return binary.LittleEndian.AppendUint32(boc, crc32.Checksum(boc, crc32.MakeTable(crc32.Castagnoli)))
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"math/bits"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The state init must be the one the address is derived from and the public key must be in its data, where the wallets store it.
+func verifyTONWalletPublicKey(normalizedAddress string, stateInit, publicKey []byte) error {
+	root, err := parseBagOfCells(stateInit)
+	if err != nil {
+		return errors.Wrap(err, "invalid state init")
+	}
+	if addressHash, hErr := hex.DecodeString(normalizedAddress[strings.IndexByte(normalizedAddress, ':')+1:]); hErr != nil ||
+		!bytes.Equal(addressHash, root.hash) {
+		return errors.Errorf("state init doesn't match the address %v", normalizedAddress)
+	}
+	data, err := root.stateInitData()
+	if err != nil {
+		return errors.Wrap(err, "invalid state init")
+	}
+	for _, offset := range tonWalletPublicKeyOffsets {
+		if key, ok := data.bits(offset, tonPublicKeyBits); ok && bytes.Equal(key, publicKey) {
+			return nil
+		}
+	}
+
+	return errors.Errorf("public key isn't the one of the wallet %v", normalizedAddress)
+}
+
+// It supports only the bags of cells with a single root and ordinary cells, that's what the wallets use for their state init.
+//
+//nolint:funlen,gocognit,gomnd,revive // It's the serialization format.
+func parseBagOfCells(boc []byte) (*tonCell, error) {
+	if len(boc) < 6 || binary.BigEndian.Uint32(boc) != tonBagOfCellsMagic {
+		return nil, errors.New("not a bag of cells")
+	}
+	hasIndex, hasCRC, refSize, offsetSize := boc[4]&0x80 != 0, boc[4]&0x40 != 0, int(boc[4]&0x07), int(boc[5])
+	if refSize == 0 || refSize > 4 || offsetSize == 0 || offsetSize > 8 {
+		return nil, errors.New("invalid bag of cells sizes")
+	}
+	if hasCRC {
+		if len(boc) < 6+4 || crc32.Checksum(boc[:len(boc)-4], crc32.MakeTable(crc32.Castagnoli)) != binary.LittleEndian.Uint32(boc[len(boc)-4:]) {
+			return nil, errors.New("invalid bag of cells checksum")
+		}
+		boc = boc[:len(boc)-4]
+	}
+	pos := 6
+	readUint := func(size int) (uint64, bool) {
+		if pos+size > len(boc) {
+			return 0, false
+		}
+		var val uint64
+		for _, b := range boc[pos : pos+size] {
+			val = val<<8 | uint64(b)
+		}
+		pos += size
+
+		return val, true
+	}
+	cellsCount, ok1 := readUint(refSize)
+	rootsCount, ok2 := readUint(refSize)
+	_, ok3 := readUint(refSize)
+	totalCellsSize, ok4 := readUint(offsetSize)
+	rootIndex, ok5 := readUint(refSize)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || rootsCount != 1 || rootIndex >= cellsCount || cellsCount > uint64(len(boc)) {
+		return nil, errors.New("invalid bag of cells header, exactly one root is supported")
+	}
+	if hasIndex {
+		pos += int(cellsCount) * offsetSize
+	}
+	if pos > len(boc) || uint64(len(boc)-pos) != totalCellsSize {
+		return nil, errors.New("invalid bag of cells size")
+	}
+	cells := make([]*tonCell, cellsCount)
+	refIndexes := make([][]uint64, cellsCount)
+	for i := range cells {
+		if pos+2 > len(boc) {
+			return nil, errors.New("truncated cell")
+		}
+		d1, d2 := boc[pos], boc[pos+1]
+		pos += 2
+		if d1&0xf8 != 0 {
+			return nil, errors.New("only ordinary cells without stored hashes are supported")
+		}
+		refsCount, dataLen := int(d1&0x07), int(d2+1)/2
+		if refsCount > tonMaxCellRefs || pos+dataLen > len(boc) {
+			return nil, errors.New("invalid cell")
+		}
+		cell := &tonCell{data: boc[pos : pos+dataLen], bitsLen: dataLen * 8, refs: make([]*tonCell, refsCount)}
+		pos += dataLen
+		if d2%2 == 1 {
+			last := cell.data[dataLen-1]
+			if last == 0 {
+				return nil, errors.New("invalid cell completion tag")
+			}
+			cell.bitsLen -= bits.TrailingZeros8(last) + 1
+		}
+		for range refsCount {
+			refIndex, ok := readUint(refSize)
+			if !ok || refIndex <= uint64(i) || refIndex >= cellsCount {
+				return nil, errors.New("invalid cell reference")
+			}
+			refIndexes[i] = append(refIndexes[i], refIndex)
+		}
+		cells[i] = cell
+	}
+	for i := len(cells) - 1; i >= 0; i-- {
+		for j, refIndex := range refIndexes[i] {
+			cells[i].refs[j] = cells[refIndex]
+		}
+		cells[i].computeHash()
+	}
+
+	return cells[rootIndex], nil
+}
+
+// The representation hash of an ordinary cell: its descriptors, its data (with the completion tag) and the depths and hashes of its references.
+func (c *tonCell) computeHash() {
+	hash := sha256.New()
+	hash.Write([]byte{byte(len(c.refs)), byte(c.bitsLen/8 + (c.bitsLen+7)/8)}) //nolint:errcheck,revive,gomnd // It never fails.
+	hash.Write(c.data)                                                         //nolint:errcheck,revive // It never fails.
+	for _, ref := range c.refs {
+		hash.Write([]byte{byte(ref.depth >> 8), byte(ref.depth)}) //nolint:errcheck,revive,gomnd // It never fails.
+		if ref.depth+1 > c.depth {
+			c.depth = ref.depth + 1
+		}
+	}
+	for _, ref := range c.refs {
+		hash.Write(ref.hash) //nolint:errcheck,revive // It never fails.
+	}
+	c.hash = hash.Sum(nil)
+}
+
+// The state init is `split_depth:(Maybe (## 5)) special:(Maybe TickTock) code:(Maybe ^Cell) data:(Maybe ^Cell) library:(Maybe ^Cell)`.
+//
+//nolint:gomnd // It's the TL-B scheme.
+func (c *tonCell) stateInitData() (*tonCell, error) {
+	pos, refIndex := 0, 0
+	for _, maybeLen := range []int{5, 2} {
+		present, ok := c.bits(pos, 1)
+		if !ok {
+			return nil, errors.New("truncated state init")
+		}
+		pos++
+		if present[0] != 0 {
+			pos += maybeLen
+		}
+	}
+	hasCode, okCode := c.bits(pos, 1)
+	hasData, okData := c.bits(pos+1, 1)
+	if !okCode || !okData || hasData[0] == 0 {
+		return nil, errors.New("state init has no data")
+	}
+	if hasCode[0] != 0 {
+		refIndex++
+	}
+	if refIndex >= len(c.refs) {
+		return nil, errors.New("state init has no data reference")
+	}
+
+	return c.refs[refIndex], nil
+}
+
+// It returns the `length` bits starting at `offset`, packed from the most significant bit of the first byte.
+//
+//nolint:gomnd // Bit operations.
+func (c *tonCell) bits(offset, length int) ([]byte, bool) {
+	if offset < 0 || length < 0 || offset+length > c.bitsLen {
+		return nil, false
+	}
+	res := make([]byte, (length+7)/8)
+	for i := range length {
+		bit := (c.data[(offset+i)/8] >> (7 - (offset+i)%8)) & 1
+		res[i/8] |= bit << (7 - i%8)
+	}
+
+	return res, true
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package dataexport
+
+import (
+	"context"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Public API.
+
+const (
+	Version = uint64(1)
+
+	UsersSectionName = "users"
+)
+
+type (
+	UserID = string
+	// Section holds all the rows, per table, that a module stores about an user.
+	Section struct {
+		Tables map[string][]map[string]any `json:"tables"`
+		Name   string                      `json:"-"`
+	}
+	Export struct {
+		ExportedAt *time.Time          `json:"exportedAt" example:"2022-01-03T16:20:52.156534Z"`
+		Sections   map[string]*Section `json:"sections"`
+		UserID     UserID              `json:"userId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2"`
+		Version    uint64              `json:"version" example:"1"`
+	}
+	Exporter interface {
+		ExportUserData(ctx context.Context, userID UserID) (*Section, error)
+	}
+	// SelectFunc returns all the rows of the query, as column name to value maps.
+	SelectFunc func(ctx context.Context, sql string, args ...any) ([]*map[string]any, error)
+)
+
+//nolint:gochecknoglobals // It's read only.
+var (
+	// UsersTableQueries are the tables the users module exports, selected by user id.
+	UsersTableQueries = map[string]string{
+		"users":                                `SELECT * FROM users WHERE id = $1`,
+		"device_metadata":                      `SELECT * FROM device_metadata WHERE user_id = $1`,
+		"referral_acquisition_history":         `SELECT * FROM referral_acquisition_history WHERE user_id = $1`,
+		"referral_acquisition_history_per_day": `SELECT * FROM referral_acquisition_history_per_day WHERE user_id = $1 ORDER BY date`,
+		"user_audit_log":                       `SELECT * FROM user_audit_log WHERE user_id = $1 ORDER BY id`,
+		"agenda_contacts_sync":                 `SELECT * FROM agenda_contacts_sync WHERE user_id = $1`,
+		"wallet_ownership_challenges":          `SELECT * FROM wallet_ownership_challenges WHERE user_id = $1`,
+		"user_blocks":                          `SELECT * FROM user_blocks WHERE user_id = $1`,
+		"user_reports":                         `SELECT * FROM user_reports WHERE reporter_user_id = $1 ORDER BY id`,
+		"username_history":                     `SELECT * FROM username_history WHERE user_id = $1 ORDER BY changed_at`,
+		"device_accounts":                      `SELECT * FROM device_accounts WHERE user_id = $1`,
+		"device_risk_flags":                    `SELECT * FROM device_risk_flags WHERE user_id = $1`,
+		"ip_risk_assessments":                  `SELECT * FROM ip_risk_assessments WHERE user_id = $1 ORDER BY created_at`,
+	}
+)
@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package dataexport
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Gather gathers the sections provided by every exporter into one versioned document.
+func Gather(ctx context.Context, userID UserID, exporters ...Exporter) (*Export, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "export user data failed because context failed")
+	}
+	export := &Export{
+		ExportedAt: time.Now(),
+		Sections:   make(map[string]*Section, len(exporters)),
+		UserID:     userID,
+		Version:    Version,
+	}
+	for _, exporter := range exporters {
+		section, err := exporter.ExportUserData(ctx, userID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to export user data section for userID:%v", userID)
+		}
+		export.Sections[section.Name] = section
+	}
+
+	return export, nil
+}
+
+// SelectSection runs every table query with the provided args and gathers the resulting rows into a section.
+// The tables without rows are kept, empty, so the section always lists everything the module could store.
+func SelectSection(
+	ctx context.Context, selectRows SelectFunc, name string, tableQueries map[string]string, args ...any,
+) (*Section, error) {
+	section := &Section{Name: name, Tables: make(map[string][]map[string]any, len(tableQueries))}
+	for table, sql := range tableQueries {
+		rows, err := selectRows(ctx, sql, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to select %v rows for %#v", table, args)
+		}
+		section.Tables[table] = make([]map[string]any, 0, len(rows))
+		for _, row := range rows {
+			section.Tables[table] = append(section.Tables[table], *row)
+		}
+	}
+
+	return section, nil
+}
+
+// RequireRows fails with storage.ErrNotFound if the table has no rows, like the table of the user itself, when the user doesn't exist.
+func (s *Section) RequireRows(table string) error {
+	if len(s.Tables[table]) == 0 {
+		return errors.Wrapf(storage.ErrNotFound, "no %v rows in the %v section", table, s.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package dataexport
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+type (
+	mockedExporter struct {
+		err     error
+		section *Section
+	}
+)
+
+func (e *mockedExporter) ExportUserData(context.Context, UserID) (*Section, error) {
+	return e.section, e.err
+}
+
+func TestGather(t *testing.T) {
+	t.Parallel()
+
+	users := &Section{Name: UsersSectionName, Tables: map[string][]map[string]any{"users": {{"id": "bogus"}}}}
+	quiz := &Section{Name: "quiz", Tables: map[string][]map[string]any{"quiz_sessions": {}}}
+	export, err := Gather(context.Background(), "bogus", &mockedExporter{section: users}, &mockedExporter{section: quiz})
+	require.NoError(t, err)
+	assert.Equal(t, UserID("bogus"), export.UserID)
+	assert.Equal(t, Version, export.Version)
+	assert.NotNil(t, export.ExportedAt)
+	assert.Equal(t, map[string]*Section{UsersSectionName: users, "quiz": quiz}, export.Sections)
+}
+
+func TestGatherFailsIfAnyExporterFails(t *testing.T) {
+	t.Parallel()
+
+	users := &mockedExporter{err: errors.Wrap(storage.ErrNotFound, "user with id bogus not found")}
+	quiz := &mockedExporter{section: &Section{Name: "quiz"}}
+	export, err := Gather(context.Background(), "bogus", quiz, users)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Nil(t, export)
+}
+
+func TestGatherFailsIfContextFailed(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	export, err := Gather(ctx, "bogus", &mockedExporter{section: &Section{Name: "quiz"}})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, export)
+}
+
+func TestSelectSection(t *testing.T) {
+	t.Parallel()
+
+	rows := map[string][]*map[string]any{
+		`SELECT * FROM a WHERE user_id = $1`: {{"user_id": "bogus", "n": 1}, {"user_id": "bogus", "n": 2}},
+		`SELECT * FROM b WHERE user_id = $1`: {},
+	}
+	selectRows := func(_ context.Context, sql string, args ...any) ([]*map[string]any, error) {
+		assert.Equal(t, []any{"bogus"}, args)
+
+		return rows[sql], nil
+	}
+	section, err := SelectSection(context.Background(), selectRows, "bogus_section", map[string]string{
+		"a": `SELECT * FROM a WHERE user_id = $1`,
+		"b": `SELECT * FROM b WHERE user_id = $1`,
+	}, "bogus")
+	require.NoError(t, err)
+	assert.Equal(t, "bogus_section", section.Name)
+	assert.Equal(t, map[string][]map[string]any{
+		"a": {{"user_id": "bogus", "n": 1}, {"user_id": "bogus", "n": 2}},
+		"b": {},
+	}, section.Tables)
+	require.NoError(t, section.RequireRows("a"))
+	require.ErrorIs(t, section.RequireRows("b"), storage.ErrNotFound)
+	require.ErrorIs(t, section.RequireRows("c"), storage.ErrNotFound)
+}
+
+func TestSelectSectionFailsIfAnySelectFails(t *testing.T) {
+	t.Parallel()
+
+	errBogus := errors.New("bogus")
+	selectRows := func(context.Context, string, ...any) ([]*map[string]any, error) {
+		return nil, errBogus
+	}
+	section, err := SelectSection(context.Background(), selectRows, "bogus_section", map[string]string{"a": `SELECT 1`}, "bogus")
+	require.ErrorIs(t, err, errBogus)
+	assert.Nil(t, section)
+}
+
+func TestUsersTableQueries(t *testing.T) {
+	t.Parallel()
+
+	tables := make([]string, 0, len(UsersTableQueries))
+	for table, sql := range UsersTableQueries {
+		tables = append(tables, table)
+		assert.Contains(t, sql, "FROM "+table+" WHERE ", table)
+		assert.Contains(t, sql, "= $1", table)
+	}
+	sort.Strings(tables)
+	assert.Equal(t, []string{
+		"agenda_contacts_sync",
+		"device_accounts",
+		"device_metadata",
+		"device_risk_flags",
+		"ip_risk_assessments",
+		"referral_acquisition_history",
+		"referral_acquisition_history_per_day",
+		"user_audit_log",
+		"user_blocks",
+		"user_reports",
+		"username_history",
+		"users",
+		"wallet_ownership_challenges",
+	}, tables)
+}
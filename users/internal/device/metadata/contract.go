@@ -7,8 +7,13 @@ import (
 	_ "embed"
 	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	stdlibtime "time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/ip2location/ip2location-go/v9"
+	"github.com/oschwald/maxminddb-golang"
 	"github.com/pkg/errors"
 
 	"github.com/ice-blockchain/eskimo/users/internal/device"
@@ -22,12 +27,17 @@ import (
 var (
 	ErrInvalidAppVersion  = errors.New("invalid mobile app version")
 	ErrOutdatedAppVersion = errors.New("outdated mobile app version")
+	ErrIPRejected         = errors.New("ip rejected by risk policy")
 )
 
 type (
-	Keyword = string
-	Country = string
-	City    = string
+	Keyword          = string
+	Country          = string
+	City             = string
+	DeviceRiskReason = string
+	IPClassification = string
+	IPRiskAction     = string
+	IPRiskRoute      = string
 	//nolint:revive // We don't have a choice if we want to embed it, cuz it will clash with others named "Repository".
 	DeviceMetadataRepository interface {
 		io.Closer
@@ -35,8 +45,41 @@ type (
 		LookupCountries(key Keyword) []Country
 		GetDeviceMetadataLocation(ctx context.Context, deviceID *device.ID, clientIP net.IP) *DeviceLocation
 		GetDeviceMetadata(ctx context.Context, id *device.ID) (*DeviceMetadata, error)
+		GetAllDeviceMetadata(ctx context.Context, userID string) ([]*DeviceMetadata, error)
 		ReplaceDeviceMetadata(ctx context.Context, deviceMetadata *DeviceMetadata, clientIP net.IP) error
+		DeleteDeviceMetadata(ctx context.Context, id *device.ID) error
 		DeleteAllDeviceMetadata(ctx context.Context, userID string) error
+		GetDeviceRisk(ctx context.Context, userID string) (*DeviceRisk, error)
+		IsKYCBlockedByDeviceRisk(ctx context.Context, userID string) (bool, error)
+		AssessIPRisk(ctx context.Context, route IPRiskRoute, id *device.ID, clientIP net.IP) (*IPRiskAssessment, error)
+		RecordIPRisk(ctx context.Context, assessment *IPRiskAssessment) error
+	}
+	// GeoIPProvider resolves the location of an IP address from a geolocation database.
+	GeoIPProvider interface {
+		io.Closer
+		Lookup(ip net.IP) (*GeoIPRecord, error)
+	}
+	GeoIPRecord struct {
+		CountryShort       string
+		CountryLong        string
+		Region             string
+		City               string
+		Isp                string
+		Domain             string
+		Zipcode            string
+		Timezone           string
+		Netspeed           string
+		Iddcode            string
+		Areacode           string
+		Weatherstationcode string
+		Weatherstationname string
+		Mcc                string
+		Mnc                string
+		Mobilebrand        string
+		Usagetype          string
+		Latitude           float64
+		Longitude          float64
+		Elevation          float64
 	}
 	DeviceLocation struct {
 		Country Country `json:"country,omitempty" example:"US" db:"country"`
@@ -47,6 +90,31 @@ type (
 		*DeviceMetadata
 		Before *DeviceMetadata `json:"before,omitempty"`
 	}
+	// DeviceRisk is why an account was flagged as possibly being part of a device farm. It has no reasons if the account was never flagged.
+	DeviceRisk struct {
+		FlaggedAt           *time.Time         `json:"flaggedAt,omitempty" example:"2022-01-03T16:20:52.156534Z" db:"flagged_at"`
+		UserID              string             `json:"userId" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2" db:"user_id"`
+		DeviceUniqueID      string             `json:"deviceUniqueId,omitempty" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9" db:"device_unique_id"` //nolint:lll // .
+		Reasons             []DeviceRiskReason `json:"reasons" example:"sharedFingerprint" enums:"sharedFingerprint,sharedDevice,emulator,riskyIP" db:"reasons"`
+		FingerprintAccounts uint64             `json:"fingerprintAccounts" example:"7" db:"fingerprint_accounts"`
+		DeviceAccounts      uint64             `json:"deviceAccounts" example:"3" db:"device_accounts"`
+	}
+	// IPRiskAssessment is how the client IP of a sign up, sign in or device metadata update was classified and what the policy decided for it.
+	IPRiskAssessment struct {
+		CreatedAt      *time.Time       `json:"createdAt" example:"2022-01-03T16:20:52.156534Z" db:"created_at"`
+		UserID         string           `json:"userId,omitempty" example:"did:ethr:0x4B73C58370AEfcEf86A6021afCDe5673511376B2" db:"user_id"`
+		DeviceUniqueID string           `json:"deviceUniqueId,omitempty" example:"FCDBD8EF-62FC-4ECB-B2F5-92C9E79AC7F9" db:"device_unique_id"` //nolint:lll // .
+		Route          IPRiskRoute      `json:"route" example:"signUp" enums:"signUp,signIn,deviceMetadata" db:"route"`
+		ClientIP       string           `json:"clientIp" example:"1.1.1.1" db:"client_ip"`
+		Classification IPClassification `json:"classification" example:"dataCenter" enums:"residential,mobile,dataCenter,vpnProxy,unknown" db:"classification"` //nolint:lll // .
+		Action         IPRiskAction     `json:"action" example:"challenge" enums:"allow,challenge,reject" db:"action"`
+		UsageType      string           `json:"usageType,omitempty" example:"DCH" db:"usage_type"`
+	}
+	// AppVersionStatus tells the app if it should be updated, even if it's not required yet.
+	AppVersionStatus struct {
+		RecommendedAppVersion string `json:"recommendedAppVersion,omitempty" example:"v1.2.3"`
+		UpdateRecommended     bool   `json:"updateRecommended" example:"true"`
+	}
 	DeviceMetadata struct {
 		// Read Only.
 		UpdatedAt        *time.Time `json:"updatedAt,omitempty" swaggertype:"string" db:"updated_at"`
@@ -77,6 +145,8 @@ type (
 		InstallerPackageName  string `json:"installerPackageName,omitempty" db:"installer_package_name"`
 		PushNotificationToken string `json:"pushNotificationToken,omitempty" db:"push_notification_token"`
 		TZ                    string `json:"tz,omitempty" db:"device_timezone"`
+		// Read Only.
+		AppVersionStatus *AppVersionStatus `json:"-" swaggerignore:"true" db:"-"`
 		ip2LocationRecord
 		APILevel            uint64 `json:"apiLevel,omitempty" db:"api_level"`
 		Tablet              bool   `json:"tablet,omitempty" db:"tablet"`
@@ -85,10 +155,49 @@ type (
 	}
 )
 
+const (
+	SharedFingerprintDeviceRiskReason DeviceRiskReason = "sharedFingerprint"
+	SharedDeviceDeviceRiskReason      DeviceRiskReason = "sharedDevice"
+	EmulatorDeviceRiskReason          DeviceRiskReason = "emulator"
+	RiskyIPDeviceRiskReason           DeviceRiskReason = "riskyIP"
+)
+
+const (
+	ResidentialIPClassification IPClassification = "residential"
+	MobileIPClassification      IPClassification = "mobile"
+	DataCenterIPClassification  IPClassification = "dataCenter"
+	VPNProxyIPClassification    IPClassification = "vpnProxy"
+	UnknownIPClassification     IPClassification = "unknown"
+)
+
+const (
+	AllowIPRiskAction     IPRiskAction = "allow"
+	ChallengeIPRiskAction IPRiskAction = "challenge"
+	RejectIPRiskAction    IPRiskAction = "reject"
+)
+
+const (
+	SignUpIPRiskRoute         IPRiskRoute = "signUp"
+	SignInIPRiskRoute         IPRiskRoute = "signIn"
+	DeviceMetadataIPRiskRoute IPRiskRoute = "deviceMetadata"
+)
+
 // Private API.
 
 const (
 	applicationYamlKey = "users"
+
+	maxMindDatabaseExtension = ".mmdb"
+	maxMindNamesLanguage     = "en"
+	geoIPReloadDebounce      = 5 * stdlibtime.Second
+
+	requestDeadline = 25 * stdlibtime.Second
+
+	defaultIPRiskAssessmentsRetention = 90 * 24 * stdlibtime.Hour
+	ipRiskAssessmentsCleanupBatchSize = 10_000
+
+	fingerprintDeviceAccountKind = "fingerprint"
+	deviceDeviceAccountKind      = "device"
 )
 
 var (
@@ -121,6 +230,58 @@ type (
 		Longitude          float64 `json:"-" swaggerignore:"true" db:"longitude"`
 		Elevation          float64 `json:"-" swaggerignore:"true" db:"elevation"`
 	}
+	ip2LocationGeoIPProvider struct {
+		db *ip2location.DB
+	}
+	maxMindGeoIPProvider struct {
+		reader *maxminddb.Reader
+	}
+	maxMindCityRecord struct {
+		City struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"city"`
+		Country struct {
+			Names   map[string]string `maxminddb:"names"`
+			IsoCode string            `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+		Postal struct {
+			Code string `maxminddb:"code"`
+		} `maxminddb:"postal"`
+		Subdivisions []struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"subdivisions"`
+		Location struct {
+			TimeZone  string  `maxminddb:"time_zone"`
+			Latitude  float64 `maxminddb:"latitude"`
+			Longitude float64 `maxminddb:"longitude"`
+		} `maxminddb:"location"`
+	}
+	// | fallbackGeoIPProvider chains the providers in order, the next ones being used only to fill in the missing city.
+	fallbackGeoIPProvider []GeoIPProvider
+	// | watchedGeoIPProvider reopens its database every time the file changes and atomically swaps it with the current one.
+	watchedGeoIPProvider struct {
+		current  GeoIPProvider
+		watcher  *fsnotify.Watcher
+		open     func(path string) (GeoIPProvider, error)
+		done     chan struct{}
+		path     string
+		debounce stdlibtime.Duration
+		mx       sync.RWMutex
+	}
+	// | appVersionConfigJSON is the remote config with the app versions, per platform, with optional overrides per country.
+	appVersionConfigJSON struct {
+		Countries map[Country]*platformAppVersions `json:"countries,omitempty"`
+		platformAppVersions
+	}
+	platformAppVersions struct {
+		Android *appVersions `json:"android,omitempty"`
+		IOS     *appVersions `json:"ios,omitempty"`
+	}
+	appVersions struct {
+		Required      string   `json:"required,omitempty"`
+		Recommended   string   `json:"recommended,omitempty"`
+		BlockedBuilds []string `json:"blockedBuilds,omitempty"`
+	}
 	country struct {
 		Name    string `json:"name"`
 		Flag    string `json:"flag"`
@@ -133,14 +294,38 @@ type (
 			Android string `yaml:"android" mapstructure:"android"`
 			IOS     string `yaml:"ios" mapstructure:"ios"`
 		} `yaml:"requiredAppVersion" mapstructure:"requiredAppVersion"`
+		appVersionConfigJSON *atomic.Pointer[appVersionConfigJSON]
+		// | AppVersionConfigJSONURL is the remote config that overrides `requiredAppVersion`, if set. It's synced every minute.
+		AppVersionConfigJSONURL string `yaml:"app-version-config-json-url" mapstructure:"app-version-config-json-url"` //nolint:tagliatelle // .
+		// | DeviceRisk holds the thresholds above which the accounts sharing a device are flagged.
+		DeviceRisk struct {
+			MaxAccountsPerFingerprint uint64 `yaml:"maxAccountsPerFingerprint" mapstructure:"maxAccountsPerFingerprint"`
+			MaxAccountsPerDevice      uint64 `yaml:"maxAccountsPerDevice" mapstructure:"maxAccountsPerDevice"`
+			// | BlockKYCReasons are the reasons that block the KYC of the flagged accounts, the same way a potentially duplicate face does.
+			BlockKYCReasons []DeviceRiskReason `yaml:"blockKycReasons" mapstructure:"blockKycReasons"`
+			FlagEmulators   bool               `yaml:"flagEmulators" mapstructure:"flagEmulators"`
+		} `yaml:"deviceRisk" mapstructure:"deviceRisk"`
+		// | IPRisk holds the networks always classified as VPN/proxy and, per route, the action for each classification.
+		// | The classifications missing from a route's policy are allowed.
+		IPRisk struct {
+			Policies map[IPRiskRoute]map[IPClassification]IPRiskAction `yaml:"policies" mapstructure:"policies"`
+			Denylist []string                                          `yaml:"denylist" mapstructure:"denylist"`
+			// | Retention is how long the challenged and rejected assessments are kept.
+			Retention stdlibtime.Duration `yaml:"retention" mapstructure:"retention"`
+		} `yaml:"ipRisk" mapstructure:"ipRisk"`
+		// | GeoIP holds the geolocation databases, in lookup order. The `.mmdb` ones are MaxMind, the rest are ip2location.
+		GeoIP struct {
+			Databases []string `yaml:"databases" mapstructure:"databases"`
+		} `yaml:"geoIP" mapstructure:"geoIP"`
 		IP2LocationBinaryPath string                   `yaml:"ip2LocationBinaryPath"`
 		messagebroker.Config  `mapstructure:",squash"` //nolint:tagliatelle // Nope.
 		SkipIP2LocationBinary bool                     `yaml:"skipIp2LocationBinary"`
 	}
 	repository struct {
-		cfg           *config
-		db            *storage.DB
-		mb            messagebroker.Client
-		ip2LocationDB *ip2location.DB
+		cfg        *config
+		db         *storage.DB
+		mb         messagebroker.Client
+		geoIP      GeoIPProvider
+		ipDenylist []*net.IPNet
 	}
 )
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devicemetadata
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ip2location/ip2location-go/v9"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/pkg/errors"
+)
+
+// It opens every database in its own hot-reloadable provider and, if there are more, chains them in the provided order.
+func newGeoIPProvider(paths []string) (GeoIPProvider, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no geoip database configured")
+	}
+	providers := make(fallbackGeoIPProvider, 0, len(paths))
+	for _, path := range paths {
+		provider, err := newWatchedGeoIPProvider(path, openGeoIPDatabase, geoIPReloadDebounce)
+		if err != nil {
+			return nil, multierror.Append(err, providers.Close()).ErrorOrNil() //nolint:wrapcheck // .
+		}
+		providers = append(providers, provider)
+	}
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	return providers, nil
+}
+
+// The format of the database is picked based on its extension: `.mmdb` for MaxMind, anything else for ip2location.
+func openGeoIPDatabase(path string) (GeoIPProvider, error) {
+	if strings.EqualFold(filepath.Ext(path), maxMindDatabaseExtension) {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open MaxMind database %v", path)
+		}
+
+		return &maxMindGeoIPProvider{reader: reader}, nil
+	}
+	db, err := ip2location.OpenDB(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open ip2location database %v", path)
+	}
+
+	return &ip2LocationGeoIPProvider{db: db}, nil
+}
+
+func (p *ip2LocationGeoIPProvider) Lookup(ip net.IP) (*GeoIPRecord, error) {
+	rec, err := p.db.Get_all(ip.String()) //nolint:nosnakecase // External library.
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to lookup %v in ip2location database", ip)
+	}
+
+	return &GeoIPRecord{
+		CountryShort:       rec.Country_short, //nolint:nosnakecase // 3rd party library.
+		CountryLong:        rec.Country_long,  //nolint:nosnakecase // 3rd party library.
+		Region:             rec.Region,
+		City:               rec.City,
+		Isp:                rec.Isp,
+		Domain:             rec.Domain,
+		Zipcode:            rec.Zipcode,
+		Timezone:           rec.Timezone,
+		Netspeed:           rec.Netspeed,
+		Iddcode:            rec.Iddcode,
+		Areacode:           rec.Areacode,
+		Weatherstationcode: rec.Weatherstationcode,
+		Weatherstationname: rec.Weatherstationname,
+		Mcc:                rec.Mcc,
+		Mnc:                rec.Mnc,
+		Mobilebrand:        rec.Mobilebrand,
+		Usagetype:          rec.Usagetype,
+		Elevation:          float64(rec.Elevation),
+		Latitude:           float64(rec.Latitude),
+		Longitude:          float64(rec.Longitude),
+	}, nil
+}
+
+func (p *ip2LocationGeoIPProvider) Close() error {
+	p.db.Close()
+
+	return nil
+}
+
+// The timezone is converted to the current UTC offset, the same way ip2location provides it.
+func (p *maxMindGeoIPProvider) Lookup(ip net.IP) (*GeoIPRecord, error) {
+	var rec maxMindCityRecord
+	if err := p.reader.Lookup(ip, &rec); err != nil {
+		return nil, errors.Wrapf(err, "failed to lookup %v in MaxMind database", ip)
+	}
+	geo := &GeoIPRecord{
+		CountryShort: rec.Country.IsoCode,
+		CountryLong:  rec.Country.Names[maxMindNamesLanguage],
+		City:         rec.City.Names[maxMindNamesLanguage],
+		Zipcode:      rec.Postal.Code,
+		Latitude:     rec.Location.Latitude,
+		Longitude:    rec.Location.Longitude,
+	}
+	if len(rec.Subdivisions) > 0 {
+		geo.Region = rec.Subdivisions[0].Names[maxMindNamesLanguage]
+	}
+	if loc, err := stdlibtime.LoadLocation(rec.Location.TimeZone); err == nil && rec.Location.TimeZone != "" {
+		geo.Timezone = stdlibtime.Now().In(loc).Format("-07:00")
+	}
+
+	return geo, nil
+}
+
+func (p *maxMindGeoIPProvider) Close() error {
+	return errors.Wrap(p.reader.Close(), "failed to close MaxMind database")
+}
+
+// Lookup uses the first provider that knows the IP. If it has no city, the next ones are asked for it,
+// as long as they agree on the country.
+func (p fallbackGeoIPProvider) Lookup(ip net.IP) (*GeoIPRecord, error) {
+	var (
+		geo  *GeoIPRecord
+		errs []error
+	)
+	for _, provider := range p {
+		rec, err := provider.Lookup(ip)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		if geo == nil {
+			geo = rec
+		} else if hasGeoIPValue(rec.City) &&
+			(!hasGeoIPValue(geo.CountryShort) || strings.EqualFold(geo.CountryShort, rec.CountryShort)) {
+			geo.CountryShort, geo.CountryLong = rec.CountryShort, rec.CountryLong
+			geo.Region, geo.City, geo.Zipcode = rec.Region, rec.City, rec.Zipcode
+			geo.Latitude, geo.Longitude = rec.Latitude, rec.Longitude
+		}
+		if hasGeoIPValue(geo.City) {
+			break
+		}
+	}
+	if geo == nil {
+		return nil, multierror.Append(errors.Errorf("no geoip database could lookup %v", ip), errs...) //nolint:wrapcheck // .
+	}
+
+	return geo, nil
+}
+
+func (p fallbackGeoIPProvider) Close() error {
+	errs := make([]error, 0, len(p))
+	for _, provider := range p {
+		errs = append(errs, provider.Close())
+	}
+
+	return multierror.Append(nil, errs...).ErrorOrNil() //nolint:wrapcheck // Not needed.
+}
+
+// The ip2location databases use `-` for the values they don't have.
+func hasGeoIPValue(val string) bool {
+	return val != "" && val != "-"
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devicemetadata
+
+import (
+	"net"
+	"path/filepath"
+	stdlibtime "time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/log"
+)
+
+// It watches the directory of the database, not the file itself, because the databases are usually updated
+// by moving a new file over the old one, which would stop a watch on the old file.
+func newWatchedGeoIPProvider(path string, open func(string) (GeoIPProvider, error), debounce stdlibtime.Duration) (*watchedGeoIPProvider, error) {
+	current, err := open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open geoip database %v", path)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, multierror.Append(errors.Wrap(err, "failed to create geoip database watcher"), current.Close()).ErrorOrNil() //nolint:wrapcheck // .
+	}
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		return nil, multierror.Append(errors.Wrapf(err, "failed to watch geoip database %v", path), current.Close(), watcher.Close()).ErrorOrNil() //nolint:wrapcheck,lll // .
+	}
+	provider := &watchedGeoIPProvider{
+		current:  current,
+		watcher:  watcher,
+		open:     open,
+		path:     filepath.Clean(path),
+		debounce: debounce,
+		done:     make(chan struct{}),
+	}
+	go provider.watch()
+
+	return provider, nil
+}
+
+func (p *watchedGeoIPProvider) Lookup(ip net.IP) (*GeoIPRecord, error) {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	return p.current.Lookup(ip) //nolint:wrapcheck // It's just a proxy.
+}
+
+func (p *watchedGeoIPProvider) Close() error {
+	watcherErr := errors.Wrap(p.watcher.Close(), "failed to close geoip database watcher")
+	<-p.done
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	return multierror.Append(watcherErr, errors.Wrapf(p.current.Close(), "failed to close geoip database %v", p.path)).ErrorOrNil() //nolint:wrapcheck // .
+}
+
+// The reload is delayed until the file stops changing, so we don't open half written databases.
+func (p *watchedGeoIPProvider) watch() {
+	defer close(p.done)
+	reload := stdlibtime.NewTimer(p.debounce)
+	reload.Stop()
+	defer reload.Stop()
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == p.path && event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+				reload.Reset(p.debounce)
+			}
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error(errors.Wrapf(err, "geoip database watcher failed for %v", p.path))
+		case <-reload.C:
+			log.Error(errors.Wrapf(p.reload(), "failed to reload geoip database %v, keeping the previous one", p.path))
+		}
+	}
+}
+
+func (p *watchedGeoIPProvider) reload() error {
+	next, err := p.open(p.path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the new geoip database")
+	}
+	p.mx.Lock()
+	previous := p.current
+	p.current = next
+	p.mx.Unlock()
+
+	return errors.Wrap(previous.Close(), "failed to close the previous geoip database")
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devicemetadata
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"slices"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/device"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// AssessIPRisk classifies the client IP and applies the policy of the route, without recording anything.
+// It returns the assessment together with ErrIPRejected, if the route rejects such IPs.
+func (r *repository) AssessIPRisk(ctx context.Context, route IPRiskRoute, id *device.ID, clientIP net.IP) (*IPRiskAssessment, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context failed")
+	}
+	var rec *GeoIPRecord
+	if r.geoIP != nil {
+		var err error
+		if rec, err = r.geoIP.Lookup(clientIP); err != nil {
+			log.Error(errors.Wrapf(err, "failed to lookup %v to assess its risk, for %#v", clientIP, id))
+		}
+	}
+
+	return r.assessIPRisk(route, id, clientIP, rec)
+}
+
+func (r *repository) assessIPRisk(route IPRiskRoute, id *device.ID, clientIP net.IP, rec *GeoIPRecord) (*IPRiskAssessment, error) {
+	assessment := &IPRiskAssessment{
+		CreatedAt:      time.Now(),
+		UserID:         id.UserID,
+		DeviceUniqueID: id.DeviceUniqueID,
+		Route:          route,
+		ClientIP:       clientIP.String(),
+		Classification: r.classifyIP(clientIP, rec),
+	}
+	if rec != nil && hasGeoIPValue(rec.Usagetype) {
+		assessment.UsageType = rec.Usagetype
+	}
+	assessment.Action = r.cfg.ipRiskAction(route, assessment.Classification)
+	if assessment.Action == RejectIPRiskAction {
+		return assessment, errors.Wrapf(ErrIPRejected, "%v IPs are rejected for %v", assessment.Classification, route)
+	}
+
+	return assessment, nil
+}
+
+// RecordIPRisk records the assessments that challenged or rejected the client IP, the allowed ones aren't kept.
+// The account of a challenged assessment is flagged, the same way the accounts of device farms are.
+func (r *repository) RecordIPRisk(ctx context.Context, assessment *IPRiskAssessment) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "context failed")
+	}
+	if assessment == nil || !assessment.isRecorded() {
+		return nil
+	}
+	sql := `INSERT INTO ip_risk_assessments (created_at, user_id, device_unique_id, route, client_ip, classification, action, usage_type)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	if _, err := storage.Exec(ctx, r.db, sql,
+		assessment.CreatedAt.Time,
+		assessment.UserID,
+		assessment.DeviceUniqueID,
+		assessment.Route,
+		assessment.ClientIP,
+		assessment.Classification,
+		assessment.Action,
+		assessment.UsageType,
+	); err != nil {
+		return errors.Wrapf(err, "failed to record %#v", assessment)
+	}
+	if assessment.Action != ChallengeIPRiskAction || assessment.UserID == "" {
+		return nil
+	}
+	risk := &DeviceRisk{
+		FlaggedAt:      time.Now(),
+		UserID:         assessment.UserID,
+		DeviceUniqueID: assessment.DeviceUniqueID,
+		Reasons:        []DeviceRiskReason{RiskyIPDeviceRiskReason},
+	}
+
+	return errors.Wrapf(r.flagDeviceRisk(ctx, risk), "failed to flag %#v", assessment)
+}
+
+func (a *IPRiskAssessment) isRecorded() bool {
+	return a.Action == ChallengeIPRiskAction || a.Action == RejectIPRiskAction
+}
+
+func (r *repository) startIPRiskAssessmentsCleaner(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(stdlibtime.Duration(1+rand.Intn(60)) * stdlibtime.Minute) //nolint:gosec,gomnd // Not an  issue.
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			const deadline = 5 * stdlibtime.Minute
+			reqCtx, cancel := context.WithTimeout(ctx, deadline)
+			log.Error(errors.Wrap(r.deleteExpiredIPRiskAssessments(reqCtx), "failed to deleteExpiredIPRiskAssessments"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// It deletes in batches, so the table isn't locked for too long if there's a big backlog.
+func (r *repository) deleteExpiredIPRiskAssessments(ctx context.Context) error {
+	sql := `DELETE FROM ip_risk_assessments
+			WHERE ctid IN (SELECT ctid
+						   FROM ip_risk_assessments
+						   WHERE created_at < $1
+						   ORDER BY created_at
+						   LIMIT $2)`
+	expiredBefore := time.Now().Add(-r.cfg.IPRisk.Retention)
+	for ctx.Err() == nil {
+		deleted, err := storage.Exec(ctx, r.db, sql, expiredBefore, ipRiskAssessmentsCleanupBatchSize)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete ip risk assessments older than %v", expiredBefore)
+		}
+		if deleted < ipRiskAssessmentsCleanupBatchSize {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ctx.Err(), "unexpected deadline")
+}
+
+func (r *repository) classifyIP(ip net.IP, rec *GeoIPRecord) IPClassification {
+	for _, network := range r.ipDenylist {
+		if network.Contains(ip) {
+			return VPNProxyIPClassification
+		}
+	}
+	if rec == nil || !hasGeoIPValue(rec.Usagetype) {
+		return UnknownIPClassification
+	}
+
+	return classifyUsageType(rec.Usagetype)
+}
+
+// The usage types are the ip2location ones, which can be combined, like `ISP/MOB`.
+func classifyUsageType(usageType string) IPClassification {
+	usageTypes := strings.Split(strings.ToUpper(usageType), "/")
+	for _, dataCenterUsageType := range []string{"DCH", "CDN", "SES"} {
+		if slices.Contains(usageTypes, dataCenterUsageType) {
+			return DataCenterIPClassification
+		}
+	}
+	if slices.Contains(usageTypes, "MOB") {
+		return MobileIPClassification
+	}
+
+	return ResidentialIPClassification
+}
+
+// The keys are matched case-insensitively, because the config loader lowercases them.
+func (cfg *config) ipRiskAction(route IPRiskRoute, classification IPClassification) IPRiskAction {
+	for policyRoute, policy := range cfg.IPRisk.Policies {
+		if !strings.EqualFold(policyRoute, route) {
+			continue
+		}
+		for policyClassification, action := range policy {
+			if strings.EqualFold(policyClassification, classification) {
+				return strings.ToLower(action)
+			}
+		}
+	}
+
+	return AllowIPRiskAction
+}
+
+func (cfg *config) validateIPRisk() error {
+	for route, policy := range cfg.IPRisk.Policies {
+		for classification, action := range policy {
+			switch strings.ToLower(action) {
+			case AllowIPRiskAction, ChallengeIPRiskAction, RejectIPRiskAction:
+			default:
+				return errors.Errorf("invalid ip risk action `%v` for %v/%v", action, route, classification)
+			}
+		}
+	}
+
+	return nil
+}
+
+// The denylist accepts both networks, in CIDR notation, and single IPs.
+func parseIPDenylist(denylist []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(denylist))
+	for _, entry := range denylist {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, errors.Errorf("invalid ip `%v` in ip risk denylist", entry)
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}) //nolint:gomnd // Bits in a byte.
+
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid network `%v` in ip risk denylist", entry)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
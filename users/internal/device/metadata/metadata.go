@@ -11,11 +11,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	stdlibtime "time"
+	"unicode"
 
 	"github.com/goccy/go-json"
 	"github.com/hashicorp/go-multierror"
-	"github.com/ip2location/ip2location-go/v9"
 	"github.com/pkg/errors"
 	"golang.org/x/mod/semver"
 
@@ -42,24 +43,54 @@ func init() {
 	}
 }
 
-func New(db *storage.DB, mb messagebroker.Client) DeviceMetadataRepository {
+func New(ctx context.Context, db *storage.DB, mb messagebroker.Client) DeviceMetadataRepository {
 	var cfg config
 	appcfg.MustLoadFromKey(applicationYamlKey, &cfg)
-	repo := &repository{db: db, mb: mb, cfg: &cfg}
+	log.Panic(errors.Wrap(cfg.validateIPRisk(), "invalid ip risk policies"))
+	ipDenylist, err := parseIPDenylist(cfg.IPRisk.Denylist)
+	log.Panic(errors.Wrap(err, "invalid ip risk denylist"))
+	if cfg.IPRisk.Retention == 0 {
+		cfg.IPRisk.Retention = defaultIPRiskAssessmentsRetention
+	}
+	repo := &repository{db: db, mb: mb, cfg: &cfg, ipDenylist: ipDenylist}
 	if mb != nil && !cfg.SkipIP2LocationBinary {
-		var err error
-		repo.ip2LocationDB, err = ip2location.OpenDB(cfg.IP2LocationBinaryPath)
-		log.Panic(errors.Wrap(err, "unable to open ip2location database"))
+		databases := cfg.GeoIP.Databases
+		if len(databases) == 0 {
+			databases = []string{cfg.IP2LocationBinaryPath}
+		}
+		repo.geoIP, err = newGeoIPProvider(databases)
+		log.Panic(errors.Wrap(err, "unable to open geoip databases"))
+	}
+	if mb != nil && cfg.AppVersionConfigJSONURL != "" {
+		cfg.appVersionConfigJSON = new(atomic.Pointer[appVersionConfigJSON])
+		log.Panic(errors.Wrap(repo.syncAppVersionConfigJSON(ctx), "failed to syncAppVersionConfigJSON"))
+		go repo.startAppVersionConfigJSONSyncer(ctx)
+	}
+	if mb != nil {
+		go repo.startIPRiskAssessmentsCleaner(ctx)
 	}
 
 	return repo
 }
 
+func (r *repository) DeleteDeviceMetadata(ctx context.Context, id *device.ID) error {
+	dm, err := r.GetDeviceMetadata(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get device metadata for %#v", id)
+	}
+
+	return errors.Wrapf(r.deleteDeviceMetadata(ctx, dm), "failed to deleteDeviceMetadata for %#v", id)
+}
+
 func (r *repository) DeleteAllDeviceMetadata(ctx context.Context, userID string) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "unexpected deadline ")
 	}
-	sql := `SELECT * FROM device_metadata WHERE user_id = $1`
+	sql := `DELETE FROM ip_risk_assessments WHERE user_id = $1`
+	if _, err := storage.Exec(ctx, r.db, sql, userID); err != nil {
+		return errors.Wrapf(err, "failed to delete ip risk assessments for userID:%v", userID)
+	}
+	sql = `SELECT * FROM device_metadata WHERE user_id = $1`
 	res, err := storage.Select[DeviceMetadata](ctx, r.db, sql, userID)
 	if err != nil {
 		return errors.Wrapf(err, "failed to select all device metadata for userID:%v", userID)
@@ -106,8 +137,8 @@ func (r *repository) deleteDeviceMetadata(ctx context.Context, dm *DeviceMetadat
 }
 
 func (r *repository) Close() error {
-	if r.ip2LocationDB != nil {
-		r.ip2LocationDB.Close()
+	if r.geoIP != nil {
+		return errors.Wrap(r.geoIP.Close(), "failed to close geoip databases")
 	}
 
 	return nil
@@ -122,7 +153,7 @@ func (r *repository) GetDeviceMetadataLocation(ctx context.Context, deviceID *de
 	//nolint:godox // .
 	// TODO: TBD if we need to use deviceID.DeviceUniqueID and/or deviceID.UserID to find some default/preferred value for the user.
 
-	result, err := r.ip2LocationDB.Get_all(clientIP.String()) //nolint:nosnakecase // External library.
+	result, err := r.geoIP.Lookup(clientIP)
 	if err != nil {
 		log.Error(errors.Wrapf(err, "unable to get country&city for %#v, %v", deviceID, clientIP.String()))
 
@@ -130,8 +161,8 @@ func (r *repository) GetDeviceMetadataLocation(ctx context.Context, deviceID *de
 	}
 
 	return &DeviceLocation{
-		Country: strings.ToUpper(result.Country_short), //nolint:nosnakecase // External library.
-		City:    result.City,
+		Country: strings.ToUpper(result.CountryShort),
+		City:    NormalizeCity(result.City),
 	}
 }
 
@@ -151,22 +182,42 @@ func (r *repository) GetDeviceMetadata(ctx context.Context, id *device.ID) (*Dev
 	return dm, nil
 }
 
+func (r *repository) GetAllDeviceMetadata(ctx context.Context, userID string) ([]*DeviceMetadata, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context failed")
+	}
+	sql := `SELECT * FROM device_metadata WHERE user_id = $1 ORDER BY updated_at DESC`
+	res, err := storage.Select[DeviceMetadata](ctx, r.db, sql, userID)
+
+	return res, errors.Wrapf(err, "failed to select all device metadata for userID:%v", userID)
+}
+
 func (r *repository) ReplaceDeviceMetadata(ctx context.Context, input *DeviceMetadata, clientIP net.IP) (err error) { //nolint:funlen // Big rollback logic.
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "context failed")
 	}
+	geoIPRecord, geoIPErr := r.lookupGeoIP(clientIP)
+	if geoIPErr == nil {
+		input.ip2LocationRecord = ip2LocationRecord(*geoIPRecord)
+	}
 	if vErr := r.verifyDeviceAppVersion(input); vErr != nil {
 		return vErr
 	}
+	input.AppVersionStatus = r.appVersionStatus(input)
 	if input.UserID == "" || input.UserID == "-" {
 		return nil
 	}
 	input.UpdatedAt = time.Now()
-	var ip2locationRecord ip2location.IP2Locationrecord
-	if ip2locationRecord, err = r.ip2LocationDB.Get_all(clientIP.String()); err != nil { //nolint:nosnakecase // External library.
-		return errors.Wrapf(err, "failed to get location information based on IP %v to replace device metadata", clientIP.String())
+	if geoIPErr != nil {
+		return errors.Wrapf(geoIPErr, "failed to get location information based on IP %v to replace device metadata", clientIP.String())
+	}
+	ipRisk, err := r.assessIPRisk(DeviceMetadataIPRiskRoute, &input.ID, clientIP, geoIPRecord)
+	if err != nil {
+		return multierror.Append( //nolint:wrapcheck // .
+			errors.Wrapf(err, "the ip risk of %v doesn't allow replacing device metadata", clientIP.String()),
+			errors.Wrapf(r.RecordIPRisk(ctx, ipRisk), "failed to record the ip risk of %v", clientIP.String()),
+		).ErrorOrNil()
 	}
-	(&input.ip2LocationRecord).convertIP2Location(&ip2locationRecord)
 	before, err := r.GetDeviceMetadata(ctx, &input.ID)
 	if err != nil && !storage.IsErr(err, storage.ErrNotFound) {
 		return errors.Wrapf(err, "failed to get current device metadata for %#v", input.ID)
@@ -191,37 +242,56 @@ func (r *repository) ReplaceDeviceMetadata(ctx context.Context, input *DeviceMet
 
 		return multierror.Append(errors.Wrapf(err, "failed to send device metadata snapshot message %#v", dm), revertErr).ErrorOrNil() //nolint:wrapcheck // .
 	}
+	if err = r.detectDeviceRisk(ctx, input); err != nil {
+		log.Error(errors.Wrapf(err, "failed to detect device risk for %#v", input.ID))
+	}
+	if err = r.RecordIPRisk(ctx, ipRisk); err != nil {
+		log.Error(errors.Wrapf(err, "failed to record ip risk for %#v", input.ID))
+	}
 
 	return nil
 }
 
+func (r *repository) lookupGeoIP(clientIP net.IP) (*GeoIPRecord, error) {
+	if r.geoIP == nil {
+		return nil, errors.New("no geoip database")
+	}
+
+	return r.geoIP.Lookup(clientIP) //nolint:wrapcheck // It's just a proxy.
+}
+
 func (r *repository) verifyDeviceAppVersion(metadata *DeviceMetadata) error {
 	readableParts := strings.Split(metadata.ReadableVersion, ".")
 	if len(readableParts) < 1+1+1 {
 		return errors.Wrapf(ErrInvalidAppVersion, "invalid version %v", metadata.ReadableVersion)
 	}
-	var requiredAppVersion string
-	if os := strings.ReplaceAll(strings.ToLower(metadata.SystemName), " ", ""); os == "android" {
-		requiredAppVersion = r.cfg.RequiredAppVersion.Android
-	} else if os == "ios" || os == "iphoneos" || os == "ipados" {
-		requiredAppVersion = r.cfg.RequiredAppVersion.IOS
-	} else {
-		requiredAppVersion = r.cfg.RequiredAppVersion.Android
+	versions := r.appVersions(metadata)
+	if versions.isBlocked(metadata.ReadableVersion) {
+		return errors.Wrapf(ErrOutdatedAppVersion, "mobile app version %v is blocked, please update", metadata.ReadableVersion)
+	}
+
+	return r.verifyMinimumAppVersion(metadata.ReadableVersion, versions.Required)
+}
+
+func (r *repository) verifyMinimumAppVersion(readableVersion, requiredAppVersion string) error {
+	readableParts := strings.Split(readableVersion, ".")
+	if len(readableParts) < 1+1+1 {
+		return errors.Wrapf(ErrInvalidAppVersion, "invalid version %v", readableVersion)
 	}
 	if semver.Compare(strings.ReplaceAll(fmt.Sprintf("v%v.%v.%v", readableParts[0], readableParts[1], readableParts[2]), "vv", "v"), requiredAppVersion) < 0 {
 		return errors.Wrapf(ErrOutdatedAppVersion,
-			"mobile app version %v is older than the required one %v, please update", metadata.ReadableVersion, requiredAppVersion)
+			"mobile app version %v is older than the required one %v, please update", readableVersion, requiredAppVersion)
 	}
 
 	return errors.Wrapf(r.verifyDeviceAppNanosVersion(requiredAppVersion, readableParts),
-		"mobile app version %v is older than the required one %v, please update", metadata.ReadableVersion, requiredAppVersion)
+		"mobile app version %v is older than the required one %v, please update", readableVersion, requiredAppVersion)
 }
 
 func (r *repository) verifyDeviceAppNanosVersion(requiredAppVersion string, readableParts []string) error {
 	requiredParts := strings.Split(requiredAppVersion, ".")
 	if len(requiredParts) > 1+1+1 && len(readableParts) == 1+1+1 {
 		return errors.Wrapf(ErrOutdatedAppVersion,
-			"mobile app version doesn't contain nanos that is required %v, please update", r.cfg.RequiredAppVersion)
+			"mobile app version doesn't contain nanos that is required %v, please update", requiredAppVersion)
 	}
 	if len(requiredParts) > 1+1+1 && len(readableParts) > 1+1+1 {
 		readableNano, err := strconv.Atoi(readableParts[3])
@@ -521,31 +591,6 @@ func (dm *DeviceMetadata) replaceSQL() (string, []any) {
 	return sql, args
 }
 
-func (rec *ip2LocationRecord) convertIP2Location(ip *ip2location.IP2Locationrecord) {
-	*rec = ip2LocationRecord{
-		CountryShort:       ip.Country_short, //nolint:nosnakecase // 3rd party library.
-		CountryLong:        ip.Country_long,  //nolint:nosnakecase // 3rd party library.
-		Region:             ip.Region,
-		City:               ip.City,
-		Isp:                ip.Isp,
-		Domain:             ip.Domain,
-		Zipcode:            ip.Zipcode,
-		Timezone:           ip.Timezone,
-		Netspeed:           ip.Netspeed,
-		Iddcode:            ip.Iddcode,
-		Areacode:           ip.Areacode,
-		Weatherstationcode: ip.Weatherstationcode,
-		Weatherstationname: ip.Weatherstationname,
-		Mcc:                ip.Mcc,
-		Mnc:                ip.Mnc,
-		Mobilebrand:        ip.Mobilebrand,
-		Usagetype:          ip.Usagetype,
-		Elevation:          float64(ip.Elevation),
-		Latitude:           float64(ip.Latitude),
-		Longitude:          float64(ip.Longitude),
-	}
-}
-
 func deviceMetadataSnapshot(before, after *DeviceMetadata) *DeviceMetadataSnapshot {
 	var before2, after2 *DeviceMetadata
 	if before != nil {
@@ -630,6 +675,28 @@ func (*repository) LookupCountries(keyword Keyword) []Country {
 	return matchingCountries
 }
 
+// NormalizeCity collapses the whitespace and capitalizes every word of the city name,
+// so the same city is always stored the same way, no matter if it comes from ip2location or from the client.
+func NormalizeCity(city City) City {
+	words := strings.Fields(city)
+	if len(words) == 0 || (len(words) == 1 && words[0] == "-") { // Ip2location uses `-` for unknown.
+		return ""
+	}
+	for ix, word := range words {
+		runes := []rune(strings.ToLower(word))
+		capitalize := true
+		for jx, char := range runes {
+			if capitalize {
+				runes[jx] = unicode.ToTitle(char)
+			}
+			capitalize = char == '-'
+		}
+		words[ix] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
+
 func (*repository) IsValid(c Country) bool {
 	_, found := countries[strings.ToUpper(c)]
 
@@ -3,9 +3,15 @@
 package devicemetadata
 
 import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	stdlibtime "time"
 
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -314,3 +320,249 @@ func TestVerifyDeviceAppVersion(t *testing.T) { //nolint:funlen // .
 	md.SystemName = "iOS"
 	require.Error(t, repo.verifyDeviceAppVersion(&md))
 }
+
+func TestDeviceRiskReasons(t *testing.T) {
+	t.Parallel()
+	repo := &repository{cfg: &config{}}
+	repo.cfg.DeviceRisk.MaxAccountsPerFingerprint = 3
+	repo.cfg.DeviceRisk.MaxAccountsPerDevice = 2
+
+	dm := &DeviceMetadata{Emulator: true}
+	assert.Empty(t, repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 3, DeviceAccounts: 2}))
+	assert.Equal(t, []DeviceRiskReason{SharedFingerprintDeviceRiskReason},
+		repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 4, DeviceAccounts: 2}))
+	assert.Equal(t, []DeviceRiskReason{SharedDeviceDeviceRiskReason, SharedFingerprintDeviceRiskReason},
+		repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 4, DeviceAccounts: 3}))
+
+	repo.cfg.DeviceRisk.FlagEmulators = true
+	assert.Equal(t, []DeviceRiskReason{EmulatorDeviceRiskReason},
+		repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 1, DeviceAccounts: 1}))
+	dm.Emulator = false
+	assert.Empty(t, repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 1, DeviceAccounts: 1}))
+
+	repo.cfg.DeviceRisk.MaxAccountsPerFingerprint, repo.cfg.DeviceRisk.MaxAccountsPerDevice = 0, 0
+	assert.Empty(t, repo.deviceRiskReasons(dm, &DeviceRisk{FingerprintAccounts: 100, DeviceAccounts: 100}))
+}
+
+func TestDeviceRiskBlocksKYC(t *testing.T) {
+	t.Parallel()
+	repo := &repository{cfg: &config{}}
+
+	assert.False(t, repo.blocksKYC(&DeviceRisk{Reasons: []DeviceRiskReason{SharedDeviceDeviceRiskReason}}))
+	repo.cfg.DeviceRisk.BlockKYCReasons = []DeviceRiskReason{SharedDeviceDeviceRiskReason, SharedFingerprintDeviceRiskReason}
+	assert.False(t, repo.blocksKYC(&DeviceRisk{Reasons: make([]DeviceRiskReason, 0)}))
+	assert.False(t, repo.blocksKYC(&DeviceRisk{Reasons: []DeviceRiskReason{EmulatorDeviceRiskReason, RiskyIPDeviceRiskReason}}))
+	assert.True(t, repo.blocksKYC(&DeviceRisk{Reasons: []DeviceRiskReason{EmulatorDeviceRiskReason, SharedDeviceDeviceRiskReason}}))
+	assert.True(t, repo.blocksKYC(&DeviceRisk{Reasons: []DeviceRiskReason{SharedFingerprintDeviceRiskReason}}))
+}
+
+func TestFallbackGeoIPProviderLookup(t *testing.T) {
+	t.Parallel()
+	var (
+		ip          = net.ParseIP("1.1.1.1")
+		noCity      = &mockGeoIPProvider{rec: &GeoIPRecord{CountryShort: "US", City: "-", Isp: "isp", Timezone: "-05:00"}}
+		otherCity   = &mockGeoIPProvider{rec: &GeoIPRecord{CountryShort: "CA", City: "Toronto"}}
+		sameCity    = &mockGeoIPProvider{rec: &GeoIPRecord{CountryShort: "us", City: "New York", Region: "NY", Latitude: 1, Longitude: 2}}
+		failing     = &mockGeoIPProvider{err: errors.New("oops")}
+		unreachable = &mockGeoIPProvider{err: errors.New("should not be called")}
+	)
+	rec, err := fallbackGeoIPProvider{failing, noCity, otherCity, sameCity, unreachable}.Lookup(ip)
+	require.NoError(t, err)
+	assert.Equal(t, &GeoIPRecord{CountryShort: "us", City: "New York", Region: "NY", Latitude: 1, Longitude: 2, Isp: "isp", Timezone: "-05:00"}, rec)
+
+	rec, err = fallbackGeoIPProvider{noCity, otherCity}.Lookup(ip)
+	require.NoError(t, err)
+	assert.Equal(t, "-", rec.City)
+
+	_, err = fallbackGeoIPProvider{failing, failing}.Lookup(ip)
+	require.Error(t, err)
+}
+
+func TestWatchedGeoIPProviderReload(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "db.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("US"), 0o600))
+	var opened []*mockGeoIPProvider
+	open := func(p string) (GeoIPProvider, error) {
+		content, err := os.ReadFile(p)
+		if err != nil || string(content) == "broken" {
+			return nil, errors.New("invalid database")
+		}
+		provider := &mockGeoIPProvider{rec: &GeoIPRecord{CountryShort: string(content)}}
+		opened = append(opened, provider)
+
+		return provider, nil
+	}
+	provider, err := newWatchedGeoIPProvider(path, open, 10*stdlibtime.Millisecond)
+	require.NoError(t, err)
+	lookupCountry := func() string {
+		rec, lErr := provider.Lookup(nil)
+		require.NoError(t, lErr)
+
+		return rec.CountryShort
+	}
+	assert.Equal(t, "US", lookupCountry())
+
+	require.NoError(t, os.WriteFile(path, []byte("CA"), 0o600))
+	require.Eventually(t, func() bool { return lookupCountry() == "CA" }, 5*stdlibtime.Second, 10*stdlibtime.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte("broken"), 0o600))
+	stdlibtime.Sleep(100 * stdlibtime.Millisecond)
+	assert.Equal(t, "CA", lookupCountry())
+
+	require.NoError(t, provider.Close())
+	require.Len(t, opened, 2)
+	assert.True(t, opened[0].closed)
+	assert.True(t, opened[1].closed)
+}
+
+type mockGeoIPProvider struct {
+	rec    *GeoIPRecord
+	err    error
+	closed bool
+}
+
+func (m *mockGeoIPProvider) Lookup(net.IP) (*GeoIPRecord, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	rec := *m.rec
+
+	return &rec, nil
+}
+
+func (m *mockGeoIPProvider) Close() error {
+	m.closed = true
+
+	return nil
+}
+
+func TestClassifyIP(t *testing.T) {
+	t.Parallel()
+	denylist, err := parseIPDenylist([]string{"10.0.0.0/8", "2.2.2.2", "2001:db8::/32"})
+	require.NoError(t, err)
+	repo := &repository{cfg: &config{}, ipDenylist: denylist}
+
+	assert.Equal(t, VPNProxyIPClassification, repo.classifyIP(net.ParseIP("10.1.2.3"), &GeoIPRecord{Usagetype: "MOB"}))
+	assert.Equal(t, VPNProxyIPClassification, repo.classifyIP(net.ParseIP("2.2.2.2"), nil))
+	assert.Equal(t, VPNProxyIPClassification, repo.classifyIP(net.ParseIP("2001:db8::1"), nil))
+	assert.Equal(t, UnknownIPClassification, repo.classifyIP(net.ParseIP("2.2.2.3"), nil))
+	assert.Equal(t, UnknownIPClassification, repo.classifyIP(net.ParseIP("2.2.2.3"), &GeoIPRecord{Usagetype: "-"}))
+	assert.Equal(t, DataCenterIPClassification, repo.classifyIP(net.ParseIP("1.1.1.1"), &GeoIPRecord{Usagetype: "DCH"}))
+	assert.Equal(t, DataCenterIPClassification, repo.classifyIP(net.ParseIP("1.1.1.1"), &GeoIPRecord{Usagetype: "CDN/MOB"}))
+	assert.Equal(t, MobileIPClassification, repo.classifyIP(net.ParseIP("1.1.1.1"), &GeoIPRecord{Usagetype: "ISP/MOB"}))
+	assert.Equal(t, ResidentialIPClassification, repo.classifyIP(net.ParseIP("1.1.1.1"), &GeoIPRecord{Usagetype: "ISP"}))
+	assert.Equal(t, ResidentialIPClassification, repo.classifyIP(net.ParseIP("1.1.1.1"), &GeoIPRecord{Usagetype: "com"}))
+
+	_, err = parseIPDenylist([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = parseIPDenylist([]string{"bogus"})
+	require.Error(t, err)
+}
+
+func TestIPRiskAction(t *testing.T) {
+	t.Parallel()
+	cfg := new(config)
+	cfg.IPRisk.Policies = map[IPRiskRoute]map[IPClassification]IPRiskAction{
+		"signup":         {"vpnproxy": "Reject", "datacenter": "challenge"},
+		"deviceMetadata": {"vpnProxy": "challenge"},
+	}
+	require.NoError(t, cfg.validateIPRisk())
+
+	assert.Equal(t, RejectIPRiskAction, cfg.ipRiskAction(SignUpIPRiskRoute, VPNProxyIPClassification))
+	assert.Equal(t, ChallengeIPRiskAction, cfg.ipRiskAction(SignUpIPRiskRoute, DataCenterIPClassification))
+	assert.Equal(t, AllowIPRiskAction, cfg.ipRiskAction(SignUpIPRiskRoute, MobileIPClassification))
+	assert.Equal(t, ChallengeIPRiskAction, cfg.ipRiskAction(DeviceMetadataIPRiskRoute, VPNProxyIPClassification))
+	assert.Equal(t, AllowIPRiskAction, cfg.ipRiskAction(SignInIPRiskRoute, VPNProxyIPClassification))
+
+	cfg.IPRisk.Policies[SignInIPRiskRoute] = map[IPClassification]IPRiskAction{VPNProxyIPClassification: "block"}
+	require.Error(t, cfg.validateIPRisk())
+}
+
+func TestAssessIPRisk(t *testing.T) {
+	t.Parallel()
+	repo := &repository{cfg: &config{}}
+	repo.cfg.IPRisk.Policies = map[IPRiskRoute]map[IPClassification]IPRiskAction{
+		SignUpIPRiskRoute: {VPNProxyIPClassification: RejectIPRiskAction, DataCenterIPClassification: ChallengeIPRiskAction},
+	}
+	id := &device.ID{UserID: "bogus", DeviceUniqueID: "device"}
+	ip := net.ParseIP("1.1.1.1")
+
+	assessment, err := repo.assessIPRisk(SignUpIPRiskRoute, id, ip, &GeoIPRecord{Usagetype: "ISP"})
+	require.NoError(t, err)
+	assert.Equal(t, AllowIPRiskAction, assessment.Action)
+	assert.False(t, assessment.isRecorded())
+
+	assessment, err = repo.assessIPRisk(SignUpIPRiskRoute, id, ip, &GeoIPRecord{Usagetype: "DCH"})
+	require.NoError(t, err)
+	assert.Equal(t, ChallengeIPRiskAction, assessment.Action)
+	assert.Equal(t, "bogus", assessment.UserID)
+	assert.Equal(t, "device", assessment.DeviceUniqueID)
+	assert.Equal(t, "1.1.1.1", assessment.ClientIP)
+	assert.Equal(t, "DCH", assessment.UsageType)
+	assert.True(t, assessment.isRecorded())
+
+	repo.ipDenylist, err = parseIPDenylist([]string{"1.1.1.1"})
+	require.NoError(t, err)
+	assessment, err = repo.assessIPRisk(SignUpIPRiskRoute, id, ip, nil)
+	require.ErrorIs(t, err, ErrIPRejected)
+	assert.Equal(t, VPNProxyIPClassification, assessment.Classification)
+	assert.Equal(t, RejectIPRiskAction, assessment.Action)
+	assert.True(t, assessment.isRecorded())
+
+	assessment, err = repo.assessIPRisk(SignInIPRiskRoute, id, ip, nil)
+	require.NoError(t, err)
+	assert.False(t, assessment.isRecorded())
+}
+
+func TestRemoteAppVersionConfig(t *testing.T) { //nolint:funlen // .
+	t.Parallel()
+	repo := repository{cfg: new(config)}
+	repo.cfg.RequiredAppVersion.Android, repo.cfg.RequiredAppVersion.IOS = "v1.0.0", "v1.0.1"
+	android := &DeviceMetadata{SystemName: "Android", ReadableVersion: "1.0.0.5"}
+	ios := &DeviceMetadata{SystemName: "iOS", ReadableVersion: "1.0.0"}
+	require.NoError(t, repo.verifyDeviceAppVersion(android))
+	require.ErrorIs(t, repo.verifyDeviceAppVersion(ios), ErrOutdatedAppVersion)
+	assert.Equal(t, &AppVersionStatus{}, repo.appVersionStatus(android))
+
+	var remoteConfig appVersionConfigJSON
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"android": {"required": "v1.0.0", "recommended": "v1.1.0", "blockedBuilds": ["v1.0.0.5"]},
+		"ios": {"required": "v0.9.0"},
+		"countries": {
+			"US": {"android": {"required": "v1.0.1"}},
+			"RO": {"android": {"recommended": "v1.0.0.3", "blockedBuilds": ["1.0.0.4"]}}
+		}
+	}`), &remoteConfig))
+	require.NoError(t, remoteConfig.validate())
+	repo.cfg.appVersionConfigJSON = new(atomic.Pointer[appVersionConfigJSON])
+	repo.cfg.appVersionConfigJSON.Store(&remoteConfig)
+
+	require.ErrorIs(t, repo.verifyDeviceAppVersion(android), ErrOutdatedAppVersion)
+	android.ReadableVersion = "1.0.0.6"
+	require.NoError(t, repo.verifyDeviceAppVersion(android))
+	require.NoError(t, repo.verifyDeviceAppVersion(ios))
+	assert.Equal(t, &AppVersionStatus{RecommendedAppVersion: "v1.1.0", UpdateRecommended: true}, repo.appVersionStatus(android))
+	assert.Equal(t, &AppVersionStatus{}, repo.appVersionStatus(ios))
+
+	android.CountryShort = "us"
+	require.ErrorIs(t, repo.verifyDeviceAppVersion(android), ErrOutdatedAppVersion)
+	android.ReadableVersion = "1.1.0"
+	assert.Equal(t, &AppVersionStatus{RecommendedAppVersion: "v1.1.0"}, repo.appVersionStatus(android))
+
+	android.CountryShort, android.ReadableVersion = "RO", "1.0.0.4"
+	require.ErrorIs(t, repo.verifyDeviceAppVersion(android), ErrOutdatedAppVersion)
+	android.ReadableVersion = "1.0.0.6"
+	require.NoError(t, repo.verifyDeviceAppVersion(android))
+	assert.Equal(t, &AppVersionStatus{RecommendedAppVersion: "v1.0.0.3"}, repo.appVersionStatus(android))
+
+	for _, invalid := range []string{
+		`{"android": {"required": "1.0.0"}}`,
+		`{"ios": {"recommended": "v1.0"}}`,
+		`{"countries": {"US": {"ios": {"required": "v1.0.0.x"}}}}`,
+	} {
+		var cfg appVersionConfigJSON
+		require.NoError(t, json.Unmarshal([]byte(invalid), &cfg))
+		require.Error(t, cfg.validate(), invalid)
+	}
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devicemetadata
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/goccy/go-json"
+	"github.com/imroc/req/v3"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+
+	"github.com/ice-blockchain/wintr/log"
+)
+
+func (r *repository) startAppVersionConfigJSONSyncer(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(stdlibtime.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reqCtx, cancel := context.WithTimeout(ctx, requestDeadline)
+			log.Error(errors.Wrap(r.syncAppVersionConfigJSON(reqCtx), "failed to syncAppVersionConfigJSON"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//nolint:gomnd // .
+func (r *repository) syncAppVersionConfigJSON(ctx context.Context) error {
+	if resp, err := req.
+		SetContext(ctx).
+		SetRetryCount(25).
+		SetRetryBackoffInterval(10*stdlibtime.Millisecond, 1*stdlibtime.Second).
+		SetRetryHook(func(resp *req.Response, err error) {
+			if err != nil {
+				log.Error(errors.Wrap(err, "failed to fetch AppVersionConfigJSON, retrying...")) //nolint:revive // .
+			} else {
+				log.Error(errors.Errorf("failed to fetch AppVersionConfigJSON with status code:%v, retrying...", resp.GetStatusCode())) //nolint:revive // .
+			}
+		}).
+		SetRetryCondition(func(resp *req.Response, err error) bool {
+			return err != nil || resp.GetStatusCode() != http.StatusOK
+		}).
+		SetHeader("Accept", "application/json").
+		SetHeader("Cache-Control", "no-cache, no-store, must-revalidate").
+		SetHeader("Pragma", "no-cache").
+		SetHeader("Expires", "0").
+		Get(r.cfg.AppVersionConfigJSONURL); err != nil {
+		return errors.Wrapf(err, "failed to get fetch `%v`", r.cfg.AppVersionConfigJSONURL)
+	} else if data, err2 := resp.ToBytes(); err2 != nil {
+		return errors.Wrapf(err2, "failed to read body of `%v`", r.cfg.AppVersionConfigJSONURL)
+	} else { //nolint:revive // .
+		var appVersionConfig appVersionConfigJSON
+		if err = json.UnmarshalContext(ctx, data, &appVersionConfig); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal into %#v, data: %v", appVersionConfig, string(data))
+		}
+		if err = appVersionConfig.validate(); err != nil {
+			return errors.Wrapf(err, "there's something wrong with the AppVersionConfigJSON body: %v", string(data))
+		}
+		r.cfg.appVersionConfigJSON.Swap(&appVersionConfig)
+
+		return nil
+	}
+}
+
+// The remote versions override the ones from `requiredAppVersion` and the ones of the device's country override the global ones.
+// The blocked builds are cumulated.
+func (r *repository) appVersions(metadata *DeviceMetadata) *appVersions {
+	isIOS := isIOSDevice(metadata)
+	versions := &appVersions{Required: r.cfg.RequiredAppVersion.Android}
+	if isIOS {
+		versions.Required = r.cfg.RequiredAppVersion.IOS
+	}
+	if r.cfg.appVersionConfigJSON == nil {
+		return versions
+	}
+	remoteConfig := r.cfg.appVersionConfigJSON.Load()
+	if remoteConfig == nil {
+		return versions
+	}
+	versions.merge(remoteConfig.platformAppVersions.forPlatform(isIOS))
+	for country, countryAppVersions := range remoteConfig.Countries {
+		if countryAppVersions != nil && metadata.CountryShort != "" && strings.EqualFold(country, metadata.CountryShort) {
+			versions.merge(countryAppVersions.forPlatform(isIOS))
+		}
+	}
+
+	return versions
+}
+
+func (r *repository) appVersionStatus(metadata *DeviceMetadata) *AppVersionStatus {
+	status := new(AppVersionStatus)
+	if recommended := r.appVersions(metadata).Recommended; recommended != "" {
+		status.RecommendedAppVersion = recommended
+		status.UpdateRecommended = r.verifyMinimumAppVersion(metadata.ReadableVersion, recommended) != nil
+	}
+
+	return status
+}
+
+func isIOSDevice(metadata *DeviceMetadata) bool {
+	os := strings.ReplaceAll(strings.ToLower(metadata.SystemName), " ", "")
+
+	return os == "ios" || os == "iphoneos" || os == "ipados"
+}
+
+func (p *platformAppVersions) forPlatform(isIOS bool) *appVersions {
+	if isIOS {
+		return p.IOS
+	}
+
+	return p.Android
+}
+
+func (v *appVersions) merge(other *appVersions) {
+	if other == nil {
+		return
+	}
+	if other.Required != "" {
+		v.Required = other.Required
+	}
+	if other.Recommended != "" {
+		v.Recommended = other.Recommended
+	}
+	v.BlockedBuilds = append(v.BlockedBuilds, other.BlockedBuilds...)
+}
+
+func (v *appVersions) isBlocked(readableVersion string) bool {
+	for _, blockedBuild := range v.BlockedBuilds {
+		if strings.EqualFold(strings.TrimPrefix(blockedBuild, "v"), strings.TrimPrefix(readableVersion, "v")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// It makes sure that the versions can be compared, otherwise a broken remote config would fail every device metadata update.
+func (c *appVersionConfigJSON) validate() error {
+	platforms := make(map[string]*platformAppVersions, len(c.Countries)+1)
+	platforms["global"] = &c.platformAppVersions
+	for country, countryAppVersions := range c.Countries {
+		if countryAppVersions != nil {
+			platforms[country] = countryAppVersions
+		}
+	}
+	for key, platform := range platforms {
+		for _, versions := range []*appVersions{platform.Android, platform.IOS} {
+			if versions == nil {
+				continue
+			}
+			for _, version := range []string{versions.Required, versions.Recommended} {
+				if version != "" && !isValidAppVersion(version) {
+					return errors.Errorf("invalid app version `%v` for %v", version, key)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func isValidAppVersion(version string) bool {
+	parts := strings.Split(version, ".")
+	if len(parts) < 1+1+1 || len(parts) > 1+1+1+1 || !strings.HasPrefix(version, "v") {
+		return false
+	}
+	if len(parts) > 1+1+1 {
+		if _, err := strconv.Atoi(parts[3]); err != nil {
+			return false
+		}
+	}
+
+	return semver.IsValid(strings.Join(parts[:3], "."))
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package devicemetadata
+
+import (
+	"context"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func (r *repository) GetDeviceRisk(ctx context.Context, userID string) (*DeviceRisk, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context failed")
+	}
+	sql := `SELECT * FROM device_risk_flags WHERE user_id = $1`
+	risk, err := storage.Get[DeviceRisk](ctx, r.db, sql, userID)
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			return &DeviceRisk{UserID: userID, Reasons: make([]DeviceRiskReason, 0)}, nil
+		}
+
+		return nil, errors.Wrapf(err, "failed to get device risk for userID:%v", userID)
+	}
+
+	return risk, nil
+}
+
+func (r *repository) IsKYCBlockedByDeviceRisk(ctx context.Context, userID string) (bool, error) {
+	if len(r.cfg.DeviceRisk.BlockKYCReasons) == 0 {
+		return false, nil
+	}
+	risk, err := r.GetDeviceRisk(ctx, userID)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get device risk for userID:%v", userID)
+	}
+
+	return r.blocksKYC(risk), nil
+}
+
+func (r *repository) blocksKYC(risk *DeviceRisk) bool {
+	for _, reason := range risk.Reasons {
+		if slices.Contains(r.cfg.DeviceRisk.BlockKYCReasons, reason) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// It links the account to the fingerprint and to the device of the metadata, then it flags the account
+// if too many accounts share any of them or if it runs on an emulator.
+// The other accounts sharing them are flagged as well, the next time they replace their device metadata.
+func (r *repository) detectDeviceRisk(ctx context.Context, dm *DeviceMetadata) error {
+	sql := `INSERT INTO device_accounts (kind, value, user_id)
+				SELECT kind, value, $1
+				FROM (VALUES ($2, $3), ($4, $5)) AS x(kind, value)
+				WHERE value != ''
+			ON CONFLICT DO NOTHING`
+	if _, err := storage.Exec(ctx, r.db, sql, dm.UserID, fingerprintDeviceAccountKind, dm.Fingerprint, deviceDeviceAccountKind, dm.DeviceUniqueID); err != nil {
+		return errors.Wrapf(err, "failed to link the device of %#v to its account", &dm.ID)
+	}
+	sql = `SELECT (SELECT count(1) FROM device_accounts WHERE kind = $1 AND value = $2 AND $2 != '') AS fingerprint_accounts,
+				  (SELECT count(1) FROM device_accounts WHERE kind = $3 AND value = $4) 			   AS device_accounts`
+	risk, err := storage.Get[DeviceRisk](ctx, r.db, sql, fingerprintDeviceAccountKind, dm.Fingerprint, deviceDeviceAccountKind, dm.DeviceUniqueID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to count the accounts sharing the device of %#v", &dm.ID)
+	}
+	risk.UserID, risk.DeviceUniqueID, risk.FlaggedAt = dm.UserID, dm.DeviceUniqueID, time.Now()
+	if risk.Reasons = r.deviceRiskReasons(dm, risk); len(risk.Reasons) == 0 {
+		return nil
+	}
+
+	return errors.Wrapf(r.flagDeviceRisk(ctx, risk), "failed to flag %#v", risk)
+}
+
+// It merges the reasons with the ones the account was already flagged for and sends the flag only if something changed.
+func (r *repository) flagDeviceRisk(ctx context.Context, risk *DeviceRisk) error {
+	sql := `INSERT INTO device_risk_flags (flagged_at, user_id, device_unique_id, reasons, fingerprint_accounts, device_accounts)
+		   VALUES ($1, $2, $3, $4, $5, $6)
+		   ON CONFLICT (user_id)
+				DO UPDATE
+					SET flagged_at 			 = EXCLUDED.flagged_at,
+						device_unique_id 	 = EXCLUDED.device_unique_id,
+						reasons 			 = ARRAY(SELECT DISTINCT unnest(device_risk_flags.reasons || EXCLUDED.reasons) ORDER BY 1),
+						fingerprint_accounts = GREATEST(device_risk_flags.fingerprint_accounts, EXCLUDED.fingerprint_accounts),
+						device_accounts 	 = GREATEST(device_risk_flags.device_accounts, EXCLUDED.device_accounts)
+				WHERE NOT (device_risk_flags.reasons @> EXCLUDED.reasons)
+					  OR device_risk_flags.fingerprint_accounts < EXCLUDED.fingerprint_accounts
+					  OR device_risk_flags.device_accounts < EXCLUDED.device_accounts
+		   RETURNING *`
+	flag, err := storage.ExecOne[DeviceRisk](ctx, r.db, sql,
+		risk.FlaggedAt.Time, risk.UserID, risk.DeviceUniqueID, risk.Reasons, risk.FingerprintAccounts, risk.DeviceAccounts)
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			return nil
+		}
+
+		return errors.Wrap(err, "failed to upsert device risk flag")
+	}
+
+	return errors.Wrapf(r.sendDeviceRiskMessage(ctx, flag), "failed to send device risk message for %#v", flag)
+}
+
+func (r *repository) deviceRiskReasons(dm *DeviceMetadata, risk *DeviceRisk) []DeviceRiskReason {
+	reasons := make([]DeviceRiskReason, 0, 1+1+1)
+	if limit := r.cfg.DeviceRisk.MaxAccountsPerFingerprint; limit > 0 && risk.FingerprintAccounts > limit {
+		reasons = append(reasons, SharedFingerprintDeviceRiskReason)
+	}
+	if limit := r.cfg.DeviceRisk.MaxAccountsPerDevice; limit > 0 && risk.DeviceAccounts > limit {
+		reasons = append(reasons, SharedDeviceDeviceRiskReason)
+	}
+	if r.cfg.DeviceRisk.FlagEmulators && dm.Emulator {
+		reasons = append(reasons, EmulatorDeviceRiskReason)
+	}
+	slices.Sort(reasons)
+
+	return reasons
+}
+
+func (r *repository) sendDeviceRiskMessage(ctx context.Context, risk *DeviceRisk) error {
+	valueBytes, err := json.MarshalContext(ctx, risk)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal DeviceRisk %#v", risk)
+	}
+	msg := &messagebroker.Message{
+		Headers: map[string]string{"producer": "eskimo"},
+		Key:     risk.UserID,
+		Topic:   r.cfg.MessageBroker.Topics[6].Name,
+		Value:   valueBytes,
+	}
+	responder := make(chan error, 1)
+	defer close(responder)
+	r.mb.SendMessage(ctx, msg, responder)
+
+	return errors.Wrapf(<-responder, "failed to send device risk message to broker")
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package outbox
+
+import (
+	"context"
+
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+// Public API.
+
+type (
+	// Message is a message broker message that was stored in the same transaction as the change it describes.
+	Message struct {
+		Topic string
+		Key   string
+		Value []byte
+		ID    int64
+	}
+)
+
+// Private API.
+
+const (
+	producer = "eskimo"
+
+	// It locks the oldest message of every topic and key, skipping the ones already locked by other relays.
+	claimSQL = `SELECT id, topic, key, value
+				FROM users_outbox o
+				WHERE NOT EXISTS (SELECT 1
+								  FROM users_outbox prev
+								  WHERE prev.topic = o.topic
+									AND prev.key = o.key
+									AND prev.id < o.id)
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED`
+)
+
+type (
+	// The users_outbox table, as seen from the transaction of a relay.
+	store interface {
+		claim(ctx context.Context, limit uint64) ([]*Message, error)
+		delete(ctx context.Context, ids []int64) error
+	}
+	table struct {
+		conn storage.QueryExecer
+	}
+)
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package outbox
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func Enqueue(ctx context.Context, conn storage.Execer, topic, key string, value []byte) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	sql := `INSERT INTO users_outbox (created_at, topic, key, value) VALUES ($1, $2, $3, $4)`
+	if _, err := storage.Exec(ctx, conn, sql, time.Now().Time, topic, key, value); err != nil {
+		return errors.Wrapf(err, "failed to insert outbox message for topic:%v, key:%v", topic, key)
+	}
+
+	return nil
+}
+
+// Relay sends, at most, one message per topic and key: the oldest one. The locked ones are skipped, so multiple relays can run
+// at the same time without waiting for each other, and, because the next message of a key is picked only after
+// the previous one was deleted, messages with the same key never overtake each other.
+// They're deleted only after the broker acknowledged them, so delivery is at least once.
+func Relay(ctx context.Context, db *storage.DB, mb messagebroker.Client, batchSize uint64) (relayed int, err error) {
+	if ctx.Err() != nil {
+		return 0, errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	err = storage.DoInTransaction(ctx, db, func(conn storage.QueryExecer) error {
+		var rErr error
+		relayed, rErr = relay(ctx, &table{conn: conn}, mb, batchSize)
+
+		return rErr
+	})
+
+	return relayed, errors.Wrap(err, "failed to relay outbox messages")
+}
+
+// If none of the claimed messages could be sent, it fails, so that the transaction is rolled back and their locks released.
+func relay(ctx context.Context, s store, mb messagebroker.Client, batchSize uint64) (int, error) {
+	messages, err := s.claim(ctx, batchSize)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to claim outbox messages")
+	}
+	relayedIDs, sErr := send(ctx, mb, messages)
+	if len(relayedIDs) == 0 {
+		return 0, sErr
+	}
+	if dErr := s.delete(ctx, relayedIDs); dErr != nil {
+		return 0, errors.Wrapf(dErr, "failed to delete relayed outbox messages:%v", relayedIDs)
+	}
+	log.Error(sErr)
+
+	return len(relayedIDs), nil
+}
+
+func (t *table) claim(ctx context.Context, limit uint64) ([]*Message, error) {
+	messages, err := storage.Select[Message](ctx, t.conn, claimSQL, limit)
+
+	return messages, errors.Wrap(err, "failed to select outbox messages")
+}
+
+func (t *table) delete(ctx context.Context, ids []int64) error {
+	_, err := storage.Exec(ctx, t.conn, `DELETE FROM users_outbox WHERE id = ANY($1)`, ids)
+
+	return errors.Wrap(err, "failed to delete outbox messages")
+}
+
+// It stops at the first failure and returns the IDs of the messages that were sent before it.
+func send(ctx context.Context, mb messagebroker.Client, messages []*Message) ([]int64, error) {
+	sentIDs := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		responder := make(chan error, 1)
+		mb.SendMessage(ctx, &messagebroker.Message{
+			Headers: map[string]string{"producer": producer},
+			Key:     msg.Key,
+			Topic:   msg.Topic,
+			Value:   msg.Value,
+		}, responder)
+		if err := <-responder; err != nil {
+			return sentIDs, errors.Wrapf(err, "failed to send outbox message:%v to broker", msg.ID)
+		}
+		sentIDs = append(sentIDs, msg.ID)
+	}
+
+	return sentIDs, nil
+}
@@ -0,0 +1,293 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package outbox
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+)
+
+type (
+	mockedBroker struct {
+		failures map[string]error
+		onSend   func(msg *messagebroker.Message)
+		sent     []*messagebroker.Message
+	}
+	// It mimics users_outbox: the rows and their locks are shared by all the transactions.
+	mockedTable struct {
+		locks map[int64]*mockedTx
+		rows  []*Message
+	}
+	mockedTx struct {
+		table     *mockedTable
+		deleteErr error
+		deleted   []int64
+	}
+)
+
+func (m *mockedBroker) SendMessage(_ context.Context, msg *messagebroker.Message, responder chan<- error) {
+	if m.onSend != nil {
+		m.onSend(msg)
+	}
+	if err := m.failures[string(msg.Value)]; err != nil {
+		responder <- err
+
+		return
+	}
+	m.sent = append(m.sent, msg)
+	responder <- nil
+}
+
+func (*mockedBroker) Close() error {
+	return nil
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+	messages := []*Message{
+		{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")},
+		{ID: 2, Topic: "t2", Key: "k1", Value: []byte("v2")},
+		{ID: 5, Topic: "t1", Key: "k2", Value: []byte("v3")},
+	}
+	mb := new(mockedBroker)
+	sentIDs, err := send(context.Background(), mb, messages)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 5}, sentIDs)
+	require.Len(t, mb.sent, len(messages))
+	for i, msg := range mb.sent {
+		assert.Equal(t, messages[i].Topic, msg.Topic)
+		assert.Equal(t, messages[i].Key, msg.Key)
+		assert.Equal(t, messages[i].Value, msg.Value)
+		assert.Equal(t, map[string]string{"producer": producer}, msg.Headers)
+	}
+
+	sentIDs, err = send(context.Background(), new(mockedBroker), nil)
+	require.NoError(t, err)
+	assert.Empty(t, sentIDs)
+}
+
+func TestSendStopsAtFirstFailure(t *testing.T) {
+	t.Parallel()
+	messages := []*Message{
+		{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")},
+		{ID: 2, Topic: "t1", Key: "k2", Value: []byte("v2")},
+		{ID: 3, Topic: "t1", Key: "k3", Value: []byte("v3")},
+	}
+	brokerErr := errors.New("broker unavailable")
+	mb := &mockedBroker{failures: map[string]error{"v2": brokerErr}}
+	sentIDs, err := send(context.Background(), mb, messages)
+	require.ErrorIs(t, err, brokerErr)
+	assert.Equal(t, []int64{1}, sentIDs)
+	require.Len(t, mb.sent, 1)
+	assert.Equal(t, "k1", mb.sent[0].Key)
+
+	mb = &mockedBroker{failures: map[string]error{"v1": brokerErr}}
+	sentIDs, err = send(context.Background(), mb, messages)
+	require.ErrorIs(t, err, brokerErr)
+	assert.Empty(t, sentIDs)
+	assert.Empty(t, mb.sent)
+}
+
+func newMockedTable(rows ...*Message) *mockedTable {
+	return &mockedTable{rows: rows, locks: make(map[int64]*mockedTx)}
+}
+
+func (t *mockedTable) begin() *mockedTx {
+	return &mockedTx{table: t}
+}
+
+func (t *mockedTable) values() []string {
+	values := make([]string, 0, len(t.rows))
+	for _, row := range t.rows {
+		values = append(values, string(row.Value))
+	}
+
+	return values
+}
+
+// Like claimSQL: the oldest row of every topic and key, in the order of the ids, unless locked by another transaction.
+func (tx *mockedTx) claim(_ context.Context, limit uint64) ([]*Message, error) {
+	claimed := make([]*Message, 0, limit)
+	for i, row := range tx.table.rows {
+		if uint64(len(claimed)) == limit {
+			break
+		}
+		if slices.ContainsFunc(tx.table.rows[:i], func(prev *Message) bool { return prev.Topic == row.Topic && prev.Key == row.Key }) {
+			continue
+		}
+		if owner, locked := tx.table.locks[row.ID]; locked && owner != tx {
+			continue
+		}
+		tx.table.locks[row.ID] = tx
+		claimed = append(claimed, row)
+	}
+
+	return claimed, nil
+}
+
+func (tx *mockedTx) delete(_ context.Context, ids []int64) error {
+	if tx.deleteErr != nil {
+		return tx.deleteErr
+	}
+	tx.deleted = append(tx.deleted, ids...)
+
+	return nil
+}
+
+func (tx *mockedTx) end(commit bool) {
+	if commit {
+		tx.table.rows = slices.DeleteFunc(tx.table.rows, func(row *Message) bool { return slices.Contains(tx.deleted, row.ID) })
+	}
+	for id, owner := range tx.table.locks {
+		if owner == tx {
+			delete(tx.table.locks, id)
+		}
+	}
+}
+
+// Like Relay: the transaction is committed only if relay succeeds.
+func relayInTx(t *testing.T, table *mockedTable, mb messagebroker.Client, batchSize uint64) (int, error) {
+	t.Helper()
+	tx := table.begin()
+	relayed, err := relay(context.Background(), tx, mb, batchSize)
+	tx.end(err == nil)
+
+	return relayed, err
+}
+
+func TestRelayKeepsTheOrderOfEveryKey(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(
+		&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("k1-1")},
+		&Message{ID: 2, Topic: "t1", Key: "k1", Value: []byte("k1-2")},
+		&Message{ID: 3, Topic: "t1", Key: "k2", Value: []byte("k2-1")},
+		&Message{ID: 4, Topic: "t2", Key: "k1", Value: []byte("t2-k1-1")},
+		&Message{ID: 5, Topic: "t1", Key: "k1", Value: []byte("k1-3")},
+		&Message{ID: 6, Topic: "t1", Key: "k2", Value: []byte("k2-2")},
+	)
+	mb := new(mockedBroker)
+	for _, expected := range [][]string{{"k1-1", "k2-1", "t2-k1-1"}, {"k1-2", "k2-2"}, {"k1-3"}, {}} {
+		relayed, err := relayInTx(t, table, mb, 10)
+		require.NoError(t, err)
+		require.Equal(t, len(expected), relayed)
+		sent := make([]string, 0, len(expected))
+		for _, msg := range mb.sent[len(mb.sent)-relayed:] {
+			sent = append(sent, string(msg.Value))
+		}
+		assert.Equal(t, expected, sent)
+	}
+	assert.Empty(t, table.rows)
+}
+
+func TestRelayClaimsAtMostOneBatch(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(
+		&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")},
+		&Message{ID: 2, Topic: "t1", Key: "k2", Value: []byte("v2")},
+		&Message{ID: 3, Topic: "t1", Key: "k3", Value: []byte("v3")},
+	)
+	mb := new(mockedBroker)
+	relayed, err := relayInTx(t, table, mb, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Equal(t, []string{"v3"}, table.values())
+}
+
+func TestRelaySkipsTheMessagesClaimedByOtherRelays(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(
+		&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("k1-1")},
+		&Message{ID: 2, Topic: "t1", Key: "k1", Value: []byte("k1-2")},
+		&Message{ID: 3, Topic: "t1", Key: "k2", Value: []byte("k2-1")},
+	)
+	other := table.begin()
+	claimed, err := other.claim(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, int64(1), claimed[0].ID)
+
+	// The next message of the key claimed by the other relay must wait for it, instead of overtaking it.
+	mb := new(mockedBroker)
+	relayed, err := relayInTx(t, table, mb, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, relayed)
+	assert.Equal(t, "k2-1", string(mb.sent[0].Value))
+	assert.Equal(t, []string{"k1-1", "k1-2"}, table.values())
+
+	// The other relay failed, so its message is picked up again, still before the next one of its key.
+	other.end(false)
+	relayed, err = relayInTx(t, table, mb, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, relayed)
+	assert.Equal(t, "k1-1", string(mb.sent[1].Value))
+	assert.Equal(t, []string{"k1-2"}, table.values())
+}
+
+func TestRelayDeletesOnlyAcknowledgedMessages(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(
+		&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")},
+		&Message{ID: 2, Topic: "t1", Key: "k2", Value: []byte("v2")},
+		&Message{ID: 3, Topic: "t1", Key: "k3", Value: []byte("v3")},
+	)
+	brokerErr := errors.New("broker unavailable")
+	mb := &mockedBroker{failures: map[string]error{"v2": brokerErr}}
+	mb.onSend = func(msg *messagebroker.Message) {
+		assert.Contains(t, table.values(), string(msg.Value), "deleted before it was sent")
+	}
+	relayed, err := relayInTx(t, table, mb, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, relayed)
+	assert.Equal(t, []string{"v2", "v3"}, table.values())
+	assert.Empty(t, table.locks)
+}
+
+func TestRelayKeepsTheMessagesIfThePublishFails(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(
+		&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")},
+		&Message{ID: 2, Topic: "t1", Key: "k2", Value: []byte("v2")},
+	)
+	brokerErr := errors.New("broker unavailable")
+	relayed, err := relayInTx(t, table, &mockedBroker{failures: map[string]error{"v1": brokerErr}}, 10)
+	require.ErrorIs(t, err, brokerErr)
+	assert.Zero(t, relayed)
+	assert.Equal(t, []string{"v1", "v2"}, table.values())
+	assert.Empty(t, table.locks)
+
+	mb := new(mockedBroker)
+	relayed, err = relayInTx(t, table, mb, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	assert.Empty(t, table.rows)
+}
+
+func TestRelayKeepsTheMessagesIfTheDeleteFails(t *testing.T) {
+	t.Parallel()
+	table := newMockedTable(&Message{ID: 1, Topic: "t1", Key: "k1", Value: []byte("v1")})
+	dbErr := errors.New("db unavailable")
+	tx := table.begin()
+	tx.deleteErr = dbErr
+	relayed, err := relay(context.Background(), tx, new(mockedBroker), 10)
+	tx.end(err == nil)
+	require.ErrorIs(t, err, dbErr)
+	assert.Zero(t, relayed)
+	assert.Equal(t, []string{"v1"}, table.values())
+}
+
+func TestClaimSQL(t *testing.T) {
+	t.Parallel()
+	assert.Contains(t, claimSQL, "prev.topic = o.topic")
+	assert.Contains(t, claimSQL, "prev.key = o.key")
+	assert.Contains(t, claimSQL, "prev.id < o.id")
+	assert.Contains(t, claimSQL, "ORDER BY id")
+	assert.Contains(t, claimSQL, "LIMIT $1")
+	assert.Contains(t, claimSQL, "FOR UPDATE SKIP LOCKED")
+}
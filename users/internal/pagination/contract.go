@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package pagination
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Public API.
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type (
+	// Cursor is the decoded form of the opaque cursor used for keyset pagination.
+	// Every paginated API uses only the fields that identify the last row of its own ordering.
+	Cursor struct {
+		CreatedAt *time.Time `json:"createdAt,omitempty"`
+		ID        string     `json:"id,omitempty"`
+		Username  string     `json:"username,omitempty"`
+		Tier      int64      `json:"tier,omitempty"`
+		Rank      int64      `json:"rank,omitempty"`
+		Score     int64      `json:"score,omitempty"`
+	}
+)
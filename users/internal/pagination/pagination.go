@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+func Encode(cursor *Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode returns nil if there's no cursor, which means the first page was requested.
+func Decode(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil //nolint:nilnil // No cursor means the first page.
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidCursor, "failed to decode cursor:%v, because %v", cursor, err)
+	}
+	var decoded Cursor
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return nil, errors.Wrapf(ErrInvalidCursor, "failed to unmarshal cursor:%v, because %v", cursor, err)
+	}
+
+	return &decoded, nil
+}
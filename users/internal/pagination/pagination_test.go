@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func TestDecodeFirstPage(t *testing.T) {
+	t.Parallel()
+	cursor, err := Decode("")
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+	assert.Empty(t, Encode(nil))
+}
+
+func TestEncodeDecodeNextPage(t *testing.T) {
+	t.Parallel()
+	createdAt := time.New(stdlibtime.UnixMilli(1659737242969).UTC())
+	for _, expected := range []*Cursor{
+		{CreatedAt: createdAt, ID: "a", Rank: 1},
+		{CreatedAt: createdAt, ID: "b", Tier: 2},
+		{Username: "jdoe", Rank: 8, Score: 1500},
+		{Rank: 11},
+	} {
+		encoded := Encode(expected)
+		require.NotEmpty(t, encoded)
+		actual, err := Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, expected.ID, actual.ID)
+		assert.Equal(t, expected.Username, actual.Username)
+		assert.Equal(t, expected.Tier, actual.Tier)
+		assert.Equal(t, expected.Rank, actual.Rank)
+		assert.Equal(t, expected.Score, actual.Score)
+		if expected.CreatedAt == nil {
+			assert.Nil(t, actual.CreatedAt)
+		} else {
+			require.NotNil(t, actual.CreatedAt)
+			assert.True(t, expected.CreatedAt.Equal(*actual.CreatedAt.Time))
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	t.Parallel()
+	for _, invalid := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"rank":"not a number"}`)),
+	} {
+		cursor, err := Decode(invalid)
+		require.ErrorIs(t, err, ErrInvalidCursor, invalid)
+		assert.Nil(t, cursor)
+	}
+}
@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package leaderboard
+
+// Public API.
+
+type (
+	// Board is the ranking of the referrers of a tier, over the last `Days` days, today included, or since forever if 0.
+	Board struct {
+		Period string
+		Tier   string
+		// The column of the referral acquisition history that counts the referrals of the tier.
+		CountColumn string
+		Days        int
+	}
+	// Eligibility restricts the ranked users, aliased as `u`. The joins are applied before the conditions.
+	Eligibility struct {
+		Joins      string
+		Conditions string
+	}
+)
+
+// Private API.
+
+const (
+	rankOrder = `c.referral_count DESC, u.created_at ASC, u.id ASC`
+)
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package leaderboard
+
+import (
+	"fmt"
+	stdlibtime "time"
+)
+
+// FirstDay is the oldest day whose referrals are counted, given the beginning of today.
+func (b *Board) FirstDay(today stdlibtime.Time) stdlibtime.Time {
+	return today.AddDate(0, 0, 1-b.Days)
+}
+
+// IsRetained tells if the per day referral acquisition history keeps enough days to count the referrals of the board.
+func (b *Board) IsRetained(maxDaysHistory uint64) bool {
+	return b.Days <= 0 || uint64(b.Days) <= maxDaysHistory
+}
+
+// InsertSQL ranks the eligible referrers and inserts the best `maxSize` of them, both globally and within each country.
+// The ties are broken in favour of the older account, then of the smaller id, so the ranks are stable between refreshes.
+func (b *Board) InsertSQL(eligibility *Eligibility, maxSize uint64, today stdlibtime.Time) (sql string, args []any) {
+	args = []any{b.Period, b.Tier, maxSize}
+	counts := fmt.Sprintf(`SELECT user_id, %v AS referral_count FROM referral_acquisition_history`, b.CountColumn)
+	if b.Days > 0 {
+		counts = fmt.Sprintf(`SELECT user_id, SUM(%v) AS referral_count
+							  FROM referral_acquisition_history_per_day
+							  WHERE date >= $4
+							  GROUP BY user_id`, b.CountColumn)
+		args = append(args, b.FirstDay(today))
+	}
+	sql = fmt.Sprintf(`
+		INSERT INTO referral_leaderboard (rank, country_rank, referral_count, period, tier, country, user_id)
+			SELECT rank, country_rank, referral_count, $1, $2, country, user_id
+			FROM (SELECT ROW_NUMBER() OVER (ORDER BY %[1]v) 						 AS rank,
+						 ROW_NUMBER() OVER (PARTITION BY u.country ORDER BY %[1]v) AS country_rank,
+						 c.referral_count,
+						 u.country,
+						 u.id 													 AS user_id
+				  FROM (%[2]v) c
+					JOIN users u
+					  ON u.id = c.user_id
+					%[3]v
+				  WHERE c.referral_count > 0
+						AND %[4]v
+				 ) ranked
+			WHERE rank <= $3
+				  OR country_rank <= $3`,
+		rankOrder, counts, eligibility.Joins, eligibility.Conditions)
+
+	return sql, args
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package leaderboard
+
+import (
+	"strings"
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstDay(t *testing.T) {
+	t.Parallel()
+	today := stdlibtime.Date(2024, 3, 5, 0, 0, 0, 0, stdlibtime.UTC)
+	assert.Equal(t, today, (&Board{Days: 1}).FirstDay(today))
+	assert.Equal(t, stdlibtime.Date(2024, 2, 28, 0, 0, 0, 0, stdlibtime.UTC), (&Board{Days: 7}).FirstDay(today))
+	assert.Equal(t, stdlibtime.Date(2024, 2, 5, 0, 0, 0, 0, stdlibtime.UTC), (&Board{Days: 30}).FirstDay(today))
+}
+
+func TestIsRetained(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		days           int
+		maxDaysHistory uint64
+		expected       bool
+	}{
+		{days: 0, maxDaysHistory: 0, expected: true},
+		{days: 0, maxDaysHistory: 5, expected: true},
+		{days: 7, maxDaysHistory: 5, expected: false},
+		{days: 7, maxDaysHistory: 7, expected: true},
+		{days: 30, maxDaysHistory: 29, expected: false},
+		{days: 30, maxDaysHistory: 30, expected: true},
+		{days: 30, maxDaysHistory: 90, expected: true},
+	} {
+		assert.Equal(t, tc.expected, (&Board{Days: tc.days}).IsRetained(tc.maxDaysHistory), "days:%v, maxDaysHistory:%v", tc.days, tc.maxDaysHistory)
+	}
+}
+
+func TestInsertSQLCountsAllTimeReferrals(t *testing.T) {
+	t.Parallel()
+	today := stdlibtime.Date(2024, 3, 5, 0, 0, 0, 0, stdlibtime.UTC)
+	sql, args := (&Board{Period: "all", Tier: "T2", CountColumn: "t2"}).InsertSQL(&Eligibility{Conditions: "true"}, 100, today)
+	assert.Equal(t, []any{"all", "T2", uint64(100)}, args)
+	assert.Contains(t, sql, "SELECT user_id, t2 AS referral_count FROM referral_acquisition_history)")
+	assert.NotContains(t, sql, "referral_acquisition_history_per_day")
+}
+
+func TestInsertSQLCountsReferralsOfThePeriod(t *testing.T) {
+	t.Parallel()
+	today := stdlibtime.Date(2024, 3, 5, 0, 0, 0, 0, stdlibtime.UTC)
+	sql, args := (&Board{Period: "7d", Tier: "T1", CountColumn: "t1", Days: 7}).InsertSQL(&Eligibility{Conditions: "true"}, 100, today)
+	assert.Equal(t, []any{"7d", "T1", uint64(100), stdlibtime.Date(2024, 2, 28, 0, 0, 0, 0, stdlibtime.UTC)}, args)
+	assert.Contains(t, normalize(sql), "SELECT user_id, SUM(t1) AS referral_count FROM referral_acquisition_history_per_day WHERE date >= $4 GROUP BY user_id")
+}
+
+func TestInsertSQLRanks(t *testing.T) {
+	t.Parallel()
+	eligibility := &Eligibility{Joins: "JOIN quiz_sessions qs ON qs.user_id = u.id", Conditions: "u.kyc_step_passed >= 2"}
+	sql, _ := (&Board{Period: "all", Tier: "T1", CountColumn: "t1"}).InsertSQL(eligibility, 100, stdlibtime.Now())
+	sql = normalize(sql)
+	assert.Contains(t, sql, "ROW_NUMBER() OVER (ORDER BY c.referral_count DESC, u.created_at ASC, u.id ASC) AS rank")
+	assert.Contains(t, sql, "ROW_NUMBER() OVER (PARTITION BY u.country ORDER BY c.referral_count DESC, u.created_at ASC, u.id ASC) AS country_rank")
+	assert.Contains(t, sql, "JOIN users u ON u.id = c.user_id JOIN quiz_sessions qs ON qs.user_id = u.id WHERE c.referral_count > 0 AND u.kyc_step_passed >= 2")
+	assert.Contains(t, sql, "WHERE rank <= $3 OR country_rank <= $3")
+}
+
+func normalize(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,64 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package reassignment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Public API.
+
+const (
+	// RandomStrategyName moves every orphan to a random user, older than the orphan. It's the default.
+	RandomStrategyName StrategyName = "random"
+	// T0StrategyName moves the orphans to the referrer of the deleted user.
+	T0StrategyName StrategyName = "t0"
+	// HouseAccountStrategyName moves the orphans to the configured house account.
+	HouseAccountStrategyName StrategyName = "houseAccount"
+	// UnassignedStrategyName leaves the orphans without a referrer, so they can choose one themselves.
+	UnassignedStrategyName StrategyName = "unassigned"
+
+	DefaultHouseAccountID UserID = "icenetwork"
+)
+
+var ErrUnknownStrategy = errors.New("unknown referral reassignment strategy")
+
+type (
+	UserID       = string
+	StrategyName = string
+	// DeletedUser is the user whose T1 referrals (the orphans) need a new referrer.
+	DeletedUser struct {
+		ID UserID
+		// Empty or equal to ID if the deleted user had no referrer.
+		ReferredBy UserID
+	}
+	// RandomReferrerPicker returns a random referrer for each orphan. Orphans missing from the result are left unassigned.
+	RandomReferrerPicker interface {
+		PickRandomReferrers(ctx context.Context, deletedUserID UserID, orphanIDs []UserID) (map[UserID]UserID, error)
+	}
+	// Strategy decides the new referrer of each orphan.
+	// An orphan that is left unassigned gets its own ID as referrer, which is how users without a referrer are stored.
+	Strategy interface {
+		Name() StrategyName
+		Reassign(ctx context.Context, deleted *DeletedUser, orphanIDs []UserID) (map[UserID]UserID, error)
+	}
+	Config struct {
+		Strategy       StrategyName `yaml:"strategy"`
+		HouseAccountID UserID       `yaml:"houseAccountId" mapstructure:"houseAccountId"` //nolint:tagliatelle // Nope.
+	}
+)
+
+// Private API.
+
+type (
+	randomStrategy struct {
+		picker RandomReferrerPicker
+	}
+	t0Strategy           struct{}
+	houseAccountStrategy struct {
+		houseAccountID UserID
+	}
+	unassignedStrategy struct{}
+)
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package reassignment
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+func New(cfg *Config, picker RandomReferrerPicker) (Strategy, error) {
+	switch cfg.Strategy {
+	case "", RandomStrategyName:
+		return &randomStrategy{picker: picker}, nil
+	case T0StrategyName:
+		return new(t0Strategy), nil
+	case HouseAccountStrategyName:
+		houseAccountID := cfg.HouseAccountID
+		if houseAccountID == "" {
+			houseAccountID = DefaultHouseAccountID
+		}
+
+		return &houseAccountStrategy{houseAccountID: houseAccountID}, nil
+	case UnassignedStrategyName:
+		return new(unassignedStrategy), nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownStrategy, "strategy:%v", cfg.Strategy)
+	}
+}
+
+func (*randomStrategy) Name() StrategyName {
+	return RandomStrategyName
+}
+
+func (s *randomStrategy) Reassign(ctx context.Context, deleted *DeletedUser, orphanIDs []UserID) (map[UserID]UserID, error) {
+	if len(orphanIDs) == 0 {
+		return map[UserID]UserID{}, nil
+	}
+	picked, err := s.picker.PickRandomReferrers(ctx, deleted.ID, orphanIDs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pick random referrers for the referrals of userID:%v", deleted.ID)
+	}
+
+	return assignEach(orphanIDs, func(orphanID UserID) UserID { return picked[orphanID] }, deleted.ID), nil
+}
+
+func (*t0Strategy) Name() StrategyName {
+	return T0StrategyName
+}
+
+func (*t0Strategy) Reassign(_ context.Context, deleted *DeletedUser, orphanIDs []UserID) (map[UserID]UserID, error) {
+	return assignEach(orphanIDs, func(UserID) UserID { return deleted.ReferredBy }, deleted.ID), nil
+}
+
+func (*houseAccountStrategy) Name() StrategyName {
+	return HouseAccountStrategyName
+}
+
+func (s *houseAccountStrategy) Reassign(_ context.Context, deleted *DeletedUser, orphanIDs []UserID) (map[UserID]UserID, error) {
+	return assignEach(orphanIDs, func(UserID) UserID { return s.houseAccountID }, deleted.ID), nil
+}
+
+func (*unassignedStrategy) Name() StrategyName {
+	return UnassignedStrategyName
+}
+
+func (*unassignedStrategy) Reassign(_ context.Context, deleted *DeletedUser, orphanIDs []UserID) (map[UserID]UserID, error) {
+	return assignEach(orphanIDs, func(UserID) UserID { return "" }, deleted.ID), nil
+}
+
+// IsRandom tells if the referrer of the orphan was picked randomly.
+// That's not the case for the orphans the random strategy found no referrer for, as they keep their own ID.
+func IsRandom(strategy StrategyName, orphanID, referredBy UserID) bool {
+	return strategy == RandomStrategyName && referredBy != "" && referredBy != orphanID
+}
+
+// The orphans for which no valid referrer is found (none, the deleted user or themselves) are left unassigned.
+func assignEach(orphanIDs []UserID, referrerOf func(orphanID UserID) UserID, deletedUserID UserID) map[UserID]UserID {
+	res := make(map[UserID]UserID, len(orphanIDs))
+	for _, orphanID := range orphanIDs {
+		referredBy := referrerOf(orphanID)
+		if referredBy == "" || referredBy == deletedUserID {
+			referredBy = orphanID
+		}
+		res[orphanID] = referredBy
+	}
+
+	return res
+}
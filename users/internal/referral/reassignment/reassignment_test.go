@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package reassignment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	mockedPicker struct {
+		err    error
+		picked map[UserID]UserID
+	}
+)
+
+func (m *mockedPicker) PickRandomReferrers(context.Context, UserID, []UserID) (map[UserID]UserID, error) {
+	return m.picked, m.err
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	for name, expected := range map[StrategyName]StrategyName{
+		"":                       RandomStrategyName,
+		RandomStrategyName:       RandomStrategyName,
+		T0StrategyName:           T0StrategyName,
+		HouseAccountStrategyName: HouseAccountStrategyName,
+		UnassignedStrategyName:   UnassignedStrategyName,
+	} {
+		strategy, err := New(&Config{Strategy: name}, new(mockedPicker))
+		require.NoError(t, err)
+		assert.Equal(t, expected, strategy.Name())
+	}
+	_, err := New(&Config{Strategy: "bogus"}, nil)
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestRandomStrategy(t *testing.T) {
+	t.Parallel()
+	strategy, err := New(&Config{Strategy: RandomStrategyName}, &mockedPicker{picked: map[UserID]UserID{"o1": "r1", "o2": "deleted"}})
+	require.NoError(t, err)
+	res, err := strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: "t0"}, []UserID{"o1", "o2", "o3"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": "r1", "o2": "o2", "o3": "o3"}, res)
+
+	res, err = strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	strategy, err = New(&Config{Strategy: RandomStrategyName}, &mockedPicker{err: errors.New("oops")})
+	require.NoError(t, err)
+	_, err = strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted"}, []UserID{"o1"})
+	require.Error(t, err)
+}
+
+func TestRandomStrategyFallback(t *testing.T) {
+	t.Parallel()
+	strategy, err := New(&Config{Strategy: RandomStrategyName}, &mockedPicker{picked: map[UserID]UserID{"o1": "r1", "o2": "deleted"}})
+	require.NoError(t, err)
+	res, err := strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: "t0"}, []UserID{"o1", "o2", "o3"})
+	require.NoError(t, err)
+	assert.True(t, IsRandom(strategy.Name(), "o1", res["o1"]))
+	// No referrer was found for these, so they keep their own id and stay free to choose one themselves.
+	assert.False(t, IsRandom(strategy.Name(), "o2", res["o2"]))
+	assert.False(t, IsRandom(strategy.Name(), "o3", res["o3"]))
+}
+
+func TestIsRandom(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsRandom(RandomStrategyName, "o1", "r1"))
+	assert.False(t, IsRandom(RandomStrategyName, "o1", "o1"))
+	assert.False(t, IsRandom(RandomStrategyName, "o1", ""))
+	for _, name := range []StrategyName{T0StrategyName, HouseAccountStrategyName, UnassignedStrategyName} {
+		assert.False(t, IsRandom(name, "o1", "r1"), name)
+		assert.False(t, IsRandom(name, "o1", "o1"), name)
+	}
+}
+
+func TestT0Strategy(t *testing.T) {
+	t.Parallel()
+	strategy, err := New(&Config{Strategy: T0StrategyName}, nil)
+	require.NoError(t, err)
+	res, err := strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: "t0"}, []UserID{"o1", "o2"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": "t0", "o2": "t0"}, res)
+
+	for _, referredBy := range []UserID{"", "deleted"} {
+		res, err = strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: referredBy}, []UserID{"o1"})
+		require.NoError(t, err)
+		assert.EqualValues(t, map[UserID]UserID{"o1": "o1"}, res)
+	}
+}
+
+func TestHouseAccountStrategy(t *testing.T) {
+	t.Parallel()
+	strategy, err := New(&Config{Strategy: HouseAccountStrategyName}, nil)
+	require.NoError(t, err)
+	res, err := strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: "t0"}, []UserID{"o1", "o2"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": DefaultHouseAccountID, "o2": DefaultHouseAccountID}, res)
+
+	strategy, err = New(&Config{Strategy: HouseAccountStrategyName, HouseAccountID: "house"}, nil)
+	require.NoError(t, err)
+	res, err = strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted"}, []UserID{"o1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": "house"}, res)
+
+	res, err = strategy.Reassign(context.Background(), &DeletedUser{ID: "house"}, []UserID{"o1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": "o1"}, res)
+}
+
+func TestUnassignedStrategy(t *testing.T) {
+	t.Parallel()
+	strategy, err := New(&Config{Strategy: UnassignedStrategyName}, nil)
+	require.NoError(t, err)
+	res, err := strategy.Reassign(context.Background(), &DeletedUser{ID: "deleted", ReferredBy: "t0"}, []UserID{"o1", "o2"})
+	require.NoError(t, err)
+	assert.EqualValues(t, map[UserID]UserID{"o1": "o1", "o2": "o2"}, res)
+}
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package usernamepolicy
+
+import (
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+)
+
+// Public API.
+
+var (
+	ErrChangeLimitReached = errors.New("username changed too many times recently")
+	ErrQuarantined        = errors.New("username was released recently and only its previous owner can claim it")
+)
+
+type (
+	UserID = string
+	// Config limits how often the username can be changed and for how long a released one is reserved to its previous owner.
+	Config struct {
+		Window            stdlibtime.Duration `yaml:"window"`
+		ReleaseQuarantine stdlibtime.Duration `yaml:"releaseQuarantine" mapstructure:"releaseQuarantine"`
+		MaxChanges        uint64              `yaml:"maxChanges" mapstructure:"maxChanges"`
+	}
+	// History is what the username history tells about a change: how many changes the user made within the window
+	// and who released the new username within the quarantine, if anybody.
+	History struct {
+		ReleasedBy *UserID
+		Changes    uint64
+	}
+)
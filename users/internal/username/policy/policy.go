@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package usernamepolicy
+
+import (
+	"github.com/pkg/errors"
+)
+
+// IsChange tells if the new username has to be verified, that's if it's provided and different from the old one.
+func IsChange(oldUsername, newUsername string) bool {
+	return newUsername != "" && newUsername != oldUsername
+}
+
+// IsRecorded tells if the change is kept in the username history.
+// The first username set by the user replaces the default one (the user id), which isn't released nor counted.
+func IsRecorded(userID UserID, oldUsername, newUsername string) bool {
+	return IsChange(oldUsername, newUsername) && oldUsername != userID
+}
+
+// Verify checks that the user can change its username to the new one.
+// A set username can be changed at most `MaxChanges` times per `Window`.
+// A released username can be claimed, until its quarantine ends, only by its previous owner.
+func (c *Config) Verify(userID UserID, oldUsername, newUsername string, history *History) error {
+	if !IsChange(oldUsername, newUsername) {
+		return nil
+	}
+	if c.MaxChanges > 0 && oldUsername != userID && history.Changes >= c.MaxChanges {
+		return errors.Wrapf(ErrChangeLimitReached, "at most %v changes are allowed every %v", c.MaxChanges, c.Window)
+	}
+	if c.ReleaseQuarantine > 0 && history.ReleasedBy != nil && *history.ReleasedBy != userID {
+		return errors.Wrapf(ErrQuarantined, "username:%v", newUsername)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package usernamepolicy
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigVerify(t *testing.T) {
+	t.Parallel()
+	cfg := &Config{Window: 30 * 24 * stdlibtime.Hour, ReleaseQuarantine: 30 * 24 * stdlibtime.Hour, MaxChanges: 3}
+	previousOwner := "previousOwner"
+	for _, tc := range []struct {
+		expected                 error
+		history                  *History
+		name                     string
+		oldUsername, newUsername string
+	}{
+		{name: "no username", oldUsername: "jdoe", history: &History{Changes: 10}},
+		{name: "same username", oldUsername: "jdoe", newUsername: "jdoe", history: &History{Changes: 10}},
+		{name: "first change", oldUsername: "jdoe", newUsername: "john", history: &History{}},
+		{name: "below the limit", oldUsername: "jdoe", newUsername: "john", history: &History{Changes: 2}},
+		{name: "limit reached", oldUsername: "jdoe", newUsername: "john", history: &History{Changes: 3}, expected: ErrChangeLimitReached},
+		{name: "default username isn't counted", oldUsername: "userID", newUsername: "john", history: &History{Changes: 3}},
+		{name: "released by someone else", oldUsername: "jdoe", newUsername: "john", history: &History{ReleasedBy: &previousOwner}, expected: ErrQuarantined},
+	} {
+		err := cfg.Verify("userID", tc.oldUsername, tc.newUsername, tc.history)
+		if tc.expected == nil {
+			require.NoError(t, err, tc.name)
+		} else {
+			require.ErrorIs(t, err, tc.expected, tc.name)
+		}
+	}
+	userID := "userID"
+	require.NoError(t, cfg.Verify(userID, "jdoe", "john", &History{ReleasedBy: &userID}), "claimed back by its previous owner")
+	require.NoError(t, (&Config{}).Verify(userID, "jdoe", "john", &History{ReleasedBy: &previousOwner, Changes: 100}), "no limits")
+}
+
+func TestIsRecorded(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsRecorded("userID", "jdoe", "john"))
+	assert.False(t, IsRecorded("userID", "userID", "john"))
+	assert.False(t, IsRecorded("userID", "jdoe", "jdoe"))
+	assert.False(t, IsRecorded("userID", "jdoe", ""))
+}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package versioning
+
+import (
+	stdlibtime "time"
+)
+
+// Public API.
+
+// Precision is the precision of the timestamps stored by postgres.
+// The versions must be generated at it, otherwise the checksums returned to the clients never match the stored ones.
+const Precision = stdlibtime.Microsecond
+
+// Private API.
+
+const (
+	base10 = 10
+)
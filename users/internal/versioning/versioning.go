@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package versioning
+
+import (
+	"strconv"
+	"strings"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// Now returns the current time at the Precision it's going to be stored at, to be used as the new version of a row.
+func Now() *time.Time {
+	return time.New(time.Now().Truncate(Precision))
+}
+
+// Checksum is the opaque representation of the version, returned to the clients.
+func Checksum(version *time.Time) string {
+	if version == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(version.UnixNano(), base10)
+}
+
+// Parse is the inverse of Checksum.
+func Parse(checksum string) (*time.Time, error) {
+	nanos, err := strconv.ParseInt(checksum, base10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "checksum %v is not numeric", checksum)
+	}
+
+	return time.New(stdlibtime.Unix(0, nanos)), nil
+}
+
+// ETag quotes the checksum, as expected by the `ETag` header.
+func ETag(checksum string) string {
+	if checksum == "" {
+		return ""
+	}
+
+	return `"` + checksum + `"`
+}
+
+// FromETag extracts the checksum from an `If-Match` header value. It returns empty for the `*` wildcard, which matches any version.
+func FromETag(etag string) string {
+	if etag = strings.TrimSpace(etag); etag == "*" {
+		return ""
+	}
+
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
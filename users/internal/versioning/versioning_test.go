@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package versioning
+
+import (
+	"testing"
+	stdlibtime "time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// store mimics what postgres keeps of a timestamp: pgx encodes it as microseconds, dropping the rest.
+func store(version *time.Time) *time.Time {
+	return time.New(stdlibtime.UnixMicro(version.UnixMicro()))
+}
+
+func TestModifyingAgainWithTheReturnedETag(t *testing.T) {
+	t.Parallel()
+
+	for range 100 {
+		// The 1st PATCH stores the new version and returns it as ETag.
+		stored := Now()
+		persisted := store(stored)
+		etag := ETag(Checksum(stored))
+
+		// The 2nd PATCH sends it back, as `If-Match`, and it must match the row the 1st one stored.
+		expected, err := Parse(FromETag(etag))
+		require.NoError(t, err)
+		require.Equal(t, persisted.UnixNano(), expected.UnixNano(), etag)
+		require.True(t, store(expected).Equal(*persisted.Time), etag)
+	}
+}
+
+func TestModifyingAgainWithTheETagOfAnOlderVersion(t *testing.T) {
+	t.Parallel()
+
+	older := Now()
+	persisted := store(time.New(older.Add(Precision)))
+	expected, err := Parse(FromETag(ETag(Checksum(older))))
+	require.NoError(t, err)
+	assert.NotEqual(t, persisted.UnixNano(), expected.UnixNano())
+}
+
+func TestNow(t *testing.T) {
+	t.Parallel()
+
+	now := Now()
+	assert.Equal(t, now.Truncate(Precision), *now.Time)
+	assert.Equal(t, store(now).UnixNano(), now.UnixNano())
+}
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Checksum(nil))
+	version := time.New(stdlibtime.Unix(0, 1_700_000_000_123_456_000))
+	assert.Equal(t, "1700000000123456000", Checksum(version))
+	parsed, err := Parse(Checksum(version))
+	require.NoError(t, err)
+	assert.True(t, parsed.Equal(*version.Time))
+
+	_, err = Parse("bogus")
+	require.Error(t, err)
+	_, err = Parse("")
+	require.Error(t, err)
+}
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, ETag(""))
+	assert.Equal(t, `"123"`, ETag("123"))
+	assert.Equal(t, "123", FromETag(`"123"`))
+	assert.Equal(t, "123", FromETag(` W/"123" `))
+	assert.Equal(t, "123", FromETag("123"))
+	assert.Empty(t, FromETag("*"))
+	assert.Empty(t, FromETag(""))
+}
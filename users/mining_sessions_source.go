@@ -31,8 +31,10 @@ func (s *miningSessionSource) Process(ctx context.Context, msg *messagebroker.Me
 
 	return errors.Wrapf(multierror.Append(nil,
 		errors.Wrap(s.incrementTotalActiveUsersCount(ctx, ses), "failed to incrementTotalActiveUsersCount"),
+		errors.Wrap(s.incrementActiveUsersPerCountryCount(ctx, ses, usr), "failed to incrementActiveUsersPerCountryCount"),
 		errors.Wrap(s.updateTotalUsersCount(ctx, &UserSnapshot{User: usr}), "failed to updateTotalUsersCount"),
 		errors.Wrap(s.updateTotalUsersPerCountryCount(ctx, &UserSnapshot{User: usr}), "failed to updateTotalUsersPerCountryCount"),
+		errors.Wrap(s.updateTotalUsersPerCityCount(ctx, &UserSnapshot{User: usr}), "failed to updateTotalUsersPerCityCount"),
 	).ErrorOrNil(), "failed to process miningSession after LivenessDetectionKYCStep: %#v, user: %#v", ses, usr)
 }
 
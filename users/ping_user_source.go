@@ -36,5 +36,7 @@ func (s *userPingSource) Process(ctx context.Context, msg *messagebroker.Message
 	usr.ID = message.UserID
 	usr.LastPingCooldownEndedAt = message.LastPingCooldownEndedAt
 
+	ctx = ContextWithAuditActor(ctx, &AuditActor{Type: InternalAuditActorType, Source: "userPingSource"})
+
 	return errors.Wrapf(s.ModifyUser(ctx, usr, nil), "failed to modify user's LastPingCooldownEndedAt for %#v", usr)
 }
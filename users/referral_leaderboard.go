@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	stdlibtime "time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	devicemetadata "github.com/ice-blockchain/eskimo/users/internal/device/metadata"
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
+	"github.com/ice-blockchain/eskimo/users/internal/referral/leaderboard"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// GetReferralLeaderboard serves the leaderboard from `referral_leaderboard`, which is periodically refreshed by the processor.
+// When a country is provided, the ranks are the ones within that country.
+func (r *repository) GetReferralLeaderboard(
+	ctx context.Context, tier ReferralType, period ReferralLeaderboardPeriod, country devicemetadata.Country, limit uint64, cursor Cursor,
+) (*ReferralLeaderboard, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "failed to get referral leaderboard because of context failed")
+	}
+	if !slices.Contains(ReferralLeaderboardTiers, tier) {
+		return nil, errors.Wrapf(ErrInvalidReferralLeaderboardTier, "tier `%v` is not one of %v", tier, ReferralLeaderboardTiers)
+	}
+	if !slices.Contains(ReferralLeaderboardPeriods, period) {
+		return nil, errors.Wrapf(ErrInvalidReferralLeaderboardPeriod, "period `%v` is not one of %v", period, ReferralLeaderboardPeriods)
+	}
+	if !period.board(tier).IsRetained(r.cfg.MaxDaysReferralsHistory) {
+		return nil, errors.Wrapf(ErrInvalidReferralLeaderboardPeriod,
+			"period `%v` exceeds the %v days of referral history that are kept", period, r.cfg.MaxDaysReferralsHistory)
+	}
+	pageCur, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid referral leaderboard cursor for tier:%v, period:%v, country:%v", tier, period, country)
+	}
+	args := []any{period, tier, limit, int64(0)}
+	if pageCur != nil {
+		args[3] = pageCur.Rank
+	}
+	rankColumn, countryCondition := "lb.rank", ""
+	if country != "" {
+		rankColumn, countryCondition = "lb.country_rank", "AND lb.country = $5"
+		args = append(args, country)
+	}
+	sql := fmt.Sprintf(`
+		SELECT %[1]v 				AS rank,
+			   lb.referral_count 	AS referral_count,
+			   lb.country 			AS country,
+			   u.id 				AS id,
+			   u.username 			AS username,
+			   %[2]v 				AS profile_picture_name
+		FROM referral_leaderboard lb
+			JOIN users u
+			  ON u.id = lb.user_id
+		WHERE lb.period = $1
+			  AND lb.tier = $2
+			  AND %[1]v > $4
+			  %[3]v
+			  AND NOT (COALESCE(u.hidden_profile_elements, '{}') && '{%[4]v,%[5]v}'::text[])
+		ORDER BY %[1]v
+		LIMIT $3`,
+		rankColumn, r.pictureClient.SQLAliasDownloadURL(`u.profile_picture_name`), countryCondition,
+		GlobalRankHiddenProfileElement, ReferralCountHiddenProfileElement)
+	entries, err := storage.Select[ReferralLeaderboardEntry](ctx, r.db, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select referral leaderboard for tier:%v, period:%v, country:%v", tier, period, country)
+	}
+	leaderboard := &ReferralLeaderboard{Entries: entries}
+	if leaderboard.Entries == nil {
+		leaderboard.Entries = make([]*ReferralLeaderboardEntry, 0)
+	}
+	if limit > 0 && uint64(len(entries)) == limit {
+		leaderboard.NextCursor = pagination.Encode(&pageCursor{Rank: int64(entries[len(entries)-1].Rank)})
+	}
+
+	return leaderboard, nil
+}
+
+func (p *processor) startReferralLeaderboardRefresher(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(p.cfg.ReferralLeaderboardRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			const deadline = 5 * stdlibtime.Minute
+			reqCtx, cancel := context.WithTimeout(ctx, deadline)
+			log.Error(errors.Wrap(p.refreshReferralLeaderboard(reqCtx), "failed to refreshReferralLeaderboard"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Each period is rebuilt in its own transaction, so readers see either its old or its new ranking, never a mix.
+// The periods that need more days than the per day referral acquisition history keeps (`maxDaysReferralsHistory`) are dropped.
+func (p *processor) refreshReferralLeaderboard(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	today := time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
+	retainedPeriods := make([]string, 0, len(ReferralLeaderboardPeriods))
+	errs := make([]error, 0, len(ReferralLeaderboardPeriods)+1)
+	for _, period := range ReferralLeaderboardPeriods {
+		boards := make([]*leaderboard.Board, 0, len(ReferralLeaderboardTiers))
+		for _, tier := range ReferralLeaderboardTiers {
+			if board := period.board(tier); board.IsRetained(p.cfg.MaxDaysReferralsHistory) {
+				boards = append(boards, board)
+			}
+		}
+		if len(boards) == 0 {
+			continue
+		}
+		retainedPeriods = append(retainedPeriods, string(period))
+		errs = append(errs, errors.Wrapf(p.replaceReferralLeaderboard(ctx, today, period, boards), "failed to replace referral leaderboard for period:%v", period))
+	}
+	sql := `DELETE FROM referral_leaderboard WHERE period != ALL($1)`
+	if _, err := storage.Exec(ctx, p.db, sql, retainedPeriods); err != nil {
+		errs = append(errs, errors.Wrap(err, "failed to delete the referral leaderboard of the periods that are not retained"))
+	}
+
+	return multierror.Append(nil, errs...).ErrorOrNil() //nolint:wrapcheck // Not needed.
+}
+
+func (p *processor) replaceReferralLeaderboard(
+	ctx context.Context, today stdlibtime.Time, period ReferralLeaderboardPeriod, boards []*leaderboard.Board,
+) error {
+	return errors.Wrap(storage.DoInTransaction(ctx, p.db, func(conn storage.QueryExecer) error {
+		if _, err := storage.Exec(ctx, conn, `DELETE FROM referral_leaderboard WHERE period = $1`, period); err != nil {
+			return errors.Wrap(err, "failed to delete the old referral leaderboard")
+		}
+		for _, board := range boards {
+			sql, args := board.InsertSQL(referralLeaderboardEligibility(), referralLeaderboardMaxSize, today)
+			if _, err := storage.Exec(ctx, conn, sql, args...); err != nil {
+				return errors.Wrapf(err, "failed to insert referral leaderboard rows for tier:%v", board.Tier)
+			}
+		}
+
+		return nil
+	}), "failed to replace referral leaderboard")
+}
+
+// Only verified users that are not pending deletion and haven't hidden their rank or referral count are ranked.
+func referralLeaderboardEligibility() *leaderboard.Eligibility {
+	return &leaderboard.Eligibility{
+		Joins: `JOIN quiz_sessions qs
+				  ON qs.user_id = u.id
+				 AND qs.ended_at IS NOT NULL
+				 AND qs.ended_successfully = true`,
+		Conditions: fmt.Sprintf(`u.kyc_step_passed >= %[1]v
+						AND u.pending_deletion_at IS NULL
+						AND NOT (COALESCE(u.hidden_profile_elements, '{}') && '{%[2]v,%[3]v}'::text[])`,
+			LivenessDetectionKYCStep, GlobalRankHiddenProfileElement, ReferralCountHiddenProfileElement),
+	}
+}
+
+func (p ReferralLeaderboardPeriod) board(tier ReferralType) *leaderboard.Board {
+	countColumn := "t1"
+	if tier == Tier2Referrals {
+		countColumn = "t2"
+	}
+
+	return &leaderboard.Board{Period: string(p), Tier: string(tier), CountColumn: countColumn, Days: p.days()}
+}
+
+func (p ReferralLeaderboardPeriod) days() int {
+	switch p {
+	case SevenDaysReferralLeaderboardPeriod:
+		return 7 //nolint:gomnd // Not a magic number.
+	case ThirtyDaysReferralLeaderboardPeriod:
+		return 30 //nolint:gomnd // Not a magic number.
+	default:
+		return 0
+	}
+}
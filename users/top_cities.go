@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	devicemetadata "github.com/ice-blockchain/eskimo/users/internal/device/metadata"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+func (r *repository) GetTopCities(
+	ctx context.Context, country devicemetadata.Country, keyword string, limit, offset uint64,
+) ([]*CityStatistics, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "get top cities failed because context failed")
+	}
+	cityPrefix := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(strings.TrimSpace(keyword))) + "%"
+	sql := `SELECT country,
+				   city,
+				   user_count
+			FROM users_per_city
+			WHERE ($3 = '' OR country = $3)
+			  AND lower(city) LIKE $4
+			ORDER BY user_count DESC, country, city
+			LIMIT $1 OFFSET $2`
+	cs, err := storage.Select[CityStatistics](ctx, r.db, sql, limit, offset, strings.ToUpper(country), cityPrefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get top cities failed for %v %v %v %v", country, keyword, limit, offset)
+	}
+
+	return cs, nil
+}
+
+//nolint:funlen,gocyclo,revive,cyclop // .
+func (r *repository) updateTotalUsersPerCityCount(ctx context.Context, usr *UserSnapshot) error {
+	isFirstMiningAfterHumanVerification := (usr.Before == nil || usr.Before.ID == "") && usr.User != nil && usr.User.ID != "" &&
+		usr.User.isFirstMiningAfterHumanVerification(r)
+	isDeleteAfterHumanVerification := (usr.User == nil || usr.User.ID == "") && usr.Before != nil && usr.Before.ID != "" &&
+		usr.Before.hadAtLeastAMiningAfterHumanVerification(r)
+	isRelocatedAfterHumanVerification := usr.User != nil && usr.User.ID != "" && usr.Before != nil && usr.Before.ID != "" &&
+		(usr.User.Country != usr.Before.Country ||
+			devicemetadata.NormalizeCity(usr.User.City) != devicemetadata.NormalizeCity(usr.Before.City)) &&
+		usr.User.hadAtLeastAMiningAfterHumanVerification(r) &&
+		usr.Before.hadAtLeastAMiningAfterHumanVerification(r)
+	var increment, decrement *devicemetadata.DeviceLocation
+	switch {
+	case isFirstMiningAfterHumanVerification:
+		increment = &usr.User.DeviceLocation
+	case isDeleteAfterHumanVerification:
+		decrement = &usr.Before.DeviceLocation
+	case isRelocatedAfterHumanVerification:
+		increment, decrement = &usr.User.DeviceLocation, &usr.Before.DeviceLocation
+	default:
+		return errors.Wrap(ctx.Err(), "context failed")
+	}
+	values := make([]string, 0, 1+1)
+	params := make([]any, 0, 1+1+1+1)
+	incrementCondition := "1=0"
+	if increment != nil && increment.Country != "" && devicemetadata.NormalizeCity(increment.City) != "" {
+		values = append(values, "($1,$2,1)")
+		params = append(params, increment.Country, devicemetadata.NormalizeCity(increment.City))
+		incrementCondition = "users_per_city.country = $1 AND users_per_city.city = $2"
+	}
+	if decrement != nil && decrement.Country != "" && devicemetadata.NormalizeCity(decrement.City) != "" {
+		values = append(values, fmt.Sprintf("($%v,$%v,0)", len(params)+1, len(params)+1+1))
+		params = append(params, decrement.Country, devicemetadata.NormalizeCity(decrement.City))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sql := fmt.Sprintf(`
+		INSERT INTO users_per_city (country, city, user_count)
+		VALUES %[1]v
+		ON CONFLICT (country, city) DO UPDATE
+		  SET user_count = (CASE WHEN %[2]v THEN GREATEST(users_per_city.user_count + 1, 0) ELSE GREATEST(users_per_city.user_count - 1, 0) END)`,
+		strings.Join(values, ","), incrementCondition)
+	_, err := storage.Exec(ctx, r.db, sql, params...)
+
+	return errors.Wrapf(err, "error changing city count for params:%#v", params)
+}
@@ -6,29 +6,54 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	stdlibtime "time"
 
 	"github.com/pkg/errors"
 
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
 )
 
-func (r *repository) GetTopCountries(ctx context.Context, keyword string, limit, offset uint64) (cs []*CountryStatistics, err error) {
+// The active users of a country are the max number of users that were active in a single day, within the last `days` days, today included.
+func (r *repository) GetTopCountries(
+	ctx context.Context, keyword string, sortBy CountryStatisticsSortBy, days, limit, offset uint64,
+) (cs []*CountryStatistics, err error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "get top countries failed because context failed")
 	}
+	var orderBy string
+	switch sortBy {
+	case "", TotalCountryStatisticsSortBy:
+		orderBy = "c.user_count DESC, active_user_count DESC"
+	case ActiveCountryStatisticsSortBy:
+		orderBy = "active_user_count DESC, c.user_count DESC"
+	default:
+		return nil, errors.Wrapf(ErrInvalidSortBy, "sortBy `%v` is not one of %v", sortBy, CountryStatisticsSortByValues)
+	}
+	if days == 0 {
+		days = 1
+	}
+	today := time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
 	countries, countryParams := r.getTopCountriesParams(keyword)
-	params := []any{limit, offset}
+	params := []any{limit, offset, today.AddDate(0, 0, 1-int(days))}
 	params = append(params, countryParams...)
 	sql := fmt.Sprintf(`
-						SELECT  country, 
-								user_count 
-						FROM users_per_country
-						WHERE lower(country) in (%v)
-						ORDER BY user_count desc 
-						LIMIT $1 OFFSET $2`, countries)
+						SELECT  c.country, 
+								c.user_count,
+								COALESCE(a.active_user_count, 0) AS active_user_count
+						FROM users_per_country c
+							LEFT JOIN (SELECT country,
+											  MAX(user_count) AS active_user_count
+									   FROM active_users_per_country_per_day
+									   WHERE date >= $3
+									   GROUP BY country) a
+								   ON a.country = c.country
+						WHERE lower(c.country) in (%v)
+						ORDER BY %v 
+						LIMIT $1 OFFSET $2`, countries, orderBy)
 	cs, err = storage.Select[CountryStatistics](ctx, r.db, sql, params...)
 	if err != nil {
-		return nil, errors.Wrapf(err, "get top countries failed for %v %v %v", keyword, limit, offset)
+		return nil, errors.Wrapf(err, "get top countries failed for %v %v %v %v %v", keyword, sortBy, days, limit, offset)
 	}
 
 	return
@@ -37,10 +62,10 @@ func (r *repository) GetTopCountries(ctx context.Context, keyword string, limit,
 func (r *repository) getTopCountriesParams(countryKeyword string) (countriesSQLEnumeration string, params []any) {
 	countriesSQLEnumeration = "''"
 	params = make([]any, 0)
-	const initialParamIdx = 3 // 1 and 2 are limit and offset.
+	const initialParamIdx = 4 // 1, 2 and 3 are limit, offset and the first active day.
 	keyword := strings.ToLower(countryKeyword)
 	if keyword == "" {
-		countriesSQLEnumeration = "lower(country)"
+		countriesSQLEnumeration = "lower(c.country)"
 	} else if countries := r.LookupCountries(keyword); len(countries) != 0 {
 		var countryParams []string
 		for i, country := range countries {
@@ -92,3 +117,40 @@ func (r *repository) updateTotalUsersPerCountryCount(ctx context.Context, usr *U
 
 	return errors.Wrapf(err, "error changing country count for params:%#v", params...)
 }
+
+func (r *repository) incrementActiveUsersPerCountryCount(ctx context.Context, ms *miningSession, usr *User) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	if usr == nil || usr.Country == "" {
+		return nil
+	}
+	days := ms.detectIncrActiveIntervals(hoursInOneDay * stdlibtime.Hour)
+	if len(days) == 0 {
+		return nil
+	}
+	sql := `INSERT INTO active_users_per_country_per_day (date, country, user_count)
+				SELECT day, $2, 1
+				FROM unnest($1::date[]) AS day
+			ON CONFLICT (date, country) DO UPDATE
+				SET user_count = active_users_per_country_per_day.user_count + 1`
+	if _, err := storage.Exec(ctx, r.db, sql, days, usr.Country); err != nil {
+		return errors.Wrapf(err, "failed to increment active users for country:%v, days:%v", usr.Country, days)
+	}
+
+	return nil
+}
+
+// Older days can't be requested anymore, see `MaxTopCountriesDays`.
+func (p *processor) deleteExpiredActiveUsersPerCountry(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	today := time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
+	sql := `DELETE FROM active_users_per_country_per_day WHERE date < $1`
+	if _, err := storage.Exec(ctx, p.db, sql, today.AddDate(0, 0, 1-MaxTopCountriesDays)); err != nil {
+		return errors.Wrap(err, "failed to delete expired data from active_users_per_country_per_day")
+	}
+
+	return nil
+}
@@ -12,16 +12,26 @@ import (
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/activity"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/time"
 )
 
-func (r *repository) GetUserGrowth(ctx context.Context, days uint64, tz *stdlibtime.Location) (*UserGrowthStatistics, error) {
+func (r *repository) GetUserGrowth(
+	ctx context.Context, days uint64, granularity UserGrowthGranularity, tz *stdlibtime.Location,
+) (*UserGrowthStatistics, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "context failed")
 	}
 	now := time.Now()
+	switch granularity {
+	case "", DayUserGrowthGranularity:
+	case WeekUserGrowthGranularity, MonthUserGrowthGranularity:
+		return r.getUserGrowthRollups(ctx, now, days, granularity, tz)
+	default:
+		return nil, errors.Wrapf(ErrInvalidGranularity, "granularity `%v` is not one of %v", granularity, UserGrowthGranularities)
+	}
 	keys := r.generateUserGrowthKeys(now, days)
 	values, err := r.getGlobalValues(ctx, keys...)
 	if err != nil {
@@ -117,6 +127,102 @@ func (r *repository) aggregateGlobalValuesToGrowth(
 	}
 }
 
+// Rollups are built from the same keys as the daily time series, but they're aggregated by the DB:
+// active is the max active users of any child interval within the period and total is the latest total users value recorded before the period's end.
+// Periods are aligned to UTC calendar weeks (starting on monday) or months and the first one is the current, partially elapsed, one.
+func (r *repository) getUserGrowthRollups( //nolint:funlen // Long SQL.
+	ctx context.Context, now *time.Time, days uint64, granularity UserGrowthGranularity, tz *stdlibtime.Location,
+) (*UserGrowthStatistics, error) {
+	nowActiveKey := r.totalActiveUsersGlobalChildKey(now.Time)
+	values, err := r.getGlobalValues(ctx, totalUsersGlobalKey, nowActiveKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to getGlobalValues for keys:%#v", []string{totalUsersGlobalKey, nowActiveKey})
+	}
+	periods := userGrowthPeriods(*now.Time, days, granularity)
+	parentFormat := r.cfg.globalAggregationIntervalParentDateFormat()
+	idxs := make([]int64, 0, len(periods))
+	activeFromKeys, activeToKeys, totalToKeys := make([]string, 0, len(periods)), make([]string, 0, len(periods)), make([]string, 0, len(periods))
+	for ix, period := range periods {
+		idxs = append(idxs, int64(ix))
+		activeFromKeys = append(activeFromKeys, fmt.Sprintf("%v_%v", totalActiveUsersGlobalKey, period.start.Format(parentFormat)))
+		activeToKeys = append(activeToKeys, fmt.Sprintf("%v_%v", totalActiveUsersGlobalKey, period.end.Format(parentFormat)))
+		totalToKeys = append(totalToKeys, r.totalUsersGlobalParentKey(&period.end))
+	}
+	sql := `SELECT p.idx,
+				   COALESCE((SELECT MAX(g.value)
+							 FROM global g
+							 WHERE g.key >= p.active_from
+							   AND g.key < p.active_to), 0) AS active,
+				   COALESCE((SELECT g.value
+							 FROM global g
+							 WHERE g.key > $5
+							   AND g.key < p.total_to
+							 ORDER BY g.key DESC
+							 LIMIT 1), 0) AS total
+			FROM unnest($1::bigint[], $2::text[], $3::text[], $4::text[]) AS p(idx, active_from, active_to, total_to)
+			ORDER BY p.idx`
+	type rollup struct {
+		Idx    int64
+		Active uint64
+		Total  uint64
+	}
+	rollups, err := storage.Select[rollup](ctx, r.db, sql, idxs, activeFromKeys, activeToKeys, totalToKeys, totalUsersGlobalKey+"_")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select %v user growth rollups for days:%v", granularity, days)
+	}
+	stats := &UserGrowthStatistics{TimeSeries: make([]*UserCountTimeSeriesDataPoint, 0, len(rollups))}
+	for _, row := range values {
+		switch row.Key {
+		case totalUsersGlobalKey:
+			stats.Total = row.Value
+		case nowActiveKey:
+			stats.Active = row.Value
+		}
+	}
+	_, tzOffset := now.In(tz).Zone()
+	clientTZ := stdlibtime.FixedZone("", -tzOffset)
+	for _, row := range rollups {
+		start := periods[row.Idx].start
+		stats.TimeSeries = append(stats.TimeSeries, &UserCountTimeSeriesDataPoint{
+			Date:      time.New(stdlibtime.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, clientTZ)),
+			UserCount: UserCount{Active: row.Active, Total: row.Total},
+		})
+	}
+	if len(stats.TimeSeries) > 0 {
+		stats.TimeSeries[0].Total = stats.Total
+	}
+
+	return stats, nil
+}
+
+type userGrowthPeriod struct {
+	start, end stdlibtime.Time
+}
+
+// The periods cover the last `days` days, today included, newest first.
+func userGrowthPeriods(now stdlibtime.Time, days uint64, granularity UserGrowthGranularity) []*userGrowthPeriod {
+	year, month, day := now.UTC().Date()
+	today := stdlibtime.Date(year, month, day, 0, 0, 0, 0, stdlibtime.UTC)
+	from := today.AddDate(0, 0, 1-int(days))
+	start := stdlibtime.Date(year, month, 1, 0, 0, 0, 0, stdlibtime.UTC)
+	next := func(date stdlibtime.Time, periods int) stdlibtime.Time { return date.AddDate(0, periods, 0) }
+	if granularity == WeekUserGrowthGranularity {
+		const daysInWeek = 7
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + daysInWeek - 1) % daysInWeek))
+		next = func(date stdlibtime.Time, periods int) stdlibtime.Time { return date.AddDate(0, 0, periods*daysInWeek) }
+	}
+	periods := make([]*userGrowthPeriod, 0, 1)
+	for {
+		periods = append(periods, &userGrowthPeriod{start: start, end: next(start, 1)})
+		if !start.After(from) {
+			break
+		}
+		start = next(start, -1)
+	}
+
+	return periods
+}
+
 func (r *repository) getGlobalValues(ctx context.Context, keys ...string) ([]*GlobalUnsigned, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "context failed")
@@ -212,26 +318,25 @@ func (r *repository) incrementTotalActiveUsersCount(ctx context.Context, ms *min
 }
 
 func (ms *miningSession) detectIncrTotalActiveUsersKeys(repo *repository) []any {
-	keys := make([]any, 0)
-	start, end := ms.EndedAt.Add(-ms.Extension), *ms.EndedAt.Time
-	if !ms.LastNaturalMiningStartedAt.Equal(*ms.StartedAt.Time) ||
-		(!ms.PreviouslyEndedAt.IsNil() &&
-			repo.totalActiveUsersGlobalChildKey(ms.StartedAt.Time) == repo.totalActiveUsersGlobalChildKey(ms.PreviouslyEndedAt.Time)) {
-		start = start.Add(repo.cfg.GlobalAggregationInterval.Child)
-	}
-	start = start.Truncate(repo.cfg.GlobalAggregationInterval.Child)
-	end = end.Truncate(repo.cfg.GlobalAggregationInterval.Child)
-	for start.Before(end) {
-		keys = append(keys, repo.totalActiveUsersGlobalChildKey(&start))
-		start = start.Add(repo.cfg.GlobalAggregationInterval.Child)
-	}
-	if ms.PreviouslyEndedAt.IsNil() || repo.totalActiveUsersGlobalChildKey(&end) != repo.totalActiveUsersGlobalChildKey(ms.PreviouslyEndedAt.Time) {
-		keys = append(keys, repo.totalActiveUsersGlobalChildKey(&end))
+	intervals := ms.detectIncrActiveIntervals(repo.cfg.GlobalAggregationInterval.Child)
+	keys := make([]any, 0, len(intervals))
+	for ix := range intervals {
+		keys = append(keys, repo.totalActiveUsersGlobalChildKey(&intervals[ix]))
 	}
 
 	return keys
 }
 
+func (ms *miningSession) detectIncrActiveIntervals(interval stdlibtime.Duration) []stdlibtime.Time {
+	return (&activity.MiningSession{
+		LastNaturalMiningStartedAt: ms.LastNaturalMiningStartedAt,
+		StartedAt:                  ms.StartedAt,
+		EndedAt:                    ms.EndedAt,
+		PreviouslyEndedAt:          ms.PreviouslyEndedAt,
+		Extension:                  ms.Extension,
+	}).IncrActiveIntervals(interval)
+}
+
 func (r *repository) notifyGlobalValueUpdateMessage(ctx context.Context, keys ...string) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "unexpected deadline")
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	usernamepolicy "github.com/ice-blockchain/eskimo/users/internal/username/policy"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// It has to run within the transaction that changes the username, after the user was updated (and so locked),
+// so concurrent changes of the same user or of the same username see each other's history.
+func (r *repository) verifyUsernameChange(ctx context.Context, conn storage.QueryExecer, oldUsr, usr *User) error {
+	if !usernamepolicy.IsChange(oldUsr.Username, usr.Username) {
+		return nil
+	}
+	policy := &r.cfg.UsernameChange
+	now := time.Now()
+	sql := `SELECT (SELECT count(1)
+					FROM username_history
+					WHERE user_id = $1
+						  AND changed_at > $2) AS changes,
+				   (SELECT user_id
+					FROM username_history
+					WHERE old_username = $3
+						  AND changed_at > $4
+					ORDER BY changed_at DESC
+					LIMIT 1) AS released_by`
+	history, err := storage.Get[usernamepolicy.History](ctx, conn, sql, usr.ID, now.Add(-policy.Window), usr.Username, now.Add(-policy.ReleaseQuarantine))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get username history for userID:%v", usr.ID)
+	}
+
+	return errors.Wrapf(policy.Verify(usr.ID, oldUsr.Username, usr.Username, history), "username change not allowed for userID:%v", usr.ID)
+}
+
+func (r *repository) insertUsernameHistory(ctx context.Context, conn storage.QueryExecer, oldUsr, usr *User) error {
+	if !usernamepolicy.IsRecorded(oldUsr.ID, oldUsr.Username, usr.Username) {
+		return nil
+	}
+	sql := `INSERT INTO username_history (changed_at, user_id, old_username, new_username) VALUES ($1, $2, $3, $4)`
+	_, err := storage.Exec(ctx, conn, sql, usr.UpdatedAt.Time, usr.ID, oldUsr.Username, usr.Username)
+
+	return errors.Wrapf(err, "failed to insert username history for userID:%v", usr.ID)
+}
+
+// The current account of a username that was changed recently, while it's still quarantined for its previous owner.
+func (r *repository) getUserByPreviousUsername(ctx context.Context, username string) (*User, error) {
+	if r.cfg.UsernameChange.ReleaseQuarantine <= 0 {
+		return nil, errors.Wrapf(ErrNotFound, "username:%v", username)
+	}
+	sql := `SELECT users.*,
+				   (qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true) AS quiz_completed
+			FROM username_history uh
+				JOIN users
+					ON users.id = uh.user_id
+				LEFT JOIN quiz_sessions qs
+					ON qs.user_id = users.id
+			WHERE uh.old_username = $1
+				  AND uh.changed_at > $2
+				  AND users.pending_deletion_at IS NULL
+			ORDER BY uh.changed_at DESC
+			LIMIT 1`
+	usr, err := storage.Get[User](ctx, r.db, sql, username, time.Now().Add(-r.cfg.UsernameChange.ReleaseQuarantine))
+
+	return usr, errors.Wrapf(err, "failed to get user by previous username %v", username)
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"math"
 	"math/big"
-	"strconv"
 	"strings"
 	"sync"
 	stdlibtime "time"
@@ -18,8 +17,11 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/blockchain/address"
 	devicemetadata "github.com/ice-blockchain/eskimo/users/internal/device/metadata"
+	"github.com/ice-blockchain/eskimo/users/internal/versioning"
 	"github.com/ice-blockchain/wintr/analytics/tracking"
+	"github.com/ice-blockchain/wintr/auth"
 	appcfg "github.com/ice-blockchain/wintr/config"
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
@@ -31,21 +33,48 @@ import (
 func New(ctx context.Context, _ context.CancelFunc) Repository {
 	var cfg config
 	appcfg.MustLoadFromKey(applicationYamlKey, &cfg)
+	if cfg.MaxDaysReferralsHistory == 0 {
+		cfg.MaxDaysReferralsHistory = defaultMaxDaysReferralsHistory
+	}
+	if cfg.MaxContactsPerUser == 0 {
+		cfg.MaxContactsPerUser = defaultMaxContactsPerUser
+	}
+	if cfg.WalletOwnershipChallengeTTL == 0 {
+		cfg.WalletOwnershipChallengeTTL = defaultWalletOwnershipChallengeTTL
+	}
 
 	db := storage.MustConnect(ctx, ddl, applicationYamlKey)
-
-	return &repository{
+	repo := &repository{
 		cfg:                      &cfg,
 		shutdown:                 db.Close,
 		db:                       db,
-		DeviceMetadataRepository: devicemetadata.New(db, nil),
+		DeviceMetadataRepository: devicemetadata.New(ctx, db, nil),
 		pictureClient:            picture.New(applicationYamlKey),
+		blockchainAddresses:      address.New(),
 	}
+	repo.referralReassignment = repo.mustCreateReferralReassignmentStrategy()
+
+	return repo
 }
 
-func StartProcessor(ctx context.Context, cancel context.CancelFunc) Processor {
+func StartProcessor(ctx context.Context, cancel context.CancelFunc, authClient auth.Client) Processor {
 	var cfg config
 	appcfg.MustLoadFromKey(applicationYamlKey, &cfg)
+	if cfg.MaxDaysReferralsHistory == 0 {
+		cfg.MaxDaysReferralsHistory = defaultMaxDaysReferralsHistory
+	}
+	if cfg.MaxContactsPerUser == 0 {
+		cfg.MaxContactsPerUser = defaultMaxContactsPerUser
+	}
+	if cfg.WalletOwnershipChallengeTTL == 0 {
+		cfg.WalletOwnershipChallengeTTL = defaultWalletOwnershipChallengeTTL
+	}
+	if cfg.ReferralLeaderboardRefreshInterval == 0 {
+		cfg.ReferralLeaderboardRefreshInterval = defaultReferralLeaderboardRefreshInterval
+	}
+	if cfg.UserAuditLogRetention == 0 {
+		cfg.UserAuditLogRetention = defaultUserAuditLogRetention
+	}
 
 	var mbConsumer messagebroker.Client
 	db := storage.MustConnect(ctx, ddl, applicationYamlKey)
@@ -54,9 +83,12 @@ func StartProcessor(ctx context.Context, cancel context.CancelFunc) Processor {
 		cfg:                      &cfg,
 		db:                       db,
 		mb:                       mbProducer,
-		DeviceMetadataRepository: devicemetadata.New(db, mbProducer),
+		DeviceMetadataRepository: devicemetadata.New(ctx, db, mbProducer),
 		pictureClient:            picture.New(applicationYamlKey, defaultProfilePictureNameRegex),
+		authClient:               authClient,
+		blockchainAddresses:      address.New(),
 	}}
+	prc.referralReassignment = prc.mustCreateReferralReassignmentStrategy()
 	if !cfg.DisableConsumer {
 		prc.trackingClient = tracking.New(applicationYamlKey)
 		mbConsumer = messagebroker.MustConnectAndStartConsuming(context.Background(), cancel, applicationYamlKey, //nolint:contextcheck // It's intended.
@@ -64,7 +96,13 @@ func StartProcessor(ctx context.Context, cancel context.CancelFunc) Processor {
 			&miningSessionSource{processor: prc},
 			&userPingSource{processor: prc},
 		)
+		go prc.startOutboxRelay(ctx)
 		go prc.startOldProcessedReferralsCleaner(ctx)
+		go prc.startReferralLeaderboardRefresher(ctx)
+		go prc.startUserAuditLogCleaner(ctx)
+	}
+	if cfg.DeletionGracePeriod > 0 {
+		go prc.startPendingDeletionsPurger(ctx)
 	}
 	prc.shutdown = closeAll(mbConsumer, prc.mb, prc.db, prc.DeviceMetadataRepository.Close)
 
@@ -118,32 +156,6 @@ func (p *processor) CheckHealth(ctx context.Context) error {
 	return errors.Wrapf(<-responder, "[health-check] failed to send health check message to broker")
 }
 
-func runConcurrently[ARG any](ctx context.Context, run func(context.Context, ARG) error, args []ARG) error {
-	if ctx.Err() != nil {
-		return errors.Wrap(ctx.Err(), "unexpected deadline")
-	}
-	if len(args) == 0 {
-		return nil
-	}
-	wg := new(sync.WaitGroup)
-	wg.Add(len(args))
-	errChan := make(chan error, len(args))
-	for i := range args {
-		go func(ix int) {
-			defer wg.Done()
-			errChan <- errors.Wrapf(run(ctx, args[ix]), "failed to run:%#v", args[ix])
-		}(i)
-	}
-	wg.Wait()
-	close(errChan)
-	errs := make([]error, 0, len(args))
-	for err := range errChan {
-		errs = append(errs, err)
-	}
-
-	return errors.Wrap(multierror.Append(nil, errs...).ErrorOrNil(), "at least one execution failed")
-}
-
 func randomBetween(left, right uint64) uint64 {
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(right)-int64(left)))
 	log.Panic(errors.Wrap(err, "crypto random generator failed"))
@@ -162,19 +174,18 @@ func lastUpdatedAt(ctx context.Context) *time.Time {
 	if !ok || checksum == "" {
 		return nil
 	}
-
-	nanos, err := strconv.Atoi(checksum)
+	version, err := versioning.Parse(checksum)
 	if err != nil {
-		log.Error(errors.Wrapf(err, "checksum %v is not numeric", checksum))
+		log.Error(errors.Wrap(err, "invalid checksum"))
 
 		return nil
 	}
 
-	return time.New(stdlibtime.Unix(0, int64(nanos)))
+	return version
 }
 
 func ContextWithChecksum(ctx context.Context, checksum string) context.Context {
-	if true || checksum == "" { //nolint:revive // TODO:: to be decided if this feature is still needed.
+	if checksum == "" {
 		return ctx
 	}
 
@@ -264,12 +275,16 @@ func (j *JSON) Scan(src any) error {
 }
 
 func (u *User) Checksum() string {
-	if u.UpdatedAt == nil {
-		return ""
-	}
-	const base10 = 10
+	return versioning.Checksum(u.UpdatedAt)
+}
 
-	return strconv.FormatInt(u.UpdatedAt.UnixNano(), base10)
+func (u *User) ETag() string {
+	return versioning.ETag(u.Checksum())
+}
+
+// ChecksumFromETag extracts the checksum from an `If-Match` header value. It returns empty for the `*` wildcard, which matches any version.
+func ChecksumFromETag(etag string) string {
+	return versioning.FromETag(etag)
 }
 
 func (u *User) SetVerified() {
@@ -488,15 +503,15 @@ func sendMessagesConcurrently[M any](ctx context.Context, sendMessage func(conte
 	return errors.Wrap(multierror.Append(nil, errs...).ErrorOrNil(), "at least one message sends failed")
 }
 
+// The keywords are the username and its `.` separated parts. Partial/fuzzy matching is done with the username's trigram index instead.
 func generateUsernameKeywords(username string) []string {
 	if username == "" {
 		return nil
 	}
 	keywordsMap := make(map[string]struct{})
 	for _, part := range append(strings.Split(username, "."), username) {
-		for i := 0; i < len(part); i++ {
-			keywordsMap[part[:i+1]] = struct{}{}
-			keywordsMap[part[len(part)-1-i:]] = struct{}{}
+		if part != "" {
+			keywordsMap[part] = struct{}{}
 		}
 	}
 	keywords := make([]string, 0, len(keywordsMap))
@@ -519,3 +534,18 @@ func ConfirmedEmail(ctx context.Context) string {
 
 	return ""
 }
+
+// ConfirmedMiningBlockchainAccountAddressContext marks the change to the provided mining address as confirmed via email.
+// It must be used only after the email link that was sent for it was verified.
+func ConfirmedMiningBlockchainAccountAddressContext(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, confirmedMiningAddressCtxValueKey, address) //nolint:revive,staticcheck // .
+}
+
+func confirmedMiningBlockchainAccountAddress(ctx context.Context) string {
+	address, ok := ctx.Value(confirmedMiningAddressCtxValueKey).(string)
+	if ok {
+		return address
+	}
+
+	return ""
+}
@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sort"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func ContextWithAuditActor(ctx context.Context, actor *AuditActor) context.Context {
+	if actor == nil || actor.Type == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, auditActorCtxValueKey, actor) //nolint:revive,staticcheck // Not an issue.
+}
+
+func auditActor(ctx context.Context) *AuditActor {
+	if actor, ok := ctx.Value(auditActorCtxValueKey).(*AuditActor); ok && actor != nil {
+		return actor
+	}
+
+	return &AuditActor{Type: InternalAuditActorType, Source: applicationYamlKey}
+}
+
+func (r *repository) GetUserAuditLog(ctx context.Context, userID string, limit uint64, cursor Cursor) (*UserAuditLog, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "failed to get user audit log because of context failed")
+	}
+	pageCur, err := pagination.Decode(cursor)
+	if err == nil && pageCur != nil && pageCur.Rank <= 0 {
+		err = errors.Wrapf(ErrInvalidCursor, "cursor:%v is missing id", cursor)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid user audit log cursor for userID:%v", userID)
+	}
+	args := []any{userID, limit}
+	keysetCondition := ""
+	if pageCur != nil {
+		keysetCondition = "AND id < $3"
+		args = append(args, pageCur.Rank)
+	}
+	sql := `SELECT id,
+				   created_at,
+				   user_id,
+				   actor_type,
+				   COALESCE(actor_id, '') AS actor_id,
+				   source,
+				   changed_fields,
+				   before,
+				   after
+			FROM user_audit_log
+			WHERE user_id = $1
+				  ` + keysetCondition + `
+			ORDER BY id DESC
+			LIMIT $2`
+	type auditLogRow struct {
+		CreatedAt     *time.Time
+		Before        *JSON
+		After         *JSON
+		UserID        UserID
+		ActorType     AuditActorType
+		ActorID       UserID
+		Source        string
+		ChangedFields []string
+		ID            int64
+	}
+	rows, err := storage.Select[auditLogRow](ctx, r.db, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select user audit log for userID:%v", userID)
+	}
+	auditLog := &UserAuditLog{Entries: make([]*UserAuditLogEntry, 0, len(rows))}
+	for _, row := range rows {
+		auditLog.Entries = append(auditLog.Entries, &UserAuditLogEntry{
+			CreatedAt:     row.CreatedAt,
+			Before:        row.Before,
+			After:         row.After,
+			Actor:         AuditActor{Type: row.ActorType, ID: row.ActorID, Source: row.Source},
+			ChangedFields: row.ChangedFields,
+			UserID:        row.UserID,
+			ID:            row.ID,
+		})
+	}
+	if limit > 0 && uint64(len(rows)) == limit {
+		auditLog.NextCursor = pagination.Encode(&pageCursor{Rank: rows[len(rows)-1].ID})
+	}
+
+	return auditLog, nil
+}
+
+func (p *processor) startUserAuditLogCleaner(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(stdlibtime.Duration(1+rand.Intn(60)) * stdlibtime.Minute) //nolint:gosec,gomnd // Not an  issue.
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			const deadline = 5 * stdlibtime.Minute
+			reqCtx, cancel := context.WithTimeout(ctx, deadline)
+			log.Error(errors.Wrap(p.deleteExpiredUserAuditLogEntries(reqCtx), "failed to deleteExpiredUserAuditLogEntries"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// It deletes in batches, so the table isn't locked for too long if there's a big backlog.
+func (p *processor) deleteExpiredUserAuditLogEntries(ctx context.Context) error {
+	sql := `DELETE FROM user_audit_log
+			WHERE id IN (SELECT id
+						 FROM user_audit_log
+						 WHERE created_at < $1
+						 ORDER BY created_at
+						 LIMIT $2)`
+	expiredBefore := time.Now().Add(-p.cfg.UserAuditLogRetention)
+	for ctx.Err() == nil {
+		deleted, err := storage.Exec(ctx, p.db, sql, expiredBefore, userAuditLogCleanupBatchSize)
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete user audit log entries older than %v", expiredBefore)
+		}
+		if deleted < userAuditLogCleanupBatchSize {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ctx.Err(), "unexpected deadline")
+}
+
+func (r *repository) insertUserAuditLogEntry(ctx context.Context, conn storage.Execer, us *UserSnapshot) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	changedFields, before, after, err := diffUserSnapshot(us)
+	if err != nil || len(changedFields) == 0 {
+		return errors.Wrapf(err, "failed to diff user snapshot for userID:%v", us.ID)
+	}
+	actor := auditActor(ctx)
+	var actorID *string
+	if actor.ID != "" {
+		actorID = &actor.ID
+	}
+	sql := `INSERT INTO user_audit_log (created_at, user_id, actor_type, actor_id, source, changed_fields, before, after)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	args := []any{time.Now().Time, us.ID, actor.Type, actorID, actor.Source, changedFields, before, after}
+	if _, err = storage.Exec(ctx, conn, sql, args...); err != nil {
+		return errors.Wrapf(err, "failed to insert user audit log entry for userID:%v, actor:%#v", us.ID, actor)
+	}
+
+	return nil
+}
+
+// The diff is done on the json representation of the snapshot, so the changed fields match the names used by the API.
+// Only the changed fields are kept in before/after.
+func diffUserSnapshot(us *UserSnapshot) (changedFields []string, before, after map[string]any, err error) {
+	if us.User == nil || us.Before == nil {
+		return nil, nil, nil, nil
+	}
+	if before, err = userAsMap(us.Before); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to convert before user to map")
+	}
+	if after, err = userAsMap(us.User); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to convert after user to map")
+	}
+	for field := range after {
+		if _, found := before[field]; !found {
+			before[field] = nil
+		}
+	}
+	for field, beforeValue := range before {
+		afterValue, found := after[field]
+		if field == "updatedAt" || (found && reflect.DeepEqual(beforeValue, afterValue)) {
+			delete(before, field)
+			delete(after, field)
+
+			continue
+		}
+		changedFields = append(changedFields, field)
+	}
+	sort.Strings(changedFields)
+
+	return changedFields, before, after, nil
+}
+
+func userAsMap(usr *User) (map[string]any, error) {
+	data, err := json.Marshal(usr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal user:%#v", usr)
+	}
+	var res map[string]any
+	if err = json.Unmarshal(data, &res); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal user:%v", string(data))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// BlockUser hides the two users from each other and removes them from each other's agenda contacts.
+// Unblocking doesn't restore the contacts, they're matched again on the next contacts sync.
+func (r *repository) BlockUser(ctx context.Context, userID, blockedUserID UserID) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "block user failed because context failed")
+	}
+	if userID == blockedUserID {
+		return errors.Wrapf(ErrTargetingYourself, "userID:%v", userID)
+	}
+
+	return errors.Wrapf(storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		sql := `INSERT INTO user_blocks (created_at, user_id, blocked_user_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+		if _, err := storage.Exec(ctx, conn, sql, time.Now().Time, userID, blockedUserID); err != nil {
+			if storage.IsErr(err, storage.ErrRelationNotFound) {
+				err = ErrNotFound
+			}
+
+			return errors.Wrap(err, "failed to insert user block")
+		}
+		for _, pair := range [][2]UserID{{userID, blockedUserID}, {blockedUserID, userID}} {
+			if err := r.removeAgendaContact(ctx, conn, pair[0], pair[1]); err != nil {
+				return errors.Wrapf(err, "failed to remove contact %v of userID:%v", pair[1], pair[0])
+			}
+		}
+
+		return nil
+	}), "failed to block userID:%v for userID:%v", blockedUserID, userID)
+}
+
+func (r *repository) removeAgendaContact(ctx context.Context, conn storage.QueryExecer, userID, contactUserID UserID) error {
+	sql := `UPDATE users
+			SET agenda_contact_user_ids = array_remove(agenda_contact_user_ids, $2)
+			WHERE id = $1
+				  AND $2 = ANY(agenda_contact_user_ids)`
+	if updated, err := storage.Exec(ctx, conn, sql, userID, contactUserID); err != nil || updated == 0 {
+		return errors.Wrap(err, "failed to remove agenda contact")
+	}
+	if _, err := r.incrementContactsSyncVersion(ctx, conn, userID); err != nil {
+		return errors.Wrap(err, "failed to increment contacts sync version")
+	}
+
+	return errors.Wrap(r.enqueueContactMessage(ctx, conn, &Contact{UserID: userID, ContactUserID: contactUserID, Deleted: true}),
+		"failed to enqueue removed contact message")
+}
+
+func (r *repository) UnblockUser(ctx context.Context, userID, blockedUserID UserID) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unblock user failed because context failed")
+	}
+	sql := `DELETE FROM user_blocks WHERE user_id = $1 AND blocked_user_id = $2`
+	_, err := storage.Exec(ctx, r.db, sql, userID, blockedUserID)
+
+	return errors.Wrapf(err, "failed to unblock userID:%v for userID:%v", blockedUserID, userID)
+}
+
+// ReportUser stores the report and sends it to moderation, through the outbox.
+func (r *repository) ReportUser(ctx context.Context, report *UserReport) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "report user failed because context failed")
+	}
+	if report.ReporterUserID == report.ReportedUserID {
+		return errors.Wrapf(ErrTargetingYourself, "userID:%v", report.ReporterUserID)
+	}
+	if !slices.Contains(UserReportReasons, report.Reason) {
+		return errors.Wrapf(ErrInvalidUserReportReason, "reason `%v` is not one of %v", report.Reason, UserReportReasons)
+	}
+	report.CreatedAt = time.Now()
+
+	return errors.Wrapf(storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		// The reported user isn't a foreign key, so the reports are kept after it's deleted; it has to exist when reported though.
+		sql := `INSERT INTO user_reports (created_at, reporter_user_id, reported_user_id, reason, comment)
+				SELECT $1::timestamp, $2, $3, $4, $5
+				WHERE EXISTS (SELECT 1 FROM users WHERE id = $3)
+				RETURNING id`
+		res, err := storage.ExecOne[struct{ ID int64 }](ctx, conn, sql,
+			report.CreatedAt.Time, report.ReporterUserID, report.ReportedUserID, report.Reason, report.Comment)
+		if err != nil {
+			if storage.IsErr(err, storage.ErrRelationNotFound) {
+				err = ErrNotFound
+			}
+
+			return errors.Wrap(err, "failed to insert user report, reporter or reported user not found")
+		}
+		report.ID = res.ID
+		valueBytes, err := json.MarshalContext(ctx, report)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal %#v", report)
+		}
+
+		return errors.Wrap(r.enqueueOutboxMessage(ctx, conn, r.cfg.MessageBroker.Topics[5].Name, report.ReportedUserID, valueBytes),
+			"failed to enqueue user report message")
+	}), "failed to report userID:%v by userID:%v", report.ReportedUserID, report.ReporterUserID)
+}
+
+func (r *repository) GetUserReports(ctx context.Context, reportedUserID UserID, limit uint64, cursor Cursor) (*UserReports, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "failed to get user reports because of context failed")
+	}
+	pageCur, err := pagination.Decode(cursor)
+	if err == nil && pageCur != nil && pageCur.Rank <= 0 {
+		err = errors.Wrapf(ErrInvalidCursor, "cursor:%v is missing id", cursor)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid user reports cursor for reportedUserID:%v", reportedUserID)
+	}
+	args := []any{limit}
+	var conditions string
+	if reportedUserID != "" {
+		args = append(args, reportedUserID)
+		conditions += fmt.Sprintf(" AND reported_user_id = $%v", len(args))
+	}
+	if pageCur != nil {
+		args = append(args, pageCur.Rank)
+		conditions += fmt.Sprintf(" AND id < $%v", len(args))
+	}
+	sql := `SELECT id,
+				   created_at,
+				   reporter_user_id,
+				   reported_user_id,
+				   reason,
+				   comment
+			FROM user_reports
+			WHERE 1=1` + conditions + `
+			ORDER BY id DESC
+			LIMIT $1`
+	reports, err := storage.Select[UserReport](ctx, r.db, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select user reports for reportedUserID:%v", reportedUserID)
+	}
+	res := &UserReports{Reports: reports}
+	if res.Reports == nil {
+		res.Reports = make([]*UserReport, 0)
+	}
+	if limit > 0 && uint64(len(reports)) == limit {
+		res.NextCursor = pagination.Encode(&pageCursor{Rank: reports[len(reports)-1].ID})
+	}
+
+	return res, nil
+}
+
+// The condition holds if neither of the two users blocked the other one.
+func notBlockedSQLCondition(userIDColumn, otherUserIDColumn string) string {
+	return fmt.Sprintf(`NOT EXISTS (SELECT 1
+							FROM user_blocks ub
+							WHERE (ub.user_id = %[1]v AND ub.blocked_user_id = %[2]v)
+								  OR (ub.user_id = %[2]v AND ub.blocked_user_id = %[1]v))`, userIDColumn, otherUserIDColumn)
+}
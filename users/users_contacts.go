@@ -4,31 +4,169 @@ package users
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
 
-	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+	"github.com/ice-blockchain/eskimo/users/internal/agenda"
+	"github.com/ice-blockchain/eskimo/users/internal/versioning"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
 )
 
+// SyncContacts replaces the agenda contacts of the user (`replace` mode) or applies the provided additions and removals to them (`delta` mode).
+// Every sync that changes the contacts increments the version, is recorded in the audit log and emits one contact message per added or removed contact.
+//
+//nolint:funlen // It's easier to follow in one place.
+func (r *repository) SyncContacts(ctx context.Context, userID UserID, sync *ContactsSync) (*ContactsSyncResult, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "sync contacts failed because context failed")
+	}
+	if !slices.Contains(ContactsSyncModes, sync.Mode) {
+		return nil, errors.Wrapf(ErrInvalidContactsSyncMode, "mode `%v` is not one of %v", sync.Mode, ContactsSyncModes)
+	}
+	var result *ContactsSyncResult
+	err := storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		oldUsr, tErr := getUserByIDForUpdate(ctx, conn, userID)
+		if tErr != nil {
+			return errors.Wrapf(tErr, "get user %v failed", userID)
+		}
+		current, tErr := getContactsSyncState(ctx, conn, userID)
+		if tErr != nil {
+			return errors.Wrapf(tErr, "failed to get contacts sync state for userID:%v", userID)
+		}
+		if sync.Mode == DeltaContactsSyncMode && sync.Version != current.Version {
+			return errors.Wrapf(ErrContactsSyncVersionMismatch, "expected version %v, got %v", current.Version, sync.Version)
+		}
+		next, tErr := r.nextAgendaContactIDs(ctx, conn, userID, current.AgendaContactUserIDs, sync)
+		if tErr != nil {
+			return errors.Wrapf(tErr, "failed to resolve next agenda contacts for userID:%v", userID)
+		}
+		if uint64(len(next)) > r.cfg.MaxContactsPerUser {
+			return errors.Wrapf(ErrTooManyContacts, "%v contacts for userID:%v, max is %v", len(next), userID, r.cfg.MaxContactsPerUser)
+		}
+		added, removed := agenda.Diff(current.AgendaContactUserIDs, next)
+		result = &ContactsSyncResult{Version: current.Version, ContactCount: uint64(len(next))}
+		if len(added) == 0 && len(removed) == 0 {
+			return nil
+		}
+		now := versioning.Now()
+		if _, tErr = storage.Exec(ctx, conn, `UPDATE users SET agenda_contact_user_ids = $2, updated_at = $3 WHERE id = $1`, userID, next, now.Time); tErr != nil {
+			return errors.Wrapf(tErr, "failed to update agenda contacts for userID:%v", userID)
+		}
+		if result.Version, tErr = r.incrementContactsSyncVersion(ctx, conn, userID); tErr != nil {
+			return errors.Wrapf(tErr, "failed to increment contacts sync version for userID:%v", userID)
+		}
+		for _, contact := range buildContactChanges(userID, added, removed) {
+			if cErr := r.enqueueContactMessage(ctx, conn, contact); cErr != nil {
+				return errors.Wrapf(cErr, "can't enqueue contacts message for userID:%v", userID)
+			}
+		}
+		before, after := *oldUsr, *oldUsr
+		before.AgendaContactUserIDs, after.AgendaContactUserIDs, after.UpdatedAt = current.AgendaContactUserIDs, next, now
+		us := &UserSnapshot{User: r.sanitizeUser(&after), Before: r.sanitizeUser(&before)}
+		if aErr := r.insertUserAuditLogEntry(ctx, conn, us); aErr != nil {
+			return errors.Wrapf(aErr, "failed to insert user audit log entry for userID:%v", userID)
+		}
+
+		return errors.Wrapf(r.enqueueUserSnapshotMessage(ctx, conn, us), "failed to enqueue updated user snapshot message %#v", us)
+	})
+
+	return result, errors.Wrapf(err, "failed to sync contacts for userID:%v, mode:%v", userID, sync.Mode)
+}
+
+func getContactsSyncState(ctx context.Context, conn storage.Querier, userID UserID) (*contactsSyncState, error) {
+	sql := `SELECT COALESCE(u.agenda_contact_user_ids,'{}'::TEXT[]) AS agenda_contact_user_ids,
+				   COALESCE(s.version, 0) 						  AS version
+			FROM users u
+				LEFT JOIN agenda_contacts_sync s
+					   ON s.user_id = u.id
+			WHERE u.id = $1
+			FOR UPDATE OF u`
+	res, err := storage.ExecOne[contactsSyncState](ctx, conn, sql, userID)
+
+	return res, errors.Wrapf(err, "can't get contacts sync state for userID:%v", userID)
+}
+
+func (r *repository) nextAgendaContactIDs(
+	ctx context.Context, conn storage.Querier, userID UserID, current []UserID, sync *ContactsSync,
+) ([]UserID, error) {
+	if sync.Mode == ReplaceContactsSyncMode {
+		return r.findUserIDsByPhoneNumberHashes(ctx, conn, userID, sync.PhoneNumberHashes)
+	}
+	removed, err := r.findUserIDsByPhoneNumberHashes(ctx, conn, userID, sync.RemovedPhoneNumberHashes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find removed contacts")
+	}
+	added, err := r.findUserIDsByPhoneNumberHashes(ctx, conn, userID, sync.AddedPhoneNumberHashes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find added contacts")
+	}
+
+	return agenda.ApplyDelta(current, removed, added), nil
+}
+
+func (r *repository) findUserIDsByPhoneNumberHashes(ctx context.Context, conn storage.Querier, userID UserID, hashes []string) ([]UserID, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	sql := `SELECT DISTINCT id FROM users WHERE phone_number_hash = ANY($1) AND id != $2 AND ` + notBlockedSQLCondition("$2", "id")
+	contactIDs, err := storage.Select[UserID](ctx, conn, sql, hashes, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get user ids by agenda hashes:%#v for userID:%v", hashes, userID)
+	}
+	res := make([]UserID, 0, len(contactIDs))
+	for _, contactID := range contactIDs {
+		res = append(res, *contactID)
+	}
+
+	return res, nil
+}
+
+func (r *repository) incrementContactsSyncVersion(ctx context.Context, conn storage.Querier, userID UserID) (uint64, error) {
+	sql := `INSERT INTO agenda_contacts_sync (user_id, version, synced_at) VALUES ($1, 1, $2)
+			ON CONFLICT (user_id) DO UPDATE
+				SET version = agenda_contacts_sync.version + 1,
+					synced_at = EXCLUDED.synced_at
+			RETURNING version`
+	res, err := storage.ExecOne[struct{ Version uint64 }](ctx, conn, sql, userID, time.Now().Time)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to upsert agenda_contacts_sync for userID:%v", userID)
+	}
+
+	return res.Version, nil
+}
+
+func buildContactChanges(userID UserID, added, removed []UserID) []*Contact {
+	contacts := make([]*Contact, 0, len(added)+len(removed))
+	for _, contactUserID := range added {
+		contacts = append(contacts, &Contact{UserID: userID, ContactUserID: contactUserID})
+	}
+	for _, contactUserID := range removed {
+		contacts = append(contacts, &Contact{UserID: userID, ContactUserID: contactUserID, Deleted: true})
+	}
+
+	return contacts
+}
+
 //nolint:funlen,gocritic,revive // It needs a better breakdown.
-func (r *repository) findAgendaContactIDs(ctx context.Context, usr *User) ([]UserID, []UserID, []*Contact, error) {
+func (r *repository) findAgendaContactIDs(ctx context.Context, usr *User) ([]UserID, []*Contact, error) {
 	if usr.AgendaPhoneNumberHashes == nil || *usr.AgendaPhoneNumberHashes == "" {
-		return nil, nil, nil, nil
+		return nil, nil, nil
 	}
 	before, err := r.getAgendaContacts(ctx, usr.ID)
 	if err != nil && !storage.IsErr(err, storage.ErrNotFound) {
-		return nil, nil, nil, errors.Wrapf(err, "can't get contacts for user id: %v", usr.ID)
+		return nil, nil, errors.Wrapf(err, "can't get contacts for user id: %v", usr.ID)
 	}
-	sql := `SELECT id FROM users WHERE phone_number_hash = ANY($1)`
-	contactIDs, err := storage.Select[UserID](ctx, r.db, sql, strings.Split(*usr.AgendaPhoneNumberHashes, ","))
+	sql := `SELECT id FROM users WHERE phone_number_hash = ANY($1) AND ` + notBlockedSQLCondition("$2", "id")
+	contactIDs, err := storage.Select[UserID](ctx, r.db, sql, strings.Split(*usr.AgendaPhoneNumberHashes, ","), usr.ID)
 	if err != nil {
-		return nil, nil, nil, errors.Wrapf(err, "can't get user ids by agenda hashes:%#v for userID:%v", *usr.AgendaPhoneNumberHashes, usr.ID)
+		return nil, nil, errors.Wrapf(err, "can't get user ids by agenda hashes:%#v for userID:%v", *usr.AgendaPhoneNumberHashes, usr.ID)
 	}
 	if len(contactIDs) == 0 {
-		return before, nil, nil, nil
+		return nil, nil, nil
 	}
 	var toUpsert, unique []UserID
 	if before != nil {
@@ -41,7 +179,10 @@ func (r *repository) findAgendaContactIDs(ctx context.Context, usr *User) ([]Use
 	}
 	toUpsert = append(toUpsert, unique...)
 	if len(unique) == 0 {
-		return before, nil, nil, nil
+		return nil, nil, nil
+	}
+	if uint64(len(toUpsert)) > r.cfg.MaxContactsPerUser {
+		return nil, nil, errors.Wrapf(ErrTooManyContacts, "%v contacts for userID:%v, max is %v", len(toUpsert), usr.ID, r.cfg.MaxContactsPerUser)
 	}
 	contacts := make([]*Contact, 0, len(unique))
 	for _, contactUserID := range unique {
@@ -51,7 +192,7 @@ func (r *repository) findAgendaContactIDs(ctx context.Context, usr *User) ([]Use
 		})
 	}
 
-	return before, toUpsert, contacts, nil
+	return toUpsert, contacts, nil
 }
 
 func contactDiff(fromTable []UserID, fromRequest []*UserID) []UserID {
@@ -86,21 +227,12 @@ func (r *repository) getAgendaContacts(ctx context.Context, userID UserID) ([]Us
 	return res.AgendaContactUserIDs, nil
 }
 
-func (r *repository) sendContactMessage(ctx context.Context, contact *Contact) error {
+func (r *repository) enqueueContactMessage(ctx context.Context, conn storage.Execer, contact *Contact) error {
 	valueBytes, err := json.MarshalContext(ctx, contact)
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal %#v", contact)
 	}
-	msg := &messagebroker.Message{
-		Headers: map[string]string{"producer": "eskimo"},
-		Key:     contact.UserID,
-		Topic:   r.cfg.MessageBroker.Topics[4].Name,
-		Value:   valueBytes,
-	}
-
-	responder := make(chan error, 1)
-	defer close(responder)
-	r.mb.SendMessage(ctx, msg, responder)
 
-	return errors.Wrapf(<-responder, "failed to send contacts message to broker")
+	return errors.Wrapf(r.enqueueOutboxMessage(ctx, conn, r.cfg.MessageBroker.Topics[4].Name, contact.UserID, valueBytes),
+		"failed to enqueue contacts message")
 }
@@ -6,18 +6,43 @@ import (
 	"context"
 	"net"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 
 	"github.com/ice-blockchain/eskimo/users/internal/device"
+	"github.com/ice-blockchain/eskimo/users/internal/versioning"
 	"github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/log"
 	"github.com/ice-blockchain/wintr/terror"
-	"github.com/ice-blockchain/wintr/time"
 )
 
-//nolint:funlen,lll // A lot of SQL params.
+// CreateUser creates the user only if the risk policy allows sign ups from the client IP.
+// If the policy requires a challenge, the user is created, but flagged.
+// The assessment is recorded only if the user was created or if the policy rejected it, in which case there's no account to tie it to.
 func (r *repository) CreateUser(ctx context.Context, usr *User, clientIP net.IP) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "create user failed because context failed")
+	}
+	ipRisk, err := r.AssessIPRisk(ctx, SignUpIPRiskRoute, &device.ID{UserID: usr.ID}, clientIP)
+	if err != nil {
+		if errors.Is(err, ErrIPRejected) {
+			ipRisk.UserID = ""
+			log.Error(errors.Wrapf(r.RecordIPRisk(ctx, ipRisk), "failed to record ip risk of rejected %v", clientIP))
+		}
+
+		return errors.Wrapf(err, "failed to assess the ip risk of %v for user %#v", clientIP, usr)
+	}
+	if err = r.createUser(ctx, usr, clientIP); err != nil {
+		return err
+	}
+	if err = r.RecordIPRisk(ctx, ipRisk); err != nil {
+		log.Error(errors.Wrapf(err, "failed to record ip risk for userID:%v", usr.ID))
+	}
+
+	return nil
+}
+
+//nolint:funlen,lll // A lot of SQL params.
+func (r *repository) createUser(ctx context.Context, usr *User, clientIP net.IP) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "create user failed because context failed")
 	}
@@ -32,22 +57,26 @@ func (r *repository) CreateUser(ctx context.Context, usr *User, clientIP net.IP)
 		usr.PhoneNumber, usr.PhoneNumberHash, usr.Username, usr.ReferredBy, usr.RandomReferredBy, usr.ClientData, usr.ProfilePictureURL, usr.Country,
 		usr.City, usr.Language, usr.CreatedAt.Time, usr.UpdatedAt.Time, usr.lookup(),
 	}
-	if _, err := storage.Exec(ctx, r.db, sql, args...); err != nil {
-		field, tErr := detectAndParseDuplicateDatabaseError(err)
-		if field == usernameDBColumnName {
-			return r.CreateUser(ctx, usr, clientIP)
+	snapshot := *usr
+	us := &UserSnapshot{User: r.sanitizeUser(&snapshot), Before: nil}
+	var duplicateField string
+	if err := storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		if _, err := storage.Exec(ctx, conn, sql, args...); err != nil {
+			var tErr error
+			duplicateField, tErr = detectAndParseDuplicateDatabaseError(err)
+
+			return errors.Wrapf(tErr, "failed to insert user %#v", usr)
 		}
 
-		return errors.Wrapf(tErr, "failed to insert user %#v", usr)
-	}
-	us := &UserSnapshot{User: r.sanitizeUser(usr), Before: nil}
-	if err := errors.Wrapf(r.sendUserSnapshotMessage(ctx, us), "failed to send user created message for %#v", usr); err != nil {
-		revertCtx, revertCancel := context.WithTimeout(context.Background(), requestDeadline)
-		defer revertCancel()
+		return errors.Wrapf(r.enqueueUserSnapshotMessage(ctx, conn, us), "failed to enqueue user created message for %#v", usr)
+	}); err != nil {
+		if duplicateField == usernameDBColumnName {
+			return r.createUser(ctx, usr, clientIP)
+		}
 
-		return multierror.Append(errors.Wrapf(err, "failed to send user created message for %#v", usr), //nolint:wrapcheck // Not needed.
-			errors.Wrapf(r.deleteUser(revertCtx, usr), "failed to delete user due to rollback, for userID:%v", usr.ID)).ErrorOrNil() //nolint:contextcheck // .
+		return errors.Wrapf(err, "failed to create user %#v", usr)
 	}
+	*usr = *us.User
 	hashCode := usr.HashCode
 	r.sanitizeUserForUI(usr)
 	usr.HashCode = hashCode
@@ -56,7 +85,7 @@ func (r *repository) CreateUser(ctx context.Context, usr *User, clientIP net.IP)
 }
 
 func (r *repository) setCreateUserDefaults(ctx context.Context, usr *User, clientIP net.IP) {
-	usr.CreatedAt = time.Now()
+	usr.CreatedAt = versioning.Now()
 	usr.UpdatedAt = usr.CreatedAt
 	usr.DeviceLocation = *r.GetDeviceMetadataLocation(ctx, &device.ID{UserID: usr.ID}, clientIP)
 	usr.ProfilePictureURL = RandomDefaultProfilePictureName()
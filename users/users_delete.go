@@ -4,15 +4,59 @@ package users
 
 import (
 	"context"
+	"math/rand"
 	"sync"
+	stdlibtime "time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/referral/reassignment"
+	"github.com/ice-blockchain/wintr/auth"
 	"github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+	"github.com/ice-blockchain/wintr/time"
 )
 
 func (r *repository) DeleteUser(ctx context.Context, userID UserID) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "context failed")
+	}
+	if r.cfg.DeletionGracePeriod == 0 {
+		return errors.Wrapf(r.purgeUser(ctx, userID, nil), "failed to purgeUser for userID:%v", userID)
+	}
+	sql := `UPDATE users
+				SET pending_deletion_at = COALESCE(pending_deletion_at, $2)
+			WHERE id = $1`
+	if updated, err := storage.Exec(ctx, r.db, sql, userID, time.Now().Time); err != nil || updated == 0 {
+		if err == nil {
+			err = ErrNotFound
+		}
+
+		return errors.Wrapf(err, "failed to mark user as pending deletion for userID:%v", userID)
+	}
+
+	return nil
+}
+
+// RestoreUser works only within the grace period. Afterwards, the user is about to be purged, so it's too late.
+func (r *repository) RestoreUser(ctx context.Context, userID UserID) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "context failed")
+	}
+	sql := `UPDATE users
+				SET pending_deletion_at = NULL
+			WHERE id = $1
+				  AND pending_deletion_at IS NOT NULL
+				  AND pending_deletion_at >= $2`
+	_, err := storage.Exec(ctx, r.db, sql, userID, time.Now().Add(-r.cfg.DeletionGracePeriod))
+
+	return errors.Wrapf(err, "failed to restore user pending deletion for userID:%v", userID)
+}
+
+// If pendingDeletionBefore is provided, the user is purged only if it's still pending deletion since before that,
+// so the users restored in the meantime are left alone.
+func (r *repository) purgeUser(ctx context.Context, userID UserID, pendingDeletionBefore *time.Time) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "context failed")
 	}
@@ -20,21 +64,77 @@ func (r *repository) DeleteUser(ctx context.Context, userID UserID) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to get user for userID:%v", userID)
 	}
-	if err = r.deleteUser(ctx, gUser); err != nil {
-		return errors.Wrapf(err, "failed to deleteUser for:%#v", gUser)
+	if !isPendingDeletionSince(gUser, pendingDeletionBefore) {
+		return nil
 	}
-	u := &UserSnapshot{Before: r.sanitizeUser(gUser)}
-	if err = r.sendUserSnapshotMessage(ctx, u); err != nil {
-		return errors.Wrapf(err, "failed to send deleted user message for %#v", u)
+	if err = r.deleteUser(ctx, gUser, pendingDeletionBefore); err != nil {
+		if errors.Is(err, errUserRestored) {
+			return nil
+		}
+
+		return errors.Wrapf(err, "failed to deleteUser for:%#v", gUser)
 	}
-	if err = r.sendTombstonedUserMessage(ctx, userID); err != nil {
-		return errors.Wrapf(err, "failed to sendTombstonedUserMessage for userID:%v", userID)
+	if r.authClient != nil {
+		if err = r.authClient.DeleteUser(ctx, userID); err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+			return errors.Wrapf(err, "failed to delete auth user for userID:%v", userID)
+		}
 	}
 
 	return nil
 }
 
-func (r *repository) deleteUser(ctx context.Context, usr *User) error { //nolint:revive // .
+func (p *processor) startPendingDeletionsPurger(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(stdlibtime.Duration(1+rand.Intn(10)) * stdlibtime.Minute) //nolint:gosec,gomnd // Not an  issue.
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			const deadline = 5 * stdlibtime.Minute
+			reqCtx, cancel := context.WithTimeout(ctx, deadline)
+			log.Error(errors.Wrap(p.purgeExpiredPendingDeletions(reqCtx), "failed to purgeExpiredPendingDeletions"))
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *processor) purgeExpiredPendingDeletions(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	const batchSize = 100
+	type expired struct {
+		ID UserID
+	}
+	sql := `SELECT id
+			FROM users
+			WHERE pending_deletion_at IS NOT NULL
+				  AND pending_deletion_at < $1
+			ORDER BY pending_deletion_at
+			LIMIT $2`
+	pendingDeletionBefore := time.New(time.Now().Add(-p.cfg.DeletionGracePeriod))
+	res, err := storage.Select[expired](ctx, p.db, sql, pendingDeletionBefore.Time, batchSize)
+	if err != nil {
+		return errors.Wrap(err, "failed to select users with expired pending deletion")
+	}
+	var mErr *multierror.Error
+	for _, usr := range res {
+		if pErr := p.purgeUser(ctx, usr.ID, pendingDeletionBefore); pErr != nil && !storage.IsErr(pErr, storage.ErrNotFound) {
+			mErr = multierror.Append(mErr, errors.Wrapf(pErr, "failed to purgeUser for userID:%v", usr.ID))
+		}
+	}
+
+	return mErr.ErrorOrNil() //nolint:wrapcheck // Not needed.
+}
+
+func isPendingDeletionSince(usr *User, pendingDeletionBefore *time.Time) bool {
+	return pendingDeletionBefore == nil || (usr.PendingDeletionAt != nil && usr.PendingDeletionAt.Before(*pendingDeletionBefore.Time))
+}
+
+//nolint:funlen // .
+func (r *repository) deleteUser(ctx context.Context, usr *User, pendingDeletionBefore *time.Time) error { //nolint:revive // .
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "delete user failed because context failed")
 	}
@@ -54,10 +154,35 @@ func (r *repository) deleteUser(ctx context.Context, usr *User) error { //nolint
 		return errors.Wrapf(err, "failed to get user for userID:%v", usr.ID)
 	}
 	*usr = *gUser
+	if !isPendingDeletionSince(usr, pendingDeletionBefore) {
+		return errUserRestored
+	}
 	sql := `DELETE FROM users WHERE id = $1`
-	if _, tErr := storage.Exec(ctx, r.db, sql, usr.ID); tErr != nil {
+	args := []any{usr.ID}
+	if pendingDeletionBefore != nil {
+		sql += ` AND pending_deletion_at < $2`
+		args = append(args, pendingDeletionBefore.Time)
+	}
+	u := &UserSnapshot{Before: r.sanitizeUser(gUser)}
+	if tErr := storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		if deleted, dErr := storage.Exec(ctx, conn, sql, args...); dErr != nil || (deleted == 0 && pendingDeletionBefore != nil) {
+			if dErr == nil {
+				dErr = errUserRestored
+			}
+
+			return errors.Wrapf(dErr, "failed to delete user with id %v", usr.ID)
+		}
+		if _, dErr := storage.Exec(ctx, conn, `DELETE FROM user_audit_log WHERE user_id = $1`, usr.ID); dErr != nil {
+			return errors.Wrapf(dErr, "failed to delete user audit log of userID:%v", usr.ID)
+		}
+		if eErr := r.enqueueUserSnapshotMessage(ctx, conn, u); eErr != nil {
+			return errors.Wrapf(eErr, "failed to enqueue deleted user message for %#v", u)
+		}
+
+		return errors.Wrapf(r.enqueueTombstonedUserMessage(ctx, conn, usr.ID), "failed to enqueueTombstonedUserMessage for userID:%v", usr.ID)
+	}); tErr != nil {
 		if storage.IsErr(tErr, storage.ErrRelationNotFound) {
-			return r.deleteUser(ctx, usr)
+			return r.deleteUser(ctx, usr, pendingDeletionBefore)
 		}
 
 		return errors.Wrapf(tErr, "failed to delete user with id %v", usr.ID)
@@ -88,11 +213,78 @@ func (r *repository) deleteUserReferences(ctx context.Context, userID UserID) er
 	return multierror.Append(nil, errs...).ErrorOrNil() //nolint:wrapcheck // Not needed.
 }
 
-//nolint:funlen // It's better to isolate everything together to decrease complexity; and it has some SQL, so...
+func (r *repository) mustCreateReferralReassignmentStrategy() reassignment.Strategy {
+	strategy, err := reassignment.New(&r.cfg.ReferralReassignment, r)
+	log.Panic(errors.Wrapf(err, "invalid referralReassignment config %#v", r.cfg.ReferralReassignment)) //nolint:revive // That's intended.
+
+	return strategy
+}
+
+// The T1 referrals of the deleted user get a new referrer, chosen by the configured reassignment strategy.
+// The deleted user is re-read every time, because its own referrer might have been deleted (and replaced) in the meantime.
 func (r *repository) updateReferredByForAllT1Referrals(ctx context.Context, userID UserID) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "context failed")
 	}
+	sql := `SELECT u.id,
+				   d.referred_by
+			FROM users u
+				JOIN users d
+				  ON d.id = $1
+			WHERE u.referred_by = $1
+				AND u.id != $1`
+	type orphan struct {
+		ID         UserID `db:"id"`
+		ReferredBy UserID `db:"referred_by"`
+	}
+	res, err := storage.Select[orphan](ctx, r.db, sql, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to select all t1 referrals of userID:%v", userID)
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	orphanIDs := make([]UserID, 0, len(res))
+	for _, o := range res {
+		orphanIDs = append(orphanIDs, o.ID)
+	}
+	deleted := &reassignment.DeletedUser{ID: userID, ReferredBy: res[0].ReferredBy}
+	newReferredBy, err := r.referralReassignment.Reassign(ctx, deleted, orphanIDs)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reassign the t1 referrals of userID:%v using strategy:%v", userID, r.referralReassignment.Name())
+	}
+	ctx = ContextWithAuditActor(ctx, &AuditActor{Type: InternalAuditActorType, Source: "referralReassignment"})
+	strategy := r.referralReassignment.Name()
+	wg := new(sync.WaitGroup)
+	wg.Add(len(orphanIDs))
+	errChan := make(chan error, len(orphanIDs))
+	for _, orphanID := range orphanIDs {
+		go func(id UserID) {
+			defer wg.Done()
+			randomReferredBy := reassignment.IsRandom(strategy, id, newReferredBy[id])
+			updatedReferral := new(User)
+			updatedReferral.ID = id
+			updatedReferral.ReferredBy = newReferredBy[id]
+			updatedReferral.RandomReferredBy = &randomReferredBy
+			errChan <- errors.Wrapf(r.modifyUser(ctx, updatedReferral, nil, strategy),
+				"failed to update referred by for userID:%v", id)
+		}(orphanID)
+	}
+	wg.Wait()
+	close(errChan)
+	errs := make([]error, 0, len(orphanIDs))
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+
+	return errors.Wrap(multierror.Append(nil, errs...).ErrorOrNil(), "failed to update referred by for some/all of user's t1 referrals")
+}
+
+//nolint:funlen // It has some SQL, so...
+func (r *repository) PickRandomReferrers(ctx context.Context, deletedUserID UserID, orphanIDs []UserID) (map[UserID]UserID, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "context failed")
+	}
 	sql := `
 	WITH randomized AS (
 		SELECT id, referred_by, created_at, hash_code FROM users
@@ -124,40 +316,21 @@ func (r *repository) updateReferredByForAllT1Referrals(ctx context.Context, user
 				) new_referred_by,
 				u.ID as id
 		FROM users u
-		WHERE u.referred_by = $1
-			AND u.id != $1`
+		WHERE u.id = ANY($2)`
 	type resp struct {
 		NewReferredBy UserID
 		ID            UserID `db:"id"`
 	}
-	res, err := storage.Select[resp](ctx, r.db, sql, userID)
+	res, err := storage.Select[resp](ctx, r.db, sql, deletedUserID, orphanIDs)
 	if err != nil {
-		return errors.Wrapf(err, "failed to select for all t1 referrals of userID:%v + their new random referralID", userID)
+		return nil, errors.Wrapf(err, "failed to select new random referralIDs for the t1 referrals of userID:%v", deletedUserID)
 	}
-
-	wg := new(sync.WaitGroup)
-	wg.Add(len(res))
-	errChan := make(chan error, len(res))
-	for ii := range res {
-		go func(ix int) {
-			defer wg.Done()
-			valTrue := true
-			updatedReferral := new(User)
-			updatedReferral.ID = res[ix].ID
-			updatedReferral.ReferredBy = res[ix].NewReferredBy
-			updatedReferral.RandomReferredBy = &valTrue
-			errChan <- errors.Wrapf(r.ModifyUser(ctx, updatedReferral, nil),
-				"failed to update referred by for userID:%v", res[ix].ID)
-		}(ii)
-	}
-	wg.Wait()
-	close(errChan)
-	errs := make([]error, 0, len(res))
-	for err := range errChan {
-		errs = append(errs, err)
+	picked := make(map[UserID]UserID, len(res))
+	for _, row := range res {
+		picked[row.ID] = row.NewReferredBy
 	}
 
-	return errors.Wrap(multierror.Append(nil, errs...).ErrorOrNil(), "failed to update referred by for some/all of user's t1 referrals")
+	return picked, nil
 }
 
 func (r *repository) deleteUserTracking(ctx context.Context, usr *UserSnapshot) error {
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/dataexport"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+)
+
+// ExportUserData gathers the sections provided by every exporter into one versioned document.
+func ExportUserData(ctx context.Context, userID UserID, exporters ...UserDataExporter) (*UserDataExport, error) {
+	return dataexport.Gather(ctx, userID, exporters...) //nolint:wrapcheck // It's just a proxy.
+}
+
+// SelectUserDataExportSection runs every table query with the provided args and gathers the resulting rows into a section.
+func SelectUserDataExportSection(
+	ctx context.Context, db *storage.DB, name string, tableQueries map[string]string, args ...any,
+) (*UserDataExportSection, error) {
+	return dataexport.SelectSection(ctx, func(ctx context.Context, sql string, args ...any) ([]*map[string]any, error) { //nolint:wrapcheck // It's just a proxy.
+		return storage.Select[map[string]any](ctx, db, sql, args...) //nolint:wrapcheck // It's wrapped by the caller.
+	}, name, tableQueries, args...)
+}
+
+func (r *repository) ExportUserData(ctx context.Context, userID UserID) (*UserDataExportSection, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "export user data failed because context failed")
+	}
+	section, err := SelectUserDataExportSection(ctx, r.db, dataexport.UsersSectionName, dataexport.UsersTableQueries, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to export users data for userID:%v", userID)
+	}
+	if err = section.RequireRows("users"); err != nil {
+		return nil, errors.Wrapf(err, "user with id %v not found", userID)
+	}
+
+	return section, nil
+}
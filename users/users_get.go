@@ -6,24 +6,28 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
 	"github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/time"
 )
 
-func (r *repository) getUserByID(ctx context.Context, id UserID) (*User, error) {
-	if ctx.Err() != nil {
-		return nil, errors.Wrap(ctx.Err(), "get user failed because context failed")
-	}
-	result, err := storage.Get[User](ctx, r.db, `
+const selectUserByIDSQL = `
 	SELECT users.*,
 	       qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true AS quiz_completed
 		FROM users
 		LEFT JOIN quiz_sessions qs
 			ON qs.user_id = users.id
-		WHERE id = $1`, id)
+		WHERE id = $1`
+
+func (r *repository) getUserByID(ctx context.Context, id UserID) (*User, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "get user failed because context failed")
+	}
+	result, err := storage.Get[User](ctx, r.db, selectUserByIDSQL, id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get user by id %v", id)
 	}
@@ -31,6 +35,16 @@ func (r *repository) getUserByID(ctx context.Context, id UserID) (*User, error)
 	return result, nil
 }
 
+// The user stays locked until the transaction of conn ends.
+func getUserByIDForUpdate(ctx context.Context, conn storage.Querier, id UserID) (*User, error) {
+	result, err := storage.Get[User](ctx, conn, selectUserByIDSQL+` FOR UPDATE OF users`, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get user by id %v for update", id)
+	}
+
+	return result, nil
+}
+
 func (r *repository) GetUserByID(ctx context.Context, userID string) (*UserProfile, error) { //nolint:revive // Its fine.
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "get user failed because context failed")
@@ -68,6 +82,9 @@ func (r *repository) getOtherUserByID(ctx context.Context, userID string) (*User
 	if err != nil {
 		return nil, err
 	}
+	if usr.PendingDeletionAt != nil {
+		return nil, errors.Wrapf(ErrNotFound, "user %v is pending deletion", userID)
+	}
 	verified := usr.IsVerified()
 	*usr = User{
 		HiddenProfileElements: usr.HiddenProfileElements,
@@ -115,7 +132,8 @@ func (r *repository) getOtherUserByID(ctx context.Context, userID string) (*User
 	return resp, nil
 }
 
-func (r *repository) GetUserByUsername(ctx context.Context, username string) (*UserProfile, error) {
+// GetUserByUsername can, optionally, resolve a recently changed username to the account that released it.
+func (r *repository) GetUserByUsername(ctx context.Context, username string, resolvePrevious bool) (*UserProfile, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "get user failed because context failed")
 	}
@@ -125,7 +143,11 @@ func (r *repository) GetUserByUsername(ctx context.Context, username string) (*U
 		FROM users 
 		LEFT JOIN quiz_sessions qs
 			ON qs.user_id = users.id
-		WHERE username = $1`, username)
+		WHERE username = $1
+			  AND users.pending_deletion_at IS NULL`, username)
+	if resolvePrevious && errors.Is(err, ErrNotFound) {
+		result, err = r.getUserByPreviousUsername(ctx, username)
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get user by username %v", username)
 	}
@@ -158,6 +180,65 @@ func (r *repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber strin
 	return usr, nil
 }
 
+func (r *repository) SearchUsers(ctx context.Context, filter *UserSearchFilter, limit, offset uint64) ([]*User, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "search users failed because context failed")
+	}
+	conditions, args := filter.sqlConditions()
+	if len(conditions) == 0 {
+		return nil, errors.Wrapf(ErrNoSearchFilter, "at least one search filter is required: %#v", filter)
+	}
+	args = append(args, limit, offset)
+	sql := fmt.Sprintf(`SELECT u.*, (qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true) AS quiz_completed
+			FROM users u
+			LEFT JOIN quiz_sessions qs
+					ON qs.user_id = u.id
+			WHERE %v
+			ORDER BY u.created_at DESC, u.id
+			LIMIT $%v OFFSET $%v`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+	res, err := storage.Select[User](ctx, r.db, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to search users by %#v", filter)
+	}
+	for _, usr := range res {
+		r.sanitizeUser(usr)
+		r.sanitizeUserForUI(usr)
+	}
+
+	return res, nil
+}
+
+// Columns that aren't set yet hold the user id as a placeholder (for uniqueness), so those must never match.
+func (f *UserSearchFilter) sqlConditions() (conditions []string, args []any) {
+	if f == nil {
+		return nil, nil
+	}
+	for _, column := range []struct {
+		name, value string
+	}{
+		{name: "email", value: f.Email},
+		{name: "phone_number", value: f.PhoneNumber},
+		{name: "blockchain_account_address", value: f.BlockchainAccountAddress},
+		{name: "mining_blockchain_account_address", value: f.MiningBlockchainAccountAddress},
+		{name: "referred_by", value: f.ReferredBy},
+	} {
+		if column.value == "" {
+			continue
+		}
+		args = append(args, column.value)
+		conditions = append(conditions, fmt.Sprintf("u.%[1]v = $%[2]v AND u.%[1]v != u.id", column.name, len(args)))
+	}
+	if f.DeviceUniqueID != "" {
+		args = append(args, f.DeviceUniqueID)
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1
+										FROM device_metadata dm
+										WHERE dm.user_id = u.id
+										  AND dm.device_unique_id = $%v)`, len(args)))
+	}
+
+	return conditions, args
+}
+
 func (r *repository) IsEmailUsedBySomebodyElse(ctx context.Context, userID, email string) (bool, error) {
 	sql := `SELECT id FROM users where email = $1`
 	usr, err := storage.Get[struct{ ID string }](ctx, r.db, sql, email)
@@ -175,11 +256,43 @@ func (r *repository) IsEmailUsedBySomebodyElse(ctx context.Context, userID, emai
 	return true, nil
 }
 
+// GetUsers matches the keyword anywhere in the username or, to tolerate typos, by trigram similarity (pg_trgm).
+// Keywords shorter than a trigram only match the beginning of the username or of one of its `.` separated parts.
+// Results are ordered by the relationship with the requesting user first, then by exact username (part) matches and similarity.
+//
 //nolint:funlen // Big sql.
-func (r *repository) GetUsers(ctx context.Context, keyword string, limit, offset uint64) (result []*MinimalUserProfile, err error) {
+func (r *repository) GetUsers(
+	ctx context.Context, keyword string, limit, offset uint64, cursor Cursor,
+) (*MinimalUserProfiles, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "get users failed because context failed")
 	}
+	pageCur, err := pagination.Decode(cursor)
+	if err == nil && pageCur != nil && pageCur.Username == "" {
+		err = errors.Wrapf(ErrInvalidCursor, "cursor:%v is missing username", cursor)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid users cursor for keyword:%v", keyword)
+	}
+	relationRank := `((CASE WHEN u.id = u.user_requesting_this_referred_by THEN 8 ELSE 0 END)
+					+ (CASE WHEN phone_number_ != '' AND phone_number_ IS NOT NULL THEN 4 ELSE 0 END)
+					+ (CASE WHEN u.t0_id = u.user_requesting_this_id THEN 2 ELSE 0 END)
+					+ (CASE WHEN u.t0_referred_by = u.user_requesting_this_id THEN 1 ELSE 0 END))`
+	params := []any{
+		time.Now().Time,
+		strings.ReplaceAll(strings.ReplaceAll(strings.ToLower(keyword), "_", "\\_"), "%", "\\%"),
+		limit,
+		offset,
+		requestingUserID(ctx),
+		strings.ToLower(keyword),
+		fmt.Sprintf("'%v'", strings.ReplaceAll(strings.ToLower(keyword), "'", "''")),
+	}
+	var keysetCondition string
+	if pageCur != nil {
+		keysetCondition = fmt.Sprintf("AND (%v, u.match_score, u.username) < ($8, $9, $10)", relationRank)
+		params[3] = uint64(0)
+		params = append(params, pageCur.Rank, pageCur.Score, pageCur.Username)
+	}
 	sql := fmt.Sprintf(`
 			SELECT 
 			    (u.kyc_step_passed >= %[2]v AND u.quiz_completed) 				  AS verified,
@@ -192,7 +305,9 @@ func (r *repository) GetUsers(ctx context.Context, keyword string, limit, offset
 				u.profile_picture_url 									  		  AS profile_picture_name,
 				u.country 											  	  		  AS country,
 				u.city 													  		  AS city,
-			    u.referral_type 										  		  AS referral_type
+			    u.referral_type 										  		  AS referral_type,
+			    %[3]v 																  AS relation_rank,
+			    u.match_score 															  AS match_score
 			FROM (SELECT COALESCE(u.last_mining_ended_at,to_timestamp(1)) 		  AS last_mining_ended_at,
 				   (CASE
 						WHEN user_requesting_this.id != u.id AND (u.referred_by = user_requesting_this.id OR u.id = user_requesting_this.referred_by)
@@ -239,7 +354,9 @@ func (r *repository) GetUsers(ctx context.Context, keyword string, limit, offset
 				    user_requesting_this.referred_by                                                    AS user_requesting_this_referred_by,
 				    t0.referred_by                                                                      AS t0_referred_by,
 				    t0.id                                                                               AS t0_id,
-			        qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true AS quiz_completed
+			        qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true AS quiz_completed,
+			        ((CASE WHEN u.lookup @@ $7::tsquery THEN 1000 ELSE 0 END)
+			        	+ (similarity(lower(u.username), $6) * 1000)::int)                                AS match_score
 			FROM users u
 					 JOIN USERS t0
 						  ON t0.id = u.referred_by
@@ -252,27 +369,46 @@ func (r *repository) GetUsers(ctx context.Context, keyword string, limit, offset
 				     LEFT JOIN quiz_sessions qs
 					   ON qs.user_id = u.id
 			WHERE 
-					u.lookup @@ $2::tsquery
+					%[6]v
+				AND u.pending_deletion_at IS NULL
+				AND %[5]v
 				  ) u 
 				  WHERE referral_type != '' AND u.username != u.id AND u.referred_by != u.id
+				  %[4]v
 				  ORDER BY
-							u.id = u.user_requesting_this_referred_by DESC,
-							(phone_number_ != '' AND phone_number_ is not null) DESC,
-							u.t0_id = u.user_requesting_this_id DESC,
-							u.t0_referred_by = u.user_requesting_this_id DESC,
+							relation_rank DESC,
+							u.match_score DESC,
 							u.username DESC
-			LIMIT $3 OFFSET $4`, r.pictureClient.SQLAliasDownloadURL(`u.profile_picture_name`), LivenessDetectionKYCStep)
-	params := []any{
-		time.Now().Time,
-		strings.ReplaceAll(strings.ReplaceAll(strings.ToLower(keyword), "_", "\\_"), "%", "\\%"),
-		limit,
-		offset,
-		requestingUserID(ctx),
+			LIMIT $3 OFFSET $4`,
+		r.pictureClient.SQLAliasDownloadURL(`u.profile_picture_name`), LivenessDetectionKYCStep, relationRank, keysetCondition, notBlockedSQLCondition("$5", "u.id"),
+		usernameMatchSQLCondition(keyword))
+	type rankedMinimalUserProfile struct {
+		*MinimalUserProfile
+		RelationRank int64
+		MatchScore   int64
 	}
-	result, err = storage.Select[MinimalUserProfile](ctx, r.db, sql, params...)
-	if result == nil {
-		result = []*MinimalUserProfile{}
+	rows, err := storage.Select[rankedMinimalUserProfile](ctx, r.db, sql, params...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select for users by %#v", params...)
+	}
+	result := &MinimalUserProfiles{Users: make([]*MinimalUserProfile, 0, len(rows))}
+	for _, row := range rows {
+		result.Users = append(result.Users, row.MinimalUserProfile)
+	}
+	if limit > 0 && uint64(len(rows)) == limit {
+		last := rows[len(rows)-1]
+		result.NextCursor = pagination.Encode(&pageCursor{Username: last.Username, Rank: last.RelationRank, Score: last.MatchScore})
+	}
+
+	return result, nil
+}
+
+// The trigram index can't be used for keywords shorter than a trigram and they'd match most of the usernames anyway,
+// so they're matched as prefixes of the lookup keywords instead, which is indexed too.
+func usernameMatchSQLCondition(keyword string) string {
+	if utf8.RuneCountInString(keyword) < minTrigramKeywordLength {
+		return `u.lookup @@ ($7 || ':*')::tsquery`
 	}
 
-	return result, errors.Wrapf(err, "failed to select for users by %#v", params...)
+	return `(lower(u.username) LIKE '%' || $2::text || '%' OR lower(u.username) % $6)`
 }
@@ -9,15 +9,26 @@ import (
 	"strings"
 	stdlibtime "time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 
+	devicemetadata "github.com/ice-blockchain/eskimo/users/internal/device/metadata"
+	"github.com/ice-blockchain/eskimo/users/internal/referral/reassignment"
+	"github.com/ice-blockchain/eskimo/users/internal/versioning"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/time"
 )
 
-//nolint:funlen,gocognit,gocyclo,revive,cyclop // It needs a better breakdown.
 func (r *repository) ModifyUser(ctx context.Context, usr *User, profilePicture *multipart.FileHeader) error {
+	return r.modifyUser(ctx, usr, profilePicture, "")
+}
+
+// The referredBy can be changed a second time only if the new one was picked randomly or
+// if it's reassigned because the previous referrer was deleted (`reassignmentStrategy` is provided).
+//
+//nolint:funlen,gocognit,gocyclo,revive,cyclop // It needs a better breakdown.
+func (r *repository) modifyUser(
+	ctx context.Context, usr *User, profilePicture *multipart.FileHeader, reassignmentStrategy reassignment.StrategyName,
+) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "update user failed because context failed")
 	}
@@ -25,15 +36,12 @@ func (r *repository) ModifyUser(ctx context.Context, usr *User, profilePicture *
 	if err != nil {
 		return errors.Wrapf(err, "get user %v failed", usr.ID)
 	}
-	notRandom := (usr.RandomReferredBy == nil || !*usr.RandomReferredBy)
+	notRandom := (usr.RandomReferredBy == nil || !*usr.RandomReferredBy) && reassignmentStrategy == ""
 	if oldUsr.ReferredBy != "" && oldUsr.ReferredBy != oldUsr.ID && usr.ReferredBy != "" && usr.ReferredBy != oldUsr.ReferredBy && notRandom {
 		return errors.Errorf("changing the referredBy a second time is not allowed")
 	}
-	if false {
-		if oldUsr.MiningBlockchainAccountAddress != "" && oldUsr.MiningBlockchainAccountAddress != oldUsr.ID &&
-			usr.MiningBlockchainAccountAddress != "" && usr.MiningBlockchainAccountAddress != oldUsr.MiningBlockchainAccountAddress {
-			return errors.Errorf("changing the miningBlockchainAccountAddress a second time is not allowed")
-		}
+	if err = r.verifyBlockchainAccountAddresses(ctx, oldUsr, usr); err != nil {
+		return errors.Wrapf(err, "invalid blockchain account addresses for userID:%v", usr.ID)
 	}
 	lu := lastUpdatedAt(ctx)
 	if lu != nil && oldUsr.UpdatedAt.UnixNano() != lu.UnixNano() {
@@ -42,13 +50,14 @@ func (r *repository) ModifyUser(ctx context.Context, usr *User, profilePicture *
 	if usr.Country != "" && !r.IsValid(usr.Country) {
 		return ErrInvalidCountry
 	}
+	usr.City = devicemetadata.NormalizeCity(usr.City)
 	if usr.Language != "" && oldUsr.Language == usr.Language {
 		usr.Language = ""
 	}
 	if usr.LastPingCooldownEndedAt != nil && oldUsr.LastPingCooldownEndedAt != nil && oldUsr.LastPingCooldownEndedAt.Equal(*usr.LastPingCooldownEndedAt.Time) {
 		usr.LastPingCooldownEndedAt = nil
 	}
-	usr.UpdatedAt = time.Now()
+	usr.UpdatedAt = versioning.Now()
 	if profilePicture != nil {
 		if profilePicture.Header.Get("Reset") == "true" {
 			profilePicture.Filename = RandomDefaultProfilePictureName()
@@ -61,11 +70,11 @@ func (r *repository) ModifyUser(ctx context.Context, usr *User, profilePicture *
 			return errors.Wrapf(err, "failed to upload profile picture for userID:%v", usr.ID)
 		}
 	}
-	agendaBefore, agendaContactIDsForUpdate, uniqueAgendaContactIDsForSend, err := r.findAgendaContactIDs(ctx, usr)
+	agendaContactIDsForUpdate, uniqueAgendaContactIDsForSend, err := r.findAgendaContactIDs(ctx, usr)
 	if err != nil {
 		return errors.Wrapf(err, "can't find agenda contact ids for user:%v", usr.ID)
 	}
-	sql, params := usr.genSQLUpdate(ctx, agendaContactIDsForUpdate)
+	sql, params := usr.genSQLUpdate(ctx, agendaContactIDsForUpdate, reassignmentStrategy != "")
 	noOpNoOfParams := 1 + 1
 	if lu != nil {
 		noOpNoOfParams++
@@ -76,36 +85,47 @@ func (r *repository) ModifyUser(ctx context.Context, usr *User, profilePicture *
 
 		return nil
 	}
-	if updatedRowsCount, tErr := storage.Exec(ctx, r.db, sql, params...); tErr != nil || updatedRowsCount == 0 {
-		_, tErr = detectAndParseDuplicateDatabaseError(tErr)
-		if tErr == nil && updatedRowsCount == 0 {
-			return ErrRaceCondition
-		}
-
-		return errors.Wrapf(tErr, "failed to update user %#v", usr)
-	}
-	bkpUsr := *oldUsr
-	if profilePicture != nil {
-		bkpUsr.ProfilePictureURL = RandomDefaultProfilePictureName()
+	us := &UserSnapshot{
+		User:                           r.sanitizeUser(oldUsr.override(usr)),
+		Before:                         r.sanitizeUser(oldUsr),
+		ReferredByReassignmentStrategy: reassignmentStrategy,
 	}
-	if sErr := runConcurrently(ctx, r.sendContactMessage, uniqueAgendaContactIDsForSend); sErr != nil {
-		rollbackSQL, rollBackParams := bkpUsr.genSQLUpdate(ctx, agendaBefore)
-		rollBackParams[1] = bkpUsr.UpdatedAt.Time
-		_, rErr := storage.Exec(ctx, r.db, rollbackSQL, rollBackParams...)
+	if err = storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		if updatedRowsCount, tErr := storage.Exec(ctx, conn, sql, params...); tErr != nil || updatedRowsCount == 0 {
+			_, tErr = detectAndParseDuplicateDatabaseError(tErr)
+			if tErr == nil && updatedRowsCount == 0 {
+				return ErrRaceCondition
+			}
 
-		return errors.Wrapf(multierror.Append(rErr, sErr).ErrorOrNil(), "can't send contacts message for userID:%v", usr.ID)
-	}
+			return errors.Wrapf(tErr, "failed to update user %#v", usr)
+		}
+		if vErr := r.verifyUsernameChange(ctx, conn, oldUsr, usr); vErr != nil {
+			return vErr
+		}
+		if hErr := r.insertUsernameHistory(ctx, conn, oldUsr, usr); hErr != nil {
+			return errors.Wrapf(hErr, "failed to record username change for userID:%v", usr.ID)
+		}
+		for _, contact := range uniqueAgendaContactIDsForSend {
+			if cErr := r.enqueueContactMessage(ctx, conn, contact); cErr != nil {
+				return errors.Wrapf(cErr, "can't enqueue contacts message for userID:%v", usr.ID)
+			}
+		}
+		if agendaContactIDsForUpdate != nil {
+			if _, cErr := r.incrementContactsSyncVersion(ctx, conn, usr.ID); cErr != nil {
+				return errors.Wrapf(cErr, "can't increment contacts sync version for userID:%v", usr.ID)
+			}
+		}
+		if aErr := r.insertUserAuditLogEntry(ctx, conn, us); aErr != nil {
+			return errors.Wrapf(aErr, "failed to record audit log entry for userID:%v", usr.ID)
+		}
 
-	us := &UserSnapshot{User: r.sanitizeUser(oldUsr.override(usr)), Before: r.sanitizeUser(oldUsr)}
-	if err = r.sendUserSnapshotMessage(ctx, us); err != nil {
-		rollbackSQL, rollBackParams := bkpUsr.genSQLUpdate(ctx, agendaBefore)
-		rollBackParams[1] = bkpUsr.UpdatedAt.Time
-		_, rollbackErr := storage.Exec(ctx, r.db, rollbackSQL, rollBackParams...)
+		return errors.Wrapf(r.enqueueUserSnapshotMessage(ctx, conn, us), "failed to enqueue updated user snapshot message %#v", us)
+	}); err != nil {
+		if errors.Is(err, ErrRaceCondition) {
+			return ErrRaceCondition
+		}
 
-		return multierror.Append( //nolint:wrapcheck // Not needed.
-			errors.Wrapf(err, "failed to send updated user snapshot message %#v", us),
-			errors.Wrapf(rollbackErr, "failed to replace user to previous value, due to rollback, prev:%#v", bkpUsr),
-		).ErrorOrNil()
+		return errors.Wrapf(err, "failed to modify user %#v", usr)
 	}
 	*usr = *us.User
 	r.sanitizeUserForUI(usr)
@@ -139,13 +159,82 @@ func (u *User) override(user *User) *User {
 	usr.PhoneNumber = mergeStringField(u.PhoneNumber, user.PhoneNumber)
 	usr.PhoneNumberHash = mergeStringField(u.PhoneNumberHash, user.PhoneNumberHash)
 	usr.BlockchainAccountAddress = mergeStringField(u.BlockchainAccountAddress, user.BlockchainAccountAddress)
+	if usr.BlockchainAccountAddress != u.BlockchainAccountAddress {
+		usr.BlockchainAccountAddressVerifiedAt = nil
+	}
 	usr.MiningBlockchainAccountAddress = mergeStringField(u.MiningBlockchainAccountAddress, user.MiningBlockchainAccountAddress)
 
 	return usr
 }
 
+// The addresses are normalized in place. An already set mining address can be changed only as often as the configured policy allows
+// and, if the policy requires it, only after the change was confirmed via email.
+func (r *repository) verifyBlockchainAccountAddresses(ctx context.Context, oldUsr, usr *User) error {
+	var err error
+	if usr.BlockchainAccountAddress != "" && usr.BlockchainAccountAddress != usr.ID {
+		if usr.BlockchainAccountAddress, _, err = r.blockchainAddresses.Normalize(usr.BlockchainAccountAddress); err != nil {
+			return errors.Wrap(err, "invalid blockchainAccountAddress")
+		}
+	}
+	if usr.MiningBlockchainAccountAddress == "" {
+		return nil
+	}
+	if usr.MiningBlockchainAccountAddress != usr.ID {
+		if usr.MiningBlockchainAccountAddress, _, err = r.blockchainAddresses.Normalize(usr.MiningBlockchainAccountAddress); err != nil {
+			return errors.Wrap(err, "invalid miningBlockchainAccountAddress")
+		}
+	}
+	confirmationRequired, err := r.verifyMiningBlockchainAccountAddressChange(oldUsr, usr.MiningBlockchainAccountAddress)
+	if err != nil || !confirmationRequired {
+		return err
+	}
+	if confirmed, _, cErr := r.blockchainAddresses.Normalize(confirmedMiningBlockchainAccountAddress(ctx)); cErr != nil ||
+		confirmed != usr.MiningBlockchainAccountAddress {
+		return ErrMiningBlockchainAccountAddressChangeNotConfirmed
+	}
+
+	return nil
+}
+
+// IsMiningBlockchainAccountAddressChangeConfirmationRequired tells if the change of the mining address of the user to the provided one
+// has to be confirmed via email first. It fails if the change isn't allowed at all.
+func (r *repository) IsMiningBlockchainAccountAddressChangeConfirmationRequired(ctx context.Context, userID UserID, address string) (bool, error) {
+	if ctx.Err() != nil {
+		return false, errors.Wrap(ctx.Err(), "context failed")
+	}
+	oldUsr, err := r.getUserByID(ctx, userID)
+	if err != nil {
+		return false, errors.Wrapf(err, "get user %v failed", userID)
+	}
+	if address != userID {
+		if address, _, err = r.blockchainAddresses.Normalize(address); err != nil {
+			return false, errors.Wrap(err, "invalid miningBlockchainAccountAddress")
+		}
+	}
+	confirmationRequired, err := r.verifyMiningBlockchainAccountAddressChange(oldUsr, address)
+	if err == nil && confirmationRequired && oldUsr.Email == "" {
+		err = errors.Wrapf(ErrMiningBlockchainAccountAddressChangeNotConfirmed, "userID:%v has no email to confirm it with", userID)
+	}
+
+	return confirmationRequired, err
+}
+
+func (r *repository) verifyMiningBlockchainAccountAddressChange(oldUsr *User, normalizedAddress string) (confirmationRequired bool, err error) {
+	if oldUsr.MiningBlockchainAccountAddress == "" || oldUsr.MiningBlockchainAccountAddress == oldUsr.ID ||
+		normalizedAddress == oldUsr.MiningBlockchainAccountAddress {
+		return false, nil
+	}
+	policy := &r.cfg.MiningBlockchainAccountAddressChange
+	if changedAt := oldUsr.MiningBlockchainAccountAddressChangedAt; policy.Cooldown > 0 && changedAt != nil &&
+		time.Now().Before(changedAt.Add(policy.Cooldown)) {
+		return false, errors.Wrapf(ErrMiningBlockchainAccountAddressChangeCooldown, "next change allowed after %v", changedAt.Add(policy.Cooldown))
+	}
+
+	return policy.RequireConfirmedEmail, nil
+}
+
 //nolint:funlen,gocognit,gocyclo,revive,cyclop // Because it's a big unitary SQL processing logic.
-func (u *User) genSQLUpdate(ctx context.Context, agendaUserIDs []UserID) (sql string, params []any) {
+func (u *User) genSQLUpdate(ctx context.Context, agendaUserIDs []UserID, referredByReassigned bool) (sql string, params []any) {
 	params = make([]any, 0)
 	params = append(params, u.ID, u.UpdatedAt.Time)
 
@@ -173,7 +262,11 @@ func (u *User) genSQLUpdate(ctx context.Context, agendaUserIDs []UserID) (sql st
 	}
 	if u.ReferredBy != "" {
 		params = append(params, u.ReferredBy)
-		sql += fmt.Sprintf(", REFERRED_BY = CASE WHEN $%[2]v THEN $%[1]v ELSE COALESCE(NULLIF(REFERRED_BY,ID),$%[1]v) END", nextIndex, nextIndex+1)
+		if referredByReassigned {
+			sql += fmt.Sprintf(", REFERRED_BY = $%v", nextIndex)
+		} else {
+			sql += fmt.Sprintf(", REFERRED_BY = CASE WHEN $%[2]v THEN $%[1]v ELSE COALESCE(NULLIF(REFERRED_BY,ID),$%[1]v) END", nextIndex, nextIndex+1)
+		}
 		if u.RandomReferredBy == nil {
 			falseVal := false
 			u.RandomReferredBy = &falseVal
@@ -241,12 +334,14 @@ func (u *User) genSQLUpdate(ctx context.Context, agendaUserIDs []UserID) (sql st
 	}
 	if u.BlockchainAccountAddress != "" {
 		params = append(params, u.BlockchainAccountAddress)
-		sql += fmt.Sprintf(", BLOCKCHAIN_ACCOUNT_ADDRESS = $%v", nextIndex)
+		sql += fmt.Sprintf(`, BLOCKCHAIN_ACCOUNT_ADDRESS = $%[1]v,
+			BLOCKCHAIN_ACCOUNT_ADDRESS_VERIFIED_AT = (CASE WHEN BLOCKCHAIN_ACCOUNT_ADDRESS = $%[1]v THEN BLOCKCHAIN_ACCOUNT_ADDRESS_VERIFIED_AT ELSE NULL END)`, nextIndex) //nolint:lll // .
 		nextIndex++
 	}
 	if u.MiningBlockchainAccountAddress != "" {
 		params = append(params, u.MiningBlockchainAccountAddress)
-		sql += fmt.Sprintf(", MINING_BLOCKCHAIN_ACCOUNT_ADDRESS = $%v", nextIndex)
+		sql += fmt.Sprintf(`, MINING_BLOCKCHAIN_ACCOUNT_ADDRESS = $%[1]v,
+			MINING_BLOCKCHAIN_ACCOUNT_ADDRESS_CHANGED_AT = (CASE WHEN MINING_BLOCKCHAIN_ACCOUNT_ADDRESS = $%[1]v THEN MINING_BLOCKCHAIN_ACCOUNT_ADDRESS_CHANGED_AT ELSE $2 END)`, nextIndex) //nolint:lll // .
 		nextIndex++
 	}
 	if u.KYCStepsLastUpdatedAt != nil {
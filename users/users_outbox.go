@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/outbox"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/log"
+)
+
+func (r *repository) enqueueOutboxMessage(ctx context.Context, conn storage.Execer, topic, key string, value []byte) error {
+	return errors.Wrapf(outbox.Enqueue(ctx, conn, topic, key, value), "failed to enqueue outbox message for topic:%v, key:%v", topic, key)
+}
+
+func (p *processor) startOutboxRelay(ctx context.Context) {
+	ticker := stdlibtime.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			const deadline = 30 * stdlibtime.Second
+			reqCtx, cancel := context.WithTimeout(ctx, deadline)
+			for {
+				relayed, err := outbox.Relay(reqCtx, p.db, p.mb, outboxRelayBatchSize)
+				log.Error(errors.Wrap(err, "failed to relay outbox messages"))
+				if err != nil || relayed == 0 {
+					break
+				}
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+func (r *repository) GetReferralTree(ctx context.Context, userID string, maxDepth, limit uint64, cursor Cursor) (*ReferralTree, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "failed to get referral tree because of context failed")
+	}
+	pageCur, err := pagination.Decode(cursor)
+	if err == nil && pageCur != nil && (pageCur.CreatedAt == nil || pageCur.ID == "" || pageCur.Rank <= 0) {
+		err = errors.Wrapf(ErrInvalidCursor, "cursor:%v is missing depth, createdAt or id", cursor)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid referral tree cursor for userID:%v", userID)
+	}
+	rows, err := r.getReferralTreeRows(ctx, userID, maxDepth, limit, pageCur)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get referral tree for userID:%v, maxDepth:%v", userID, maxDepth)
+	}
+	tree := &ReferralTree{Levels: make([]*ReferralTreeLevel, 0, maxDepth), Nodes: make([]*ReferralTreeNode, 0, len(rows))}
+	var last *referralTreeRow
+	for _, row := range rows {
+		if row.IDX == 0 {
+			tree.Levels = append(tree.Levels, &ReferralTreeLevel{UserCount: UserCount{Total: row.Total, Active: row.ActiveCount}, Depth: row.Depth})
+
+			continue
+		}
+		tree.Nodes = append(tree.Nodes, row.ReferralTreeNode)
+		last = row
+	}
+	if limit > 0 && uint64(len(tree.Nodes)) == limit {
+		tree.NextCursor = pagination.Encode(&pageCursor{CreatedAt: last.CreatedAt, ID: last.ID, Rank: int64(last.Depth)})
+	}
+
+	return tree, nil
+}
+
+// The recursive walk over users.referred_by, starting from the T1 referrals of $1 and stopping at depth $2.
+// It keeps track of the visited path, to protect against referral cycles.
+// The users pending deletion are walked through, so that their referrals are still part of the tree, but they're not returned.
+const referralTreeCTE = `WITH RECURSIVE tree AS (
+				SELECT u.id,
+					   u.referred_by,
+					   u.username,
+					   u.profile_picture_name,
+					   u.created_at,
+					   u.last_mining_ended_at,
+					   u.pending_deletion_at,
+					   1 				 AS depth,
+					   ARRAY[$1, u.id] 	 AS path
+				FROM users u
+				WHERE u.referred_by = $1
+				  AND u.id != $1
+				  AND u.username != u.id
+				  AND u.referred_by != u.id
+				  AND $2 > 0
+				UNION ALL
+				SELECT u.id,
+					   u.referred_by,
+					   u.username,
+					   u.profile_picture_name,
+					   u.created_at,
+					   u.last_mining_ended_at,
+					   u.pending_deletion_at,
+					   tree.depth + 1 	 AS depth,
+					   tree.path || u.id AS path
+				FROM tree
+					JOIN users u
+					  ON u.referred_by = tree.id
+				WHERE tree.depth < $2
+				  AND u.username != u.id
+				  AND u.referred_by != u.id
+				  AND NOT u.id = ANY(tree.path)
+			),
+			visible_tree AS MATERIALIZED (
+				SELECT *
+				FROM tree
+				WHERE pending_deletion_at IS NULL
+			)`
+
+// The tree is walked only once per request: the first rows are the levels (idx = 0), followed by the requested page of nodes (idx = 1).
+func (r *repository) getReferralTreeRows(
+	ctx context.Context, userID string, maxDepth, limit uint64, pageCur *pageCursor,
+) ([]*referralTreeRow, error) {
+	args := []any{userID, maxDepth, limit, time.Now().Time}
+	var keysetCondition string
+	if pageCur != nil {
+		keysetCondition = "WHERE depth > $5 OR (depth = $5 AND (created_at, id) < ($6, $7))"
+		args = append(args, pageCur.Rank, pageCur.CreatedAt.Time, pageCur.ID)
+	}
+	sql := fmt.Sprintf(`%[1]v
+		SELECT 0 																				 AS idx,
+			   depth,
+			   COUNT(1) 																		 AS total,
+			   COALESCE(SUM(CASE 
+								WHEN COALESCE(last_mining_ended_at, to_timestamp(0)) > $4 
+									THEN 1 
+								ELSE 0 
+							END), 0) 															 AS active_count,
+			   to_timestamp(0) 																	 AS active,
+			   '' 																				 AS id,
+			   '' 																				 AS username,
+			   '' 																				 AS profile_picture_name,
+			   '' 																				 AS referred_by,
+			   to_timestamp(0)::timestamp 														 AS created_at
+		FROM visible_tree
+		GROUP BY depth
+
+		UNION ALL
+
+		SELECT * FROM (
+			SELECT 1 										   AS idx,
+				   depth,
+				   0 										   AS total,
+				   0 										   AS active_count,
+				   COALESCE(last_mining_ended_at, to_timestamp(0)) AS active,
+				   id,
+				   username,
+				   %[2]v 									   AS profile_picture_name,
+				   referred_by,
+				   created_at
+			FROM visible_tree
+			%[3]v
+			ORDER BY depth ASC, created_at DESC, id DESC
+			LIMIT $3
+		) nodes
+		ORDER BY idx ASC, depth ASC, created_at DESC, id DESC`,
+		referralTreeCTE, r.pictureClient.SQLAliasDownloadURL(`profile_picture_name`), keysetCondition)
+	rows, err := storage.Select[referralTreeRow](ctx, r.db, sql, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to select referral tree for userID:%v", userID)
+	}
+
+	return rows, nil
+}
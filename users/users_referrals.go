@@ -5,24 +5,32 @@ package users
 import (
 	"context"
 	"fmt"
-	"math"
 	"math/rand"
 	"strconv"
-	"strings"
 	stdlibtime "time"
 
 	"github.com/pkg/errors"
 
+	"github.com/ice-blockchain/eskimo/users/internal/pagination"
 	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
 	"github.com/ice-blockchain/wintr/log"
 	"github.com/ice-blockchain/wintr/time"
 )
 
 //nolint:funlen // It has a long SQL, it's better to be within the same method.
-func (r *repository) GetReferrals(ctx context.Context, userID string, referralType ReferralType, limit, offset uint64) (*Referrals, error) {
+func (r *repository) GetReferrals(
+	ctx context.Context, userID string, referralType ReferralType, limit, offset uint64, cursor Cursor,
+) (*Referrals, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "failed to get referrals because of context failed")
 	}
+	pageCur, err := pagination.Decode(cursor)
+	if err == nil && pageCur != nil && (pageCur.CreatedAt == nil || pageCur.ID == "") {
+		err = errors.Wrapf(ErrInvalidCursor, "cursor:%v is missing createdAt or id", cursor)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid referrals cursor for userID:%v", userID)
+	}
 	totalAndActiveColumns := `  CAST(COALESCE(SUM(1), 0) AS text) 												   	AS id,
 								CAST(COALESCE(SUM(CASE 
 											WHEN COALESCE(referrals.last_mining_ended_at, to_timestamp(0)) > $4 
@@ -36,7 +44,8 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 			 JOIN USERS referrals
 					ON (referrals.referred_by = u.id OR u.referred_by = referrals.id)
                    AND referrals.username != referrals.id
-                   AND referrals.referred_by != referrals.id`
+                   AND referrals.referred_by != referrals.id
+                   AND referrals.pending_deletion_at IS NULL`
 	case Tier2Referrals:
 		referralTypeJoin = `
 			 JOIN USERS t1
@@ -48,11 +57,12 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 							ON referrals.referred_by = t1.ID
 							AND referrals.id != t1.id
                    			AND referrals.username != referrals.id
-						    AND referrals.referred_by != referrals.id`
+						    AND referrals.referred_by != referrals.id
+						    AND referrals.pending_deletion_at IS NULL`
 	case TeamReferrals:
-		result, err := r.getTeamReferrals(ctx, userID, limit, offset)
-		if err != nil {
-			return nil, errors.Wrapf(err, "can't get team referrals for userID:%v", userID)
+		result, tErr := r.getTeamReferrals(ctx, userID, limit, offset, pageCur)
+		if tErr != nil {
+			return nil, errors.Wrapf(tErr, "can't get team referrals for userID:%v", userID)
 		}
 
 		return result, nil
@@ -63,6 +73,7 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 					AND referrals.id = ANY(u.agenda_contact_user_ids)
                     AND referrals.username != referrals.id
 					AND referrals.referred_by != referrals.id
+					AND referrals.pending_deletion_at IS NULL
 					AND u.id != referrals.id`
 		totalAndActiveColumns = `'0' 																   				AS id,
 								 '0' 																   				AS username,`
@@ -72,6 +83,20 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 	if referralType != ContactsReferrals {
 		referralTypeJoinSumAgg = referralTypeJoin
 	}
+	contactRank := `(CASE
+						WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL 
+								AND referrals.id = ANY(u.agenda_contact_user_ids)
+								AND COALESCE(referrals.phone_number,'') != ''
+							THEN 1
+						ELSE 0
+					 END)`
+	args := []any{userID, referralType, offset, time.Now().Time, limit}
+	var keysetCondition string
+	if pageCur != nil {
+		keysetCondition = fmt.Sprintf("AND (%v, referrals.created_at, referrals.id) < ($6, $7, $8)", contactRank)
+		args[2] = uint64(0)
+		args = append(args, pageCur.Rank, pageCur.CreatedAt.Time, pageCur.ID)
+	}
 	sql := fmt.Sprintf(`
 		SELECT  FALSE																		   						AS verified, 
 				to_timestamp(0)																		   				AS active, 
@@ -82,7 +107,9 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 				''																					   				AS profile_picture_name, 
 				''																					   				AS country, 
 				''																					   				AS city, 
-				''																					   				AS referral_type 
+				''																					   				AS referral_type,
+				to_timestamp(0)::timestamp															   				AS created_at,
+				0																					   				AS contact_rank
 		FROM USERS u
 				%[4]v
 		WHERE u.id = $1
@@ -99,7 +126,9 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 			   X.profile_picture_name 					 											   				AS profile_picture_name,
 			   X.country,
 			   '' AS city,
-			   $2 AS referral_type
+			   $2 AS referral_type,
+			   X.created_at,
+			   X.contact_rank
 		FROM (SELECT  
 				(referrals.kyc_step_passed >= %[5]v AND qs.user_id IS NOT NULL AND qs.ended_at is not null AND qs.ended_successfully = true)	AS verified,
 				COALESCE(referrals.last_mining_ended_at, to_timestamp(0))              				   				AS last_mining_ended_at,
@@ -121,26 +150,20 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 					ELSE ''
 				 END)                                                                                  				AS phone_number_,
 				%[1]v                                              									   				AS profile_picture_name,
-				referrals.created_at                                                                   				AS created_at
+				referrals.created_at                                                                   				AS created_at,
+				%[6]v																				   				AS contact_rank
 				FROM USERS u
 						%[2]v
 				LEFT JOIN quiz_sessions qs
 					   ON qs.user_id = referrals.id
 				WHERE u.id = $1
-				ORDER BY ((CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(u.agenda_contact_user_ids)
-								THEN referrals.phone_number
-								ELSE ''
-				 		   END) != ''
-						  AND 
-						  (CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(u.agenda_contact_user_ids)
-						  		THEN referrals.phone_number
-					  			ELSE ''
-					 	   END) != null) DESC,
-						 referrals.created_at DESC
+				%[7]v
+				ORDER BY contact_rank DESC,
+						 referrals.created_at DESC,
+						 referrals.id DESC
 				LIMIT $5 OFFSET $3
-			 ) X`, r.pictureClient.SQLAliasDownloadURL(`referrals.profile_picture_name`), referralTypeJoin, totalAndActiveColumns, referralTypeJoinSumAgg, LivenessDetectionKYCStep) //nolint:lll // .
-	args := []any{userID, referralType, offset, time.Now().Time, limit}
-	result, err := storage.Select[MinimalUserProfile](ctx, r.db, sql, args...)
+			 ) X`, r.pictureClient.SQLAliasDownloadURL(`referrals.profile_picture_name`), referralTypeJoin, totalAndActiveColumns, referralTypeJoinSumAgg, LivenessDetectionKYCStep, contactRank, keysetCondition) //nolint:lll // .
+	result, err := storage.Select[cursoredMinimalUserProfile](ctx, r.db, sql, args...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to select for all t1 referrals of userID:%v + their new random referralID", userID)
 	}
@@ -162,18 +185,56 @@ func (r *repository) GetReferrals(ctx context.Context, userID string, referralTy
 		active, err = strconv.ParseUint(result[0].Username, 10, 64)
 		log.Panic(err)
 	}
+	res, nextCursor := toReferralsPage(result[1:], limit)
 
 	return &Referrals{
 		UserCount: UserCount{
 			Total:  total,
 			Active: active,
 		},
-		Referrals: result[1:],
+		Referrals:  res,
+		NextCursor: nextCursor,
 	}, nil
 }
 
+func toReferralsPage(rows []*cursoredMinimalUserProfile, limit uint64) (res []*MinimalUserProfile, nextCursor Cursor) {
+	res = make([]*MinimalUserProfile, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, row.MinimalUserProfile)
+	}
+	if limit > 0 && uint64(len(rows)) == limit {
+		last := rows[len(rows)-1]
+		nextCursor = pagination.Encode(&pageCursor{CreatedAt: last.CreatedAt, ID: last.ID, Rank: last.ContactRank})
+	}
+
+	return res, nextCursor
+}
+
 //nolint:funlen // Long SQL with field list.
-func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit, offset uint64) (*Referrals, error) {
+func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit, offset uint64, pageCur *pageCursor) (*Referrals, error) {
+	contactRank := `(CASE
+						WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL 
+								AND referrals.id = ANY(t0.agenda_contact_user_ids)
+								AND COALESCE(referrals.phone_number,'') != ''
+							THEN 1
+						ELSE 0
+					 END)`
+	args := []any{userID, offset, time.Now().Time, limit}
+	var t1KeysetCondition, t2KeysetCondition string
+	if pageCur != nil {
+		args[1] = uint64(0)
+		args = append(args, pageCur.Rank, pageCur.CreatedAt.Time, pageCur.ID)
+		keysetCondition := fmt.Sprintf("AND (%v, referrals.created_at, referrals.id) < ($5, $6, $7)", contactRank)
+		switch pageCur.Tier {
+		case 1:
+			t1KeysetCondition = keysetCondition
+		case 2: //nolint:gomnd // It's the T2 tier.
+			t1KeysetCondition = "AND FALSE"
+			t2KeysetCondition = keysetCondition
+		default:
+			return nil, errors.Wrapf(ErrInvalidCursor, "invalid team tier:%v", pageCur.Tier)
+		}
+	}
 	sql := fmt.Sprintf(`
 		SELECT * FROM (
 			SELECT  		
@@ -195,18 +256,22 @@ func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit,
 				''																					   				AS profile_picture_name, 
 				''																					   				AS country, 
 				''																					   				AS city,
-				'' 																									AS referral_type 
+				'' 																									AS referral_type,
+				to_timestamp(0)::timestamp																			AS created_at,
+				0																									AS contact_rank
 			FROM USERS t0
 			JOIN USERS t1
 				ON (t1.referred_by = t0.id OR t0.referred_by = t1.id)
 					AND t1.username != t1.id
 					AND t1.referred_by != t1.id
+					AND t1.pending_deletion_at IS NULL
 			LEFT JOIN USERS t2
 				ON t2.referred_by = t1.ID
 				AND t2.id != t1.id
 				AND t2.username != t2.id
 				AND t2.referred_by != t2.id
 				AND t2.referred_by != t0.referred_by
+				AND t2.pending_deletion_at IS NULL
 			WHERE t0.id = $1
 
 			UNION ALL
@@ -236,23 +301,20 @@ func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit,
 					%[1]v                                              									   			AS profile_picture_name,
 					referrals.country,
 					'' AS city,
-					'T1' AS tier_type
+					'T1' AS tier_type,
+					referrals.created_at,
+					%[4]v AS contact_rank
 					FROM users t0
 					JOIN USERS referrals
 							ON (referrals.referred_by = t0.id OR t0.referred_by = referrals.id)
 						AND referrals.username != referrals.id
 						AND referrals.referred_by != referrals.id
+						AND referrals.pending_deletion_at IS NULL
 					WHERE t0.id = $1
-					ORDER BY ((CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(t0.agenda_contact_user_ids)
-										THEN referrals.phone_number
-										ELSE ''
-									END) != ''
-								AND 
-								(CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(t0.agenda_contact_user_ids)
-										THEN referrals.phone_number
-										ELSE ''
-									END) != null) DESC,
-									referrals.created_at DESC
+					%[2]v
+					ORDER BY contact_rank DESC,
+							 referrals.created_at DESC,
+							 referrals.id DESC
 				) X
 
 				UNION 
@@ -281,7 +343,9 @@ func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit,
 						%[1]v                                              									   AS profile_picture_name,
 						referrals.country,
 						'' AS city,
-						'T2' AS tier_type
+						'T2' AS tier_type,
+						referrals.created_at,
+						%[4]v AS contact_rank
 						FROM users t0
 						JOIN users t1
 							ON t1.referred_by = t0.id
@@ -290,25 +354,22 @@ func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit,
 						WHERE t0.id = $1
 						AND referrals.referred_by != referrals.id
 						AND referrals.username != referrals.id
-						ORDER BY ((CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(t0.agenda_contact_user_ids)
-											THEN referrals.phone_number
-											ELSE ''
-										END) != ''
-									AND 
-									(CASE WHEN NULLIF(referrals.phone_number_hash,'') IS NOT NULL AND referrals.id = ANY(t0.agenda_contact_user_ids)
-											THEN referrals.phone_number
-											ELSE ''
-										END) != null) DESC,
-										referrals.created_at DESC
+						AND referrals.pending_deletion_at IS NULL
+						%[3]v
+						ORDER BY contact_rank DESC,
+							 referrals.created_at DESC,
+							 referrals.id DESC
 				) Y
+				ORDER BY idx ASC, contact_rank DESC, created_at DESC, id DESC
 				LIMIT $4 OFFSET $2
 			) Z
 		) W
-		ORDER BY idx ASC`, r.pictureClient.SQLAliasDownloadURL(`referrals.profile_picture_name`))
-	args := []any{userID, offset, time.Now().Time, limit}
+		ORDER BY idx ASC, contact_rank DESC, created_at DESC, id DESC`, r.pictureClient.SQLAliasDownloadURL(`referrals.profile_picture_name`), t1KeysetCondition, t2KeysetCondition, contactRank) //nolint:lll // .
 	type orderedMinimalUserProfile struct {
 		*MinimalUserProfile
-		IDX uint64
+		CreatedAt   *time.Time
+		IDX         uint64
+		ContactRank int64
 	}
 	result, err := storage.Select[orderedMinimalUserProfile](ctx, r.db, sql, args...)
 	if err != nil {
@@ -344,79 +405,56 @@ func (r *repository) getTeamReferrals(ctx context.Context, userID string, limit,
 	for _, row := range result {
 		res = append(res, row.MinimalUserProfile)
 	}
+	var nextCursor Cursor
+	if limit > 0 && uint64(len(res)-1) == limit {
+		last := result[len(result)-1]
+		nextCursor = pagination.Encode(&pageCursor{CreatedAt: last.CreatedAt, ID: last.ID, Tier: int64(last.IDX), Rank: last.ContactRank})
+	}
 
 	return &Referrals{
 		UserCount: UserCount{
 			Total:  totalT1 + totalT2,
 			Active: activeT1 + activeT2,
 		},
-		Referrals: res[1:],
+		Referrals:  res[1:],
+		NextCursor: nextCursor,
 	}, nil
 }
 
-//nolint:funlen // Long SQL with field list.
-func (r *repository) GetReferralAcquisitionHistory(ctx context.Context, userID string) ([]*ReferralAcquisition, error) {
+func (r *repository) GetReferralAcquisitionHistory(ctx context.Context, userID string, days uint64) ([]*ReferralAcquisition, error) {
 	if ctx.Err() != nil {
 		return nil, errors.Wrap(ctx.Err(), "failed to get acquisition history because context failed")
 	}
-
+	if days == 0 || days > r.cfg.MaxDaysReferralsHistory {
+		days = r.cfg.MaxDaysReferralsHistory
+	}
 	now := time.Now()
-	nowMidnight := time.New(time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour))
-	sql := `
-		SELECT *
-		from referral_acquisition_history
-			where user_id = $1`
-	type resultFromQuery struct {
-		Date          *time.Time `db:"date"`
-		UserID        UserID     `db:"user_id"`
-		T1            int64      `db:"t1"`
-		T2            int64      `db:"t2"`
-		T1Today       int64      `db:"t1_today"`
-		T2Today       int64      `db:"t2_today"`
-		T1TodayMinus1 int64      `db:"t1_today_minus_1"`
-		T2TodayMinus1 int64      `db:"t2_today_minus_1"`
-		T1TodayMinus2 int64      `db:"t1_today_minus_2"`
-		T2TodayMinus2 int64      `db:"t2_today_minus_2"`
-		T1TodayMinus3 int64      `db:"t1_today_minus_3"`
-		T2TodayMinus3 int64      `db:"t2_today_minus_3"`
-		T1TodayMinus4 int64      `db:"t1_today_minus_4"`
-		T2TodayMinus4 int64      `db:"t2_today_minus_4"`
-	}
-	res, err := storage.Get[resultFromQuery](ctx, r.db, sql, userID)
+	nowMidnight := now.In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
+	sql := `SELECT date, t1, t2
+			FROM referral_acquisition_history_per_day
+			WHERE user_id = $1
+			  AND date > $2`
+	type referralAcquisitionPerDay struct {
+		Date *time.Time `db:"date"`
+		T1   int64      `db:"t1"`
+		T2   int64      `db:"t2"`
+	}
+	res, err := storage.Select[referralAcquisitionPerDay](ctx, r.db, sql, userID, nowMidnight.AddDate(0, 0, -int(days)))
 	if err != nil {
-		if storage.IsErr(err, storage.ErrNotFound) {
-			return []*ReferralAcquisition{}, nil
-		}
-
-		return nil, errors.Wrapf(err, "failed to select ReferralAcquisition history for userID:%v", userID)
-	}
-	elapsedDaysSinceLastRefCountsUpdate := int(nowMidnight.Sub(*res.Date.Time).Nanoseconds() / int64(hoursInOneDay*stdlibtime.Hour))
-	if elapsedDaysSinceLastRefCountsUpdate > maxDaysReferralsHistory {
-		elapsedDaysSinceLastRefCountsUpdate = maxDaysReferralsHistory
-	}
-	result := make([]*ReferralAcquisition, maxDaysReferralsHistory) //nolint:makezero // We're know size for sure.
-	orderOfDaysT1 := []int64{res.T1Today, res.T1TodayMinus1, res.T1TodayMinus2, res.T1TodayMinus3, res.T1TodayMinus4}
-	orderOfDaysT2 := []int64{res.T2Today, res.T2TodayMinus1, res.T2TodayMinus2, res.T2TodayMinus3, res.T2TodayMinus4}
-	for ind := 0; ind < elapsedDaysSinceLastRefCountsUpdate; ind++ {
-		var date *time.Time
-		if ind != 0 {
-			date = time.New(now.AddDate(0, 0, -ind))
-		} else {
-			date = now
-		}
-		result[ind] = &ReferralAcquisition{
-			Date: date,
-			T1:   uint64(0),
-			T2:   uint64(0),
-		}
-	}
-	for day := elapsedDaysSinceLastRefCountsUpdate; day < maxDaysReferralsHistory; day++ {
-		date := time.New(now.AddDate(0, 0, -day))
-		result[day] = &ReferralAcquisition{
-			Date: date,
-			T1:   uint64(orderOfDaysT1[day-elapsedDaysSinceLastRefCountsUpdate]),
-			T2:   uint64(orderOfDaysT2[day-elapsedDaysSinceLastRefCountsUpdate]),
+		return nil, errors.Wrapf(err, "failed to select ReferralAcquisition history for userID:%v, days:%v", userID, days)
+	}
+	countsPerDay := make(map[int]*referralAcquisitionPerDay, len(res))
+	for _, row := range res {
+		countsPerDay[int(nowMidnight.Sub(row.Date.In(stdlibtime.UTC))/(hoursInOneDay*stdlibtime.Hour))] = row
+	}
+	result := make([]*ReferralAcquisition, 0, days)
+	for day := 0; day < int(days); day++ {
+		acquisition := &ReferralAcquisition{Date: time.New(now.AddDate(0, 0, -day))}
+		if counts, found := countsPerDay[day]; found {
+			acquisition.T1 = uint64(counts.T1)
+			acquisition.T2 = uint64(counts.T2)
 		}
+		result = append(result, acquisition)
 	}
 
 	return result, nil
@@ -424,11 +462,11 @@ func (r *repository) GetReferralAcquisitionHistory(ctx context.Context, userID s
 
 func (r *repository) updateReferralCount(ctx context.Context, msgTimestamp stdlibtime.Time, us *UserSnapshot) error {
 	var userID, referredBy string
-	dayBetweenCreationAndDeletion := int16(-1)
+	var deletedUserCreatedAt *time.Time
 	if us.Before != nil {
 		userID = us.Before.ID
 		referredBy = us.Before.ReferredBy
-		dayBetweenCreationAndDeletion = int16(msgTimestamp.Sub(*us.Before.CreatedAt.Time) / (hoursInOneDay * stdlibtime.Hour))
+		deletedUserCreatedAt = us.Before.CreatedAt
 	}
 
 	if us.User != nil {
@@ -437,7 +475,7 @@ func (r *repository) updateReferralCount(ctx context.Context, msgTimestamp stdli
 		}
 		userID = us.ID
 		referredBy = us.ReferredBy
-		dayBetweenCreationAndDeletion = -1
+		deletedUserCreatedAt = nil
 	}
 	_, err := storage.Exec(ctx, r.db, `INSERT INTO processed_referrals(user_id, referred_by, processed_at, deleted) VALUES ($1, $2, $3, $4)`,
 		userID, referredBy, msgTimestamp, us.User == nil)
@@ -447,172 +485,64 @@ func (r *repository) updateReferralCount(ctx context.Context, msgTimestamp stdli
 		return errors.Wrapf(err, "failed to verify uniqueness of user referral message")
 	}
 
-	return errors.Wrapf(r.incrementOrDecrementReferralCount(ctx, referredBy, dayBetweenCreationAndDeletion),
+	return errors.Wrapf(r.incrementOrDecrementReferralCount(ctx, referredBy, deletedUserCreatedAt),
 		"failed to update referrals count for userID:%v", userID)
 }
 
-//nolint:gocritic,revive // Struct is private, so we return values from it.
-func (r *repository) getCurrentReferralCount(ctx context.Context, userID UserID) (t1, t2 int64, date, T0Date *time.Time, T0UserID UserID, err error) {
-	type refCount struct {
-		Date     *time.Time `db:"date"`
-		T0Date   *time.Time `db:"t0_date"`
-		T0UserID UserID     `db:"t0_user_id"`
-		T1       int64
-		T2       int64
-	}
-	sql := `
-	WITH t0 AS (
-		SELECT id FROM (SELECT referred_by AS id FROM users WHERE id = $1 AND referred_by != id
-						UNION ALL SELECT NULL as id) tmp LIMIT 1
-	) SELECT
-		  COALESCE(refs.t1_today,0) AS t1,
-		  COALESCE(t0_refs.t2_today,0) AS t2,
-		  refs.date,
-		  COALESCE(t0.id,'') as t0_user_id,
-		  t0_refs.date as t0_date
-	FROM t0
-		LEFT JOIN referral_acquisition_history refs ON refs.user_id = $1
-		LEFT JOIN referral_acquisition_history t0_refs ON t0_refs.user_id = t0.id
-	`
-	count, err := storage.Get[refCount](ctx, r.db, sql, userID)
-	if err != nil {
-		return 0, 0, nil, nil, "", errors.Wrapf(err, "failed to read current referral count for userID:%v", userID)
-	}
-	if count.Date == nil {
-		return 0, count.T2, nil, count.T0Date, count.T0UserID, errors.Wrapf(storage.ErrNotFound, "failed to read current referral count for userID:%v", userID)
-	}
-
-	return count.T1, count.T2, count.Date, count.T0Date, count.T0UserID, nil
-}
-
-//nolint:funlen // Long SQL.
-func (r *repository) incrementOrDecrementReferralCount(ctx context.Context, userID UserID, daysBetweenCreationAndDeletion int16) error {
+// It increments the T1 count of userID and the T2 count of its referrer (T0), both the totals and the ones for today.
+// If a referral was deleted, it decrements them instead, the per day ones being the ones of the day the referral was created in.
+func (r *repository) incrementOrDecrementReferralCount(ctx context.Context, userID UserID, deletedUserCreatedAt *time.Time) error {
 	if ctx.Err() != nil {
 		return errors.Wrapf(ctx.Err(), "ctx failed: ")
 	}
-	op := "+"
-	opToday := "+"
-	if daysBetweenCreationAndDeletion >= 0 {
-		op = "-"
-		opToday = "*" // Multiply by 1 = noop.
-		if daysBetweenCreationAndDeletion == 0 {
-			opToday = "-"
-		}
-	}
-	nowMidnight := time.New(time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour))
-	t1, t2, storedDate, t0Date, t0UserID, err := r.getCurrentReferralCount(ctx, userID)
-	if err != nil && !storage.IsErr(err, storage.ErrNotFound) {
-		return errors.Wrapf(err, "failed to read current value")
-	}
-	if storedDate == nil {
-		storedDate = nowMidnight
-	}
-	if t0Date == nil {
-		t0Date = nowMidnight
-	}
-	shiftDays := int16(math.Min(float64(nowMidnight.Sub(*storedDate.Time).Nanoseconds()/int64(hoursInOneDay*stdlibtime.Hour)), maxDaysReferralsHistory))
-	shiftDaysT0 := int16(math.Min(float64(nowMidnight.Sub(*t0Date.Time).Nanoseconds()/int64(hoursInOneDay*stdlibtime.Hour)), maxDaysReferralsHistory))
-
-	shiftDatesFields := r.genShiftDaysSQLFields(shiftDays, shiftDaysT0, daysBetweenCreationAndDeletion)
-	t0UpdateTrigger := ""
-	if t0UserID != "" {
-		t0UpdateTrigger = ",\n\t\t\t       ($6, $5, 1, 1, 1, 1)"
-	}
-	sql := fmt.Sprintf(`INSERT INTO referral_acquisition_history(user_id, date, t1, t1_today, t2, t2_today)
-			VALUES ($1, $5, 1, 1, 0, 0)
-			%[2]v
-		ON CONFLICT (user_id) DO UPDATE 
-		SET
-			t1_today = (CASE
-				WHEN referral_acquisition_history.user_id = $1 and referral_acquisition_history.date = $5 THEN GREATEST(referral_acquisition_history.t1_today %[4]v 1,0)
-				WHEN referral_acquisition_history.user_id = $1 and referral_acquisition_history.date != $5 THEN GREATEST(0 %[4]v 1, 0)
-				WHEN referral_acquisition_history.date = $5 THEN referral_acquisition_history.t1_today 
-			    ELSE 0 END),
-			t1 = (CASE
-				WHEN referral_acquisition_history.user_id = $1 THEN GREATEST(referral_acquisition_history.t1 %[3]v 1,0)
-				ELSE referral_acquisition_history.t1 END),
-			t2_today = (CASE
-			WHEN referral_acquisition_history.user_id = $6 AND referral_acquisition_history.date = $5 THEN GREATEST(referral_acquisition_history.t2_today %[4]v 1, 0)
-			WHEN referral_acquisition_history.user_id = $6 AND referral_acquisition_history.date != $5 THEN GREATEST(0 %[4]v 1, 0)
-			WHEN referral_acquisition_history.date = $5 THEN referral_acquisition_history.t2_today
-		    ELSE 0 END),
-			t2 = (CASE
-			WHEN referral_acquisition_history.user_id = $6 THEN GREATEST(referral_acquisition_history.t2 %[3]v 1,0)
-			ELSE referral_acquisition_history.t2 END),
-			date = $5
-		    %[1]v
-		WHERE (referral_acquisition_history.date = $2 AND referral_acquisition_history.user_id = $1 AND referral_acquisition_history.t1_today = $3)
-  		   OR (referral_acquisition_history.date = $7 AND referral_acquisition_history.user_id = $6 AND referral_acquisition_history.t2_today = $4)`,
-		shiftDatesFields,
-		t0UpdateTrigger,
-		op,
-		opToday,
-	)
-	rowsUpdated, err := storage.Exec(ctx, r.db, sql, userID, storedDate.Time, t1, t2, nowMidnight.Time, t0UserID, t0Date.Time)
-	if rowsUpdated == 0 || storage.IsErr(err, storage.ErrNotFound) {
-		return r.incrementOrDecrementReferralCount(ctx, userID, daysBetweenCreationAndDeletion)
-	}
-
-	return errors.Wrapf(err, "failed to increment referral counts for userID %v", userID)
-}
-
-func (r *repository) genShiftDaysSQLFields(shiftDays, t0ShiftDays, daysBetweenUserCreationAndDeletion int16) string {
-	if shiftDays == 0 && t0ShiftDays == 0 && (daysBetweenUserCreationAndDeletion <= 0 || daysBetweenUserCreationAndDeletion >= maxDaysReferralsHistory) {
-		return ""
+	delta := int64(1)
+	nowMidnight := time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
+	day := nowMidnight
+	if deletedUserCreatedAt != nil {
+		delta = -1
+		day = deletedUserCreatedAt.In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
 	}
+	trackDay := day.After(nowMidnight.AddDate(0, 0, -int(r.cfg.MaxDaysReferralsHistory)))
 
-	return fmt.Sprintf(", %v,\n %v",
-		r.generateReferralsShiftDaysSQL(Tier1Referrals, shiftDays, t0ShiftDays, daysBetweenUserCreationAndDeletion),
-		r.generateReferralsShiftDaysSQL(Tier2Referrals, shiftDays, t0ShiftDays, daysBetweenUserCreationAndDeletion),
-	)
-}
-
-func (r *repository) generateReferralsShiftDaysSQL(refType ReferralType, daysLag, t0daysLag, daysBetweenUserCreationAndDeletion int16) string {
-	updateStatements := []string{}
-	for currDay := int16(1); currDay <= maxDaysReferralsHistory-1; currDay++ {
-		diff := currDay - daysLag
-		diffT0 := currDay - t0daysLag
-		decrementDueToUserDeletion := ""
-		if currDay == daysBetweenUserCreationAndDeletion {
-			decrementDueToUserDeletion = " - 1"
-		}
-		targetFieldT0 := r.findTargetField(refType, diffT0, t0daysLag, currDay, decrementDueToUserDeletion)
-		targetField := r.findTargetField(refType, diff, daysLag, currDay, decrementDueToUserDeletion)
-		field := ""
-		switch {
-		case targetFieldT0 != "" && targetField != "":
-			field = fmt.Sprintf(
-				"%[1]v_today_minus_%[2]v = (CASE WHEN referral_acquisition_history.user_id = $1 THEN GREATEST(%[3]v %[4]v,0) ELSE GREATEST(%[5]v %[4]v,0) END)",
-				refType, currDay, targetField, decrementDueToUserDeletion, targetFieldT0,
-			)
-		case targetFieldT0 != "":
-			field = fmt.Sprintf("%v_today_minus_%v = GREATEST(%v %v,0)", refType, currDay, targetFieldT0, decrementDueToUserDeletion)
-		case targetField != "":
-			field = fmt.Sprintf("%v_today_minus_%v = GREATEST(%v %v,0)", refType, currDay, targetField, decrementDueToUserDeletion)
+	return errors.Wrapf(storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		if err := r.addReferralCount(ctx, conn, userID, "t1", delta, day, trackDay); err != nil {
+			return errors.Wrapf(err, "failed to update t1 referral count")
 		}
-		if field == "" {
-			continue
+		sql := `SELECT referred_by FROM users WHERE id = $1 AND referred_by != id`
+		t0, err := storage.Get[struct{ ReferredBy UserID }](ctx, conn, sql, userID)
+		if err != nil {
+			if storage.IsErr(err, storage.ErrNotFound) {
+				return nil
+			}
+
+			return errors.Wrapf(err, "failed to get T0 of userID:%v", userID)
 		}
-		updateStatements = append(updateStatements, field)
-	}
 
-	return strings.Join(updateStatements, ",\n")
+		return errors.Wrapf(r.addReferralCount(ctx, conn, t0.ReferredBy, "t2", delta, day, trackDay),
+			"failed to update t2 referral count of T0:%v", t0.ReferredBy)
+	}), "failed to increment referral counts by %v for userID %v", delta, userID)
 }
 
-func (*repository) findTargetField(refType ReferralType, diff, daysLag, currDay int16, decrementDueToUserDeletion string) string {
-	targetField := fmt.Sprintf("referral_acquisition_history.%v_today_minus_%v", refType, diff)
-	if diff == 0 {
-		targetField = fmt.Sprintf("referral_acquisition_history.%v_today", refType)
-	} else if diff < 0 {
-		targetField = "0"
+func (*repository) addReferralCount(
+	ctx context.Context, conn storage.Execer, userID UserID, column string, delta int64, day stdlibtime.Time, trackDay bool,
+) error {
+	sql := fmt.Sprintf(`INSERT INTO referral_acquisition_history(user_id, %[1]v) VALUES ($1, GREATEST($2, 0))
+		ON CONFLICT (user_id) DO UPDATE
+		SET %[1]v = GREATEST(referral_acquisition_history.%[1]v + $2, 0)`, column)
+	if _, err := storage.Exec(ctx, conn, sql, userID, delta); err != nil {
+		return errors.Wrapf(err, "failed to update total %v referral count for userID:%v", column, userID)
+	}
+	if !trackDay {
+		return nil
 	}
-	if daysLag == 0 && decrementDueToUserDeletion != "" { // If we dont need to shift, but to sub 1, due to user deletion, subtract it from the same field.
-		targetField = fmt.Sprintf("referral_acquisition_history.%v_today_minus_%v", refType, currDay)
-	} else if daysLag == 0 {
-		return ""
+	sql = fmt.Sprintf(`INSERT INTO referral_acquisition_history_per_day(user_id, date, %[1]v) VALUES ($1, $3, GREATEST($2, 0))
+		ON CONFLICT (user_id, date) DO UPDATE
+		SET %[1]v = GREATEST(referral_acquisition_history_per_day.%[1]v + $2, 0)`, column)
+	if _, err := storage.Exec(ctx, conn, sql, userID, delta, day); err != nil {
+		return errors.Wrapf(err, "failed to update %v referral count for userID:%v, day:%v", column, userID, day)
 	}
 
-	return targetField
+	return nil
 }
 
 func (p *processor) startOldProcessedReferralsCleaner(ctx context.Context) {
@@ -625,6 +555,8 @@ func (p *processor) startOldProcessedReferralsCleaner(ctx context.Context) {
 			const deadline = 30 * stdlibtime.Second
 			reqCtx, cancel := context.WithTimeout(ctx, deadline)
 			log.Error(errors.Wrap(p.deleteOldProcessedReferrals(reqCtx), "failed to deleteOldTrackedActions"))
+			log.Error(errors.Wrap(p.deleteExpiredReferralAcquisitionHistory(reqCtx), "failed to deleteExpiredReferralAcquisitionHistory"))
+			log.Error(errors.Wrap(p.deleteExpiredActiveUsersPerCountry(reqCtx), "failed to deleteExpiredActiveUsersPerCountry"))
 			cancel()
 		case <-ctx.Done():
 			return
@@ -644,13 +576,28 @@ func (p *processor) deleteOldProcessedReferrals(ctx context.Context) error {
 	return nil
 }
 
+func (p *processor) deleteExpiredReferralAcquisitionHistory(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(ctx.Err(), "unexpected deadline")
+	}
+	nowMidnight := time.Now().In(stdlibtime.UTC).Truncate(hoursInOneDay * stdlibtime.Hour)
+	sql := `DELETE FROM referral_acquisition_history_per_day WHERE date <= $1`
+	if _, err := storage.Exec(ctx, p.db, sql, nowMidnight.AddDate(0, 0, -int(p.cfg.MaxDaysReferralsHistory))); err != nil {
+		return errors.Wrap(err, "failed to delete expired data from referral_acquisition_history_per_day")
+	}
+
+	return nil
+}
+
 func (r *repository) deleteReferralAcquisitionHistory(ctx context.Context, userID UserID) error {
 	if ctx.Err() != nil {
 		return errors.Wrap(ctx.Err(), "unexpected deadline")
 	}
-	sql := `DELETE FROM referral_acquisition_history WHERE user_id = $1`
-	if _, err := storage.Exec(ctx, r.db, sql, userID); err != nil {
-		return errors.Wrapf(err, "failed to delete referral acquisition history for userID:%v", userID)
+	for _, table := range []string{"referral_acquisition_history", "referral_acquisition_history_per_day"} {
+		sql := fmt.Sprintf(`DELETE FROM %v WHERE user_id = $1`, table)
+		if _, err := storage.Exec(ctx, r.db, sql, userID); err != nil {
+			return errors.Wrapf(err, "failed to delete %v for userID:%v", table, userID)
+		}
 	}
 
 	return nil
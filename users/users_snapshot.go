@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	messagebroker "github.com/ice-blockchain/wintr/connectors/message_broker"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
 )
 
 func (s *userSnapshotSource) Process(ctx context.Context, msg *messagebroker.Message) error {
@@ -27,25 +28,18 @@ func (s *userSnapshotSource) Process(ctx context.Context, msg *messagebroker.Mes
 	return multierror.Append( //nolint:wrapcheck // Not needed.
 		errors.Wrap(s.updateTotalUsersCount(ctx, usr), "failed to updateTotalUsersCount"),
 		errors.Wrap(s.updateTotalUsersPerCountryCount(ctx, usr), "failed to updateTotalUsersPerCountryCount"),
+		errors.Wrap(s.updateTotalUsersPerCityCount(ctx, usr), "failed to updateTotalUsersPerCityCount"),
 		errors.Wrap(s.updateReferralCount(ctx, msg.Timestamp, usr), "failed to updateReferralCount"),
 		errors.Wrap(s.deleteUserTracking(ctx, usr), "failed to deleteUserTracking"),
 	).ErrorOrNil()
 }
 
-func (r *repository) sendTombstonedUserMessage(ctx context.Context, userID string) error {
-	msg := &messagebroker.Message{
-		Headers: map[string]string{"producer": "eskimo"},
-		Key:     userID,
-		Topic:   r.cfg.MessageBroker.Topics[1].Name,
-	}
-	responder := make(chan error, 1)
-	defer close(responder)
-	r.mb.SendMessage(ctx, msg, responder)
-
-	return errors.Wrapf(<-responder, "failed to send tombstoned user message to broker")
+func (r *repository) enqueueTombstonedUserMessage(ctx context.Context, conn storage.Execer, userID string) error {
+	return errors.Wrapf(r.enqueueOutboxMessage(ctx, conn, r.cfg.MessageBroker.Topics[1].Name, userID, nil),
+		"failed to enqueue tombstoned user message")
 }
 
-func (r *repository) sendUserSnapshotMessage(ctx context.Context, user *UserSnapshot) error {
+func (r *repository) enqueueUserSnapshotMessage(ctx context.Context, conn storage.Execer, user *UserSnapshot) error {
 	valueBytes, err := json.MarshalContext(ctx, user)
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal %#v", user)
@@ -58,16 +52,6 @@ func (r *repository) sendUserSnapshotMessage(ctx context.Context, user *UserSnap
 		key = user.ID
 	}
 
-	msg := &messagebroker.Message{
-		Headers: map[string]string{"producer": "eskimo"},
-		Key:     key,
-		Topic:   r.cfg.MessageBroker.Topics[1].Name,
-		Value:   valueBytes,
-	}
-
-	responder := make(chan error, 1)
-	defer close(responder)
-	r.mb.SendMessage(ctx, msg, responder)
-
-	return errors.Wrapf(<-responder, "failed to send user snapshot message to broker")
+	return errors.Wrapf(r.enqueueOutboxMessage(ctx, conn, r.cfg.MessageBroker.Topics[1].Name, key, valueBytes),
+		"failed to enqueue user snapshot message")
 }
@@ -54,7 +54,7 @@ func TestMain(m *testing.M) {
 }
 
 func afterConnectorsStarted(ctx context.Context) connectorsfixture.ContextErrClose {
-	usersProcessor = StartProcessor(ctx, func() {})
+	usersProcessor = StartProcessor(ctx, func() {}, nil)
 	usersRepository = usersProcessor
 	userSnapshotProcessor = &userSnapshotSource{processor: usersProcessor.(*processor)} //nolint:forcetypeassert // We know for sure.
 
@@ -117,7 +117,7 @@ func requireAllAPIMethodsFailIfRepositoryOrProcessorAreStopped(cctx context.Cont
 			return usersRepository.ReplaceDeviceMetadata(ctx, metadata, bogusIP)
 		},
 		func(ctx context.Context) error {
-			_, err := usersRepository.GetReferrals(ctx, "bogusUserID", Tier1Referrals, 1, 0)
+			_, err := usersRepository.GetReferrals(ctx, "bogusUserID", Tier1Referrals, 1, 0, "")
 			return err
 		},
 		func(ctx context.Context) error {
@@ -133,11 +133,11 @@ func requireAllAPIMethodsFailIfRepositoryOrProcessorAreStopped(cctx context.Cont
 			return err
 		},
 		func(ctx context.Context) error {
-			_, err := usersRepository.GetUsers(ctx, "bogus", 1, 0)
+			_, err := usersRepository.GetUsers(ctx, "bogus", 1, 0, "")
 			return err
 		},
 		func(ctx context.Context) error {
-			_, err := usersRepository.GetUserByUsername(ctx, "bogususername")
+			_, err := usersRepository.GetUserByUsername(ctx, "bogususername", false)
 			return err
 		},
 		func(ctx context.Context) error {
@@ -145,7 +145,7 @@ func requireAllAPIMethodsFailIfRepositoryOrProcessorAreStopped(cctx context.Cont
 			return err
 		},
 		func(ctx context.Context) error {
-			_, err := usersRepository.GetTopCountries(ctx, "us", 1, 0)
+			_, err := usersRepository.GetTopCountries(ctx, "us", TotalCountryStatisticsSortBy, 1, 1, 0)
 			return err
 		},
 	}
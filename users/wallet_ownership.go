@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: ice License 1.0
+
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	stdlibtime "time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ice-blockchain/eskimo/users/internal/blockchain/address"
+	"github.com/ice-blockchain/eskimo/users/internal/versioning"
+	storage "github.com/ice-blockchain/wintr/connectors/storage/v2"
+	"github.com/ice-blockchain/wintr/time"
+)
+
+// CreateWalletOwnershipChallenge issues a new challenge for the address, replacing the previous one of the user, if any.
+func (r *repository) CreateWalletOwnershipChallenge(ctx context.Context, userID UserID, address string) (*WalletOwnershipChallenge, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "create wallet ownership challenge failed because context failed")
+	}
+	normalized, _, err := r.blockchainAddresses.Normalize(address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address for userID:%v", userID)
+	}
+	nonce := make([]byte, walletOwnershipChallengeNonceLength)
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	challenge := &WalletOwnershipChallenge{
+		ExpiresAt: time.New(time.Now().Add(r.cfg.WalletOwnershipChallengeTTL)),
+		Address:   normalized,
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	challenge.Message = challenge.message(userID)
+	sql := `INSERT INTO wallet_ownership_challenges (expires_at, user_id, address, nonce)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id)
+			DO UPDATE
+				SET expires_at = EXCLUDED.expires_at,
+					address    = EXCLUDED.address,
+					nonce      = EXCLUDED.nonce`
+	if _, err = storage.Exec(ctx, r.db, sql, challenge.ExpiresAt.Time, userID, challenge.Address, challenge.Nonce); err != nil {
+		if storage.IsErr(err, storage.ErrRelationNotFound) {
+			err = ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "failed to insert wallet ownership challenge for userID:%v", userID)
+	}
+
+	return challenge, nil
+}
+
+// VerifyWalletOwnership consumes the challenge and, if the signature is valid, stores the address as the verified `blockchainAccountAddress`.
+func (r *repository) VerifyWalletOwnership(ctx context.Context, userID UserID, proof *WalletOwnershipProof) (*User, error) {
+	if ctx.Err() != nil {
+		return nil, errors.Wrap(ctx.Err(), "verify wallet ownership failed because context failed")
+	}
+	normalized, _, err := r.blockchainAddresses.Normalize(proof.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid address for userID:%v", userID)
+	}
+	challenge, err := r.consumeWalletOwnershipChallenge(ctx, userID, normalized)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to consume wallet ownership challenge for userID:%v", userID)
+	}
+	signed := &address.SignedMessage{Message: challenge.message(userID), Signature: proof.Signature, PublicKey: proof.PublicKey, StateInit: proof.StateInit}
+	if err = r.blockchainAddresses.VerifySignature(normalized, signed); err != nil {
+		return nil, errors.Wrapf(err, "wallet ownership not proven for userID:%v", userID)
+	}
+	var usr *User
+	if err = storage.DoInTransaction(ctx, r.db, func(conn storage.QueryExecer) error {
+		oldUsr, tErr := getUserByIDForUpdate(ctx, conn, userID)
+		if tErr != nil {
+			return errors.Wrapf(tErr, "get user %v failed", userID)
+		}
+		usr = new(User)
+		*usr = *oldUsr
+		usr.UpdatedAt = versioning.Now()
+		usr.BlockchainAccountAddress, usr.BlockchainAccountAddressVerifiedAt = normalized, usr.UpdatedAt
+		sql := `UPDATE users
+				SET blockchain_account_address = $2,
+					blockchain_account_address_verified_at = $3,
+					updated_at = $3
+				WHERE id = $1`
+		if updatedRowsCount, uErr := storage.Exec(ctx, conn, sql, userID, normalized, usr.UpdatedAt.Time); uErr != nil || updatedRowsCount == 0 {
+			_, uErr = detectAndParseDuplicateDatabaseError(uErr)
+			if uErr == nil && updatedRowsCount == 0 {
+				return ErrRaceCondition
+			}
+
+			return errors.Wrapf(uErr, "failed to update verified blockchain account address for userID:%v", userID)
+		}
+		us := &UserSnapshot{User: r.sanitizeUser(usr), Before: r.sanitizeUser(oldUsr)}
+		if aErr := r.insertUserAuditLogEntry(ctx, conn, us); aErr != nil {
+			return errors.Wrapf(aErr, "failed to insert user audit log entry for userID:%v", userID)
+		}
+
+		return errors.Wrapf(r.enqueueUserSnapshotMessage(ctx, conn, us), "failed to enqueue updated user snapshot message %#v", us)
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify wallet ownership for userID:%v", userID)
+	}
+	r.sanitizeUserForUI(usr)
+
+	return usr, nil
+}
+
+// The challenge is deleted before the signature is verified, so every nonce can be tried only once.
+func (r *repository) consumeWalletOwnershipChallenge(ctx context.Context, userID UserID, address string) (*WalletOwnershipChallenge, error) {
+	sql := `DELETE FROM wallet_ownership_challenges
+			WHERE user_id = $1
+			RETURNING *`
+	challenge, err := storage.ExecOne[struct {
+		ExpiresAt stdlibtime.Time `db:"expires_at"`
+		UserID    UserID          `db:"user_id"`
+		Address   string          `db:"address"`
+		Nonce     string          `db:"nonce"`
+	}](ctx, r.db, sql, userID)
+	if err != nil {
+		if storage.IsErr(err, storage.ErrNotFound) {
+			err = ErrWalletOwnershipChallengeNotFound
+		}
+
+		return nil, errors.Wrapf(err, "failed to delete wallet ownership challenge for userID:%v", userID)
+	}
+	if challenge.Address != address || time.Now().After(challenge.ExpiresAt) {
+		return nil, errors.Wrapf(ErrWalletOwnershipChallengeNotFound, "no valid challenge for address %v", address)
+	}
+
+	return &WalletOwnershipChallenge{ExpiresAt: time.New(challenge.ExpiresAt), Address: challenge.Address, Nonce: challenge.Nonce}, nil
+}
+
+func (c *WalletOwnershipChallenge) message(userID UserID) string {
+	return fmt.Sprintf(walletOwnershipChallengeMessageFormat, c.Address, userID, c.Nonce, c.ExpiresAt.Format(stdlibtime.RFC3339))
+}